@@ -0,0 +1,56 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const bundleHelp = `
+This command consists of multiple subcommands to create and install bundles.
+
+A bundle is a single file holding a chart, the charts of all of its
+dependencies, their provenance files and the list of the container images the
+chart uses. It is meant for installing charts in disconnected environments,
+where chart repositories and registries cannot be reached.
+
+Bundles are tar archives of OCI image layouts, in which charts are stored as in
+OCI registries. Besides 'helm bundle install', the path of a bundle can be given
+to 'helm install', 'helm upgrade', 'helm template' and 'helm pull' in place of
+a chart.
+`
+
+func newBundleCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "create and install chart bundles",
+		Long:  bundleHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(
+		newBundleCreateCmd(cfg, out),
+		newBundleInstallCmd(cfg, out),
+	)
+
+	return cmd
+}
@@ -0,0 +1,162 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+const bundleCreateDesc = `
+Create a bundle of a chart.
+
+The chart is a chart reference, a path to a packaged chart, a path to an
+unpacked chart directory or a URL, as for 'helm install'. The dependencies of
+chart directories are first rebuilt from Chart.lock, as with
+'helm dependency build', fetching their provenance files when available.
+
+The bundle holds the chart, the charts of all of its dependencies and their
+provenance files, along with the list of the container images found in the
+manifests of the chart rendered with the given values.
+
+    $ helm bundle create ./mychart -f production.yaml
+    $ helm bundle create oci://example.com/charts/nginx --version 1.2.3 --verify
+
+If --verify is set, the chart, unless it is a directory, and the dependencies
+fetched for it MUST have provenance files, and MUST pass all verification steps.
+
+The bundle is written to '<name>-<version>.bundle.tar' in the current directory
+unless '--destination' is set. The creation time it records is the current time,
+or the time set by the SOURCE_DATE_EPOCH environment variable.
+`
+
+func newBundleCreateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewBundleCreate(cfg)
+	locate := action.NewInstall(cfg)
+	valueOpts := &values.Options{}
+	var kubeVersion string
+	var extraAPIs []string
+	var skipRefresh bool
+
+	cmd := &cobra.Command{
+		Use:   "create [CHART]",
+		Short: "create a bundle of a chart and its dependencies",
+		Long:  bundleCreateDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListCharts(toComplete, true)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if kubeVersion != "" {
+				parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
+				if err != nil {
+					return fmt.Errorf("invalid kube version '%s': %s", kubeVersion, err)
+				}
+				client.KubeVersion = parsedKubeVersion
+			}
+			client.APIVersions = chartutil.VersionSet(extraAPIs)
+			created, ok, err := sourceDateEpoch()
+			if err != nil {
+				return err
+			}
+			if ok {
+				client.Created = created
+			}
+
+			registryClient, err := newRegistryClient(locate.CertFile, locate.KeyFile, locate.CaFile,
+				locate.InsecureSkipTLSverify, locate.PlainHTTP)
+			if err != nil {
+				return fmt.Errorf("missing registry client: %w", err)
+			}
+			locate.SetRegistryClient(registryClient)
+
+			if locate.Version == "" && locate.Devel {
+				locate.Version = ">0.0.0-0"
+			}
+			// Chart directories cannot be verified, unlike their dependencies.
+			verify := locate.Verify
+			if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+				locate.Verify = false
+			}
+			cp, err := locate.ChartPathOptions.LocateChart(args[0], settings)
+			if err != nil {
+				return err
+			}
+
+			p := getter.All(settings)
+			if fi, err := os.Stat(cp); err == nil && fi.IsDir() {
+				man := &downloader.Manager{
+					Out:              out,
+					ChartPath:        cp,
+					Verify:           downloader.VerifyLater,
+					Keyring:          locate.Keyring,
+					TrustPolicy:      settings.TrustPolicy,
+					SkipUpdate:       skipRefresh,
+					KeepProvenance:   true,
+					Getters:          p,
+					Debug:            settings.Debug,
+					RegistryClient:   registryClient,
+					RepositoryConfig: settings.RepositoryConfig,
+					RepositoryCache:  settings.RepositoryCache,
+					ChartCache:       settings.ChartCache,
+					Offline:          settings.Offline,
+				}
+				if verify {
+					man.Verify = downloader.VerifyAlways
+				}
+				if err := man.Build(); err != nil {
+					return err
+				}
+			}
+
+			vals, err := valueOpts.MergeValues(p)
+			if err != nil {
+				return err
+			}
+			dest, err := client.Run(cp, vals)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Successfully created bundle and saved it to: %s\n", dest)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&client.Destination, "destination", "d", "", "path of the bundle. Defaults to <name>-<version>.bundle.tar in the current directory")
+	f.BoolVar(&skipRefresh, "skip-refresh", false, "do not refresh the local repository cache before rebuilding dependencies")
+	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
+	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
+	f.BoolVar(&locate.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
+	addValueOptionsFlags(f, valueOpts)
+	addChartPathOptionsFlags(f, &locate.ChartPathOptions)
+
+	return cmd
+}
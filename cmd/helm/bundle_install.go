@@ -0,0 +1,57 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const bundleInstallDesc = `
+Install the chart of a bundle.
+
+This is the same as 'helm install' given the path of a bundle, and takes the
+same flags. The chart is installed along with the dependencies it holds, without
+reaching any chart repository or registry.
+
+If --verify is set, the bundle MUST hold the provenance file of the chart, and
+the chart MUST pass all verification steps.
+
+    $ helm bundle install myapp ./myapp-1.2.3.bundle.tar -f production.yaml
+`
+
+func newBundleInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := newInstallCmd(cfg, out)
+	install := cmd.RunE
+
+	cmd.Use = "install [NAME] [BUNDLE]"
+	cmd.Short = "install the chart of a bundle"
+	cmd.Long = bundleInstallDesc
+	cmd.ValidArgsFunction = nil
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		if bundle := args[len(args)-1]; !action.IsBundle(bundle) {
+			return errors.Errorf("%q is not a bundle", bundle)
+		}
+		return install(c, args)
+	}
+
+	return cmd
+}
@@ -0,0 +1,143 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo/repotest"
+)
+
+func TestBundleCmd(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/testcharts/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootDir := srv.Root()
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "bundled",
+			Version:    "1.2.3",
+			Dependencies: []*chart.Dependency{
+				{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+			},
+		},
+		Templates: []*chart.File{{
+			Name: "templates/pod.yaml",
+			Data: []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: {{ .Values.image }}\n"),
+		}},
+		Raw: []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte("image: nginx:1.25\n")}},
+	}
+	if err := chartutil.SaveDir(c, rootDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repoFlags := fmt.Sprintf("--repository-config %s --repository-cache %s", filepath.Join(rootDir, "repositories.yaml"), rootDir)
+	bundle := filepath.Join(rootDir, "bundled.bundle.tar")
+	cmd := fmt.Sprintf("bundle create '%s' -d '%s' --set image=nginx:1.26 --verify --keyring testdata/helm-test-key.pub %s",
+		filepath.Join(rootDir, "bundled"), bundle, repoFlags)
+	_, out, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if expect := "Successfully created bundle and saved it to: " + bundle; !strings.Contains(out, expect) {
+		t.Errorf("expected %q in output:\n%s", expect, out)
+	}
+
+	// The provenance file of the dependency is kept for the bundle.
+	if _, err := os.Stat(filepath.Join(rootDir, "bundled", "charts", "signtest-0.1.0.tgz.prov")); err != nil {
+		t.Error(err)
+	}
+
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	layout, err := registry.LoadLayoutArchive(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charts := layout.Charts(); strings.Join(charts, ",") != "bundled:1.2.3,signtest:0.1.0" {
+		t.Errorf("unexpected charts in bundle: %v", charts)
+	}
+	if _, prov, err := layout.PullChart("signtest:0.1.0"); err != nil || prov == nil {
+		t.Errorf("expected the provenance file of signtest in the bundle, got error: %v", err)
+	}
+
+	t.Run("template from bundle", func(t *testing.T) {
+		_, out, err := executeActionCommand(fmt.Sprintf("template web '%s' %s", bundle, repoFlags))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "image: nginx:1.25") || !strings.Contains(out, `image: "alpine:3.3"`) {
+			t.Errorf("unexpected output:\n%s", out)
+		}
+	})
+
+	t.Run("pull from bundle", func(t *testing.T) {
+		dest := t.TempDir()
+		_, out, err := executeActionCommand(fmt.Sprintf("pull '%s' -d '%s' --untar %s", bundle, dest, repoFlags))
+		if err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "bundled", "charts", "signtest", "Chart.yaml")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("verify chart without provenance", func(t *testing.T) {
+		_, _, err := executeActionCommand(fmt.Sprintf("pull '%s' -d '%s' --verify --keyring testdata/helm-test-key.pub %s", bundle, t.TempDir(), repoFlags))
+		if err == nil || !strings.Contains(err.Error(), "has no provenance file for bundled:1.2.3") {
+			t.Errorf("expected missing provenance error, got: %v", err)
+		}
+	})
+
+	tests := []cmdTestCase{
+		{
+			name:   "install bundle",
+			cmd:    fmt.Sprintf("bundle install web '%s' %s", bundle, repoFlags),
+			golden: "output/bundle-install.txt",
+		},
+		{
+			name:      "install chart that is not a bundle",
+			cmd:       fmt.Sprintf("bundle install web testdata/testcharts/empty %s", repoFlags),
+			golden:    "output/bundle-install-not-bundle.txt",
+			wantError: true,
+		},
+		{
+			name:      "create bundle without chart",
+			cmd:       "bundle create",
+			golden:    "output/bundle-create-no-args.txt",
+			wantError: true,
+		},
+	}
+	runTestCmd(t, tests)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+)
+
+const cacheHelp = `
+This command consists of multiple subcommands to manage the chart cache.
+
+The chart cache holds the chart archives downloaded by 'helm pull', 'helm install'
+and 'helm dependency', along with their provenance files, keyed by the digests
+of the archives. Charts are fetched from the cache instead of their repository
+or registry when their digest is known, from the index of their repository,
+from the manifest of their registry or from the lock file of the chart
+depending on them.
+
+With the --offline flag, charts are only fetched from the cache, and are
+resolved from the cached indexes of repositories and the references they were
+downloaded from.
+
+The cache is located at $HELM_CHART_CACHE, or at the path set by --chart-cache.
+`
+
+func newCacheCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache list|prune",
+		Short: "manage the chart cache",
+		Long:  cacheHelp,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newCacheListCmd(out))
+	cmd.AddCommand(newCachePruneCmd(out))
+
+	return cmd
+}
+
+// formatSize formats a size in bytes with binary units.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
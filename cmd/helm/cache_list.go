@@ -0,0 +1,87 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/internal/chartcache"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+func newCacheListCmd(out io.Writer) *cobra.Command {
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "list the charts of the chart cache",
+		Args:    require.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			entries, err := chartcache.New(settings.ChartCache).List()
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &cacheListWriter{entries})
+		},
+	}
+
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type cacheListWriter struct {
+	entries []*chartcache.Entry
+}
+
+func (w *cacheListWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("NAME", "VERSION", "DIGEST", "SIZE", "LAST USED")
+	var total int64
+	for _, e := range w.entries {
+		table.AddRow(e.Name, e.Version, e.Digest, formatSize(e.Size), e.LastUsed.Format(time.ANSIC))
+		total += e.Size
+	}
+	if err := output.EncodeTable(out, table); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\nTotal: "+formatSize(total)+"\n")
+	return err
+}
+
+func (w *cacheListWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.list())
+}
+
+func (w *cacheListWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.list())
+}
+
+// list returns the entries to encode, an empty list rather than null when the
+// cache is empty.
+func (w *cacheListWriter) list() []*chartcache.Entry {
+	if w.entries == nil {
+		return []*chartcache.Entry{}
+	}
+	return w.entries
+}
@@ -0,0 +1,75 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/internal/chartcache"
+)
+
+const cachePruneDesc = `
+Remove the least recently used charts of the chart cache, until the cache holds
+at most the size set by --max-size.
+
+The size is a number of bytes, with an optional suffix such as 'Mi' or 'Gi'.
+Without --max-size, all the charts are removed.
+
+    $ helm cache prune --max-size 500Mi
+`
+
+func newCachePruneCmd(out io.Writer) *cobra.Command {
+	var maxSize string
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "remove the least recently used charts of the chart cache",
+		Long:  cachePruneDesc,
+		Args:  require.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			size, err := resource.ParseQuantity(maxSize)
+			if err != nil || size.Sign() < 0 {
+				return errors.Errorf("invalid size %q", maxSize)
+			}
+			removed, err := chartcache.New(settings.ChartCache).Prune(size.Value())
+			var freed int64
+			for _, e := range removed {
+				if e.Name != "" {
+					fmt.Fprintf(out, "Removed %s %s (%s)\n", e.Name, e.Version, e.Digest)
+				} else {
+					fmt.Fprintf(out, "Removed %s\n", e.Digest)
+				}
+				freed += e.Size
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Removed %d charts, freeing %s\n", len(removed), formatSize(freed))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&maxSize, "max-size", "0", "maximum size of the chart cache")
+
+	return cmd
+}
@@ -0,0 +1,138 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/internal/chartcache"
+	"helm.sh/helm/v3/pkg/repo/repotest"
+)
+
+func TestCacheCmd(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/testcharts/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	ociSrv, err := repotest.NewOCIServer(t, srv.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociSrv.Run(t)
+
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings are shared by the commands run by the tests.
+	defer func(chartCache string, offline bool) {
+		settings.ChartCache = chartCache
+		settings.Offline = offline
+	}(settings.ChartCache, settings.Offline)
+
+	cacheDir := t.TempDir()
+	run := func(args string, offline bool) (string, error) {
+		outdir := t.TempDir()
+		cmd := fmt.Sprintf("%s -d '%s' --repository-config %s --repository-cache %s --registry-config %s --chart-cache %s --offline=%t",
+			args,
+			outdir,
+			filepath.Join(srv.Root(), "repositories.yaml"),
+			srv.Root(),
+			filepath.Join(srv.Root(), "config.json"),
+			cacheDir,
+			offline,
+		)
+		_, out, err := executeActionCommand(cmd)
+		if err == nil {
+			if files, _ := filepath.Glob(filepath.Join(outdir, "*.tgz")); len(files) != 1 {
+				t.Errorf("%q: expected a chart archive in %s, got %v", args, outdir, files)
+			}
+		}
+		return out, err
+	}
+
+	pulls := []string{
+		"pull test/signtest --verify --keyring testdata/helm-test-key.pub",
+		fmt.Sprintf("pull oci://%s/u/ocitestuser/oci-dependent-chart --version 0.1.0", ociSrv.RegistryURL),
+	}
+	for _, args := range pulls {
+		if _, err := run(args, true); err == nil {
+			t.Errorf("%q: expected an error pulling an uncached chart offline", args)
+		}
+		if _, err := run(args, false); err != nil {
+			t.Fatalf("%q: %s", args, err)
+		}
+		if _, err := run(args, true); err != nil {
+			t.Errorf("%q: pulling a cached chart offline: %s", args, err)
+		}
+	}
+
+	if _, err := run("pull test/signtest --version 0.2.0", true); err == nil {
+		t.Error("expected an error pulling an uncached version offline")
+	}
+
+	_, out, err := executeActionCommand(fmt.Sprintf("cache list --chart-cache %s -o json", cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []*chartcache.Entry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 cached charts, got %d", len(entries))
+	}
+	if entries[0].Name != "oci-dependent-chart" || entries[1].Name != "signtest" || !entries[1].Provenance {
+		t.Errorf("unexpected cached charts %+v %+v", entries[0], entries[1])
+	}
+
+	_, out, err = executeActionCommand(fmt.Sprintf("cache prune --chart-cache %s", cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out, "Removed 2 charts, freeing "+formatSize(entries[0].Size+entries[1].Size)+"\n") {
+		t.Errorf("unexpected output of cache prune: %q", out)
+	}
+	if files, _ := filepath.Glob(filepath.Join(cacheDir, "sha256", "*")); len(files) != 0 {
+		t.Errorf("expected an empty chart cache, got %v", files)
+	}
+
+	if _, _, err := executeActionCommand(fmt.Sprintf("cache prune --chart-cache %s --max-size 1x", cacheDir)); err == nil {
+		t.Error("expected an error pruning with an invalid size")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	} {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
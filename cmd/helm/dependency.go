@@ -71,6 +71,20 @@ the dependency charts stored locally. The path should start with a prefix of
 If the dependency chart is retrieved locally, it is not required to have the
 repository added to helm by "helm add repo". Version matching is also supported
 for this case.
+
+The repository can also be a git repository, prefixed with "git+". The path of
+the chart in the repository follows a double slash, and the branch, tag or
+commit to use is set by the 'ref' query parameter. For example,
+
+    # Chart.yaml
+    dependencies:
+    - name: common
+      version: "^1.2.0"
+      repository: "git+https://example.com/org/charts.git//library/common?ref=v1.2.0"
+
+The ref is resolved to a commit when the dependencies are updated, and this
+commit is recorded in the lock file, so that building the dependencies always
+packages the same chart.
 `
 
 const dependencyListDesc = `
@@ -84,7 +98,7 @@ This will produce an error if the chart cannot be loaded.
 
 func newDependencyCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "dependency update|build|list",
+		Use:     "dependency update|build|list|verify|outdated",
 		Aliases: []string{"dep", "dependencies"},
 		Short:   "manage a chart's dependencies",
 		Long:    dependencyDesc,
@@ -94,6 +108,8 @@ func newDependencyCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	cmd.AddCommand(newDependencyListCmd(out))
 	cmd.AddCommand(newDependencyUpdateCmd(cfg, out))
 	cmd.AddCommand(newDependencyBuildCmd(cfg, out))
+	cmd.AddCommand(newDependencyVerifyCmd(out))
+	cmd.AddCommand(newDependencyOutdatedCmd(cfg, out))
 
 	return cmd
 }
@@ -55,15 +55,19 @@ func newDependencyBuildCmd(cfg *action.Configuration, out io.Writer) *cobra.Comm
 				chartpath = filepath.Clean(args[0])
 			}
 			man := &downloader.Manager{
-				Out:              out,
-				ChartPath:        chartpath,
-				Keyring:          client.Keyring,
-				SkipUpdate:       client.SkipRefresh,
-				Getters:          getter.All(settings),
-				RegistryClient:   cfg.RegistryClient,
-				RepositoryConfig: settings.RepositoryConfig,
-				RepositoryCache:  settings.RepositoryCache,
-				Debug:            settings.Debug,
+				Out:                    out,
+				ChartPath:              chartpath,
+				Keyring:                client.Keyring,
+				TrustPolicy:            settings.TrustPolicy,
+				SkipUpdate:             client.SkipRefresh,
+				MaxConcurrentDownloads: client.MaxConcurrentDownloads,
+				Getters:                getter.All(settings),
+				RegistryClient:         cfg.RegistryClient,
+				RepositoryConfig:       settings.RepositoryConfig,
+				RepositoryCache:        settings.RepositoryCache,
+				ChartCache:             settings.ChartCache,
+				Offline:                settings.Offline,
+				Debug:                  settings.Debug,
 			}
 			if client.Verify {
 				man.Verify = downloader.VerifyIfPossible
@@ -80,6 +84,7 @@ func newDependencyBuildCmd(cfg *action.Configuration, out io.Writer) *cobra.Comm
 	f.BoolVar(&client.Verify, "verify", false, "verify the packages against signatures")
 	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
 	f.BoolVar(&client.SkipRefresh, "skip-refresh", false, "do not refresh the local repository cache")
+	f.IntVar(&client.MaxConcurrentDownloads, "max-concurrent-downloads", client.MaxConcurrentDownloads, "maximum number of dependencies downloaded at the same time")
 
 	return cmd
 }
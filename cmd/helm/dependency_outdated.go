@@ -0,0 +1,156 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+const dependencyOutdatedDesc = `
+Report the dependencies of a chart that have newer versions available.
+
+For each dependency in 'Chart.yaml' fetched from a chart repository, an OCI
+registry or a local path, this lists the version locked in 'Chart.lock', the
+newest version matching the version constraint of the dependency ('WANTED'),
+which 'helm dependency update' would lock, and the newest version available
+('LATEST'). Pre-releases are only listed as latest versions of dependencies
+locked at a pre-release.
+
+The indexes of chart repositories are refreshed first, unless --skip-refresh is
+set, and the tags of OCI registries are listed.
+
+With --exit-code, the command fails when updates are available, which allows
+automation to act upon outdated dependencies:
+
+    $ helm dependency outdated ./mychart -o json --exit-code
+`
+
+func newDependencyOutdatedCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+	var outfmt output.Format
+	var exitCode bool
+
+	cmd := &cobra.Command{
+		Use:   "outdated CHART",
+		Short: "report the dependencies of a chart with newer versions available",
+		Long:  dependencyOutdatedDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			chartpath := "."
+			if len(args) > 0 {
+				chartpath = filepath.Clean(args[0])
+			}
+			// Keep the progress of repository updates out of structured output.
+			var progress io.Writer = os.Stderr
+			if outfmt == output.Table {
+				progress = out
+			}
+			man := &downloader.Manager{
+				Out:              progress,
+				ChartPath:        chartpath,
+				SkipUpdate:       client.SkipRefresh,
+				Getters:          getter.All(settings),
+				RegistryClient:   cfg.RegistryClient,
+				RepositoryConfig: settings.RepositoryConfig,
+				RepositoryCache:  settings.RepositoryCache,
+				ChartCache:       settings.ChartCache,
+				Offline:          settings.Offline,
+				Debug:            settings.Debug,
+			}
+			deps, err := man.Outdated()
+			if err != nil {
+				return err
+			}
+			if err := outfmt.Write(out, &dependencyOutdatedWriter{deps, client.ColumnWidth}); err != nil {
+				return err
+			}
+
+			updates := 0
+			for _, dep := range deps {
+				if dep.HasUpdate() {
+					updates++
+				}
+			}
+			if exitCode && updates > 0 {
+				return errors.Errorf("%d of %d dependencies have updates available", updates, len(deps))
+			}
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.SkipRefresh, "skip-refresh", false, "do not refresh the local repository cache")
+	f.BoolVar(&exitCode, "exit-code", false, "fail when updates are available")
+	f.UintVar(&client.ColumnWidth, "max-col-width", 80, "maximum column width for output table")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type dependencyOutdatedWriter struct {
+	deps        []*downloader.OutdatedDependency
+	columnWidth uint
+}
+
+func (w *dependencyOutdatedWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.MaxColWidth = w.columnWidth
+	table.AddRow("NAME", "REPOSITORY", "CONSTRAINT", "LOCKED", "WANTED", "LATEST")
+	for _, dep := range w.deps {
+		name := dep.Name
+		if dep.Alias != "" {
+			name = fmt.Sprintf("%s (%s)", dep.Alias, dep.Name)
+		}
+		table.AddRow(name, dep.Repository, dep.Constraint, dep.Locked, dep.Wanted, dep.Latest)
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (w *dependencyOutdatedWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.elements())
+}
+
+func (w *dependencyOutdatedWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.elements())
+}
+
+type dependencyOutdatedElement struct {
+	*downloader.OutdatedDependency
+	Update bool `json:"update"`
+}
+
+func (w *dependencyOutdatedWriter) elements() []dependencyOutdatedElement {
+	// Initialize the array so no results returns an empty array instead of null
+	elements := make([]dependencyOutdatedElement, 0, len(w.deps))
+	for _, dep := range w.deps {
+		elements = append(elements, dependencyOutdatedElement{dep, dep.HasUpdate()})
+	}
+	return elements
+}
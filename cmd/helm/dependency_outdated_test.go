@@ -0,0 +1,92 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo/repotest"
+)
+
+func TestDependencyOutdatedCmd(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/testcharts/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	rootDir := srv.Root()
+	repoFlags := fmt.Sprintf("--repository-config %s --repository-cache %s", filepath.Join(rootDir, "repositories.yaml"), rootDir)
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "outdated",
+			Version:    "0.1.0",
+			Dependencies: []*chart.Dependency{
+				{Name: "compressedchart", Version: "<0.3.0", Repository: srv.URL()},
+				{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+			},
+		},
+	}
+	if err := chartutil.SaveDir(c, rootDir); err != nil {
+		t.Fatal(err)
+	}
+	chartpath := filepath.Join(rootDir, "outdated")
+	if _, out, err := executeActionCommand(fmt.Sprintf("dependency update '%s' %s", chartpath, repoFlags)); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+
+	_, out, err := executeActionCommand(fmt.Sprintf("dependency outdated '%s' %s", chartpath, repoFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "NAME           \tREPOSITORY") || !strings.Contains(out, "\t<0.3.0    \t0.2.0 \t0.2.0 \t0.3.0") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	_, out, err = executeActionCommand(fmt.Sprintf("dependency outdated '%s' -o json --exit-code --skip-refresh %s", chartpath, repoFlags))
+	if err == nil || err.Error() != "1 of 2 dependencies have updates available" {
+		t.Errorf("expected updates error, got %v", err)
+	}
+	var deps []map[string]interface{}
+	// The output ends with the error.
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&deps); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	expect := []map[string]interface{}{
+		{"name": "compressedchart", "repository": srv.URL(), "constraint": "<0.3.0", "locked": "0.2.0", "wanted": "0.2.0", "latest": "0.3.0", "update": true},
+		{"name": "signtest", "repository": srv.URL(), "constraint": "0.1.0", "locked": "0.1.0", "wanted": "0.1.0", "latest": "0.1.0", "update": false},
+	}
+	if !reflect.DeepEqual(expect, deps) {
+		t.Errorf("expected %v, got %v", expect, deps)
+	}
+
+	_, out, err = executeActionCommand(fmt.Sprintf("dependency outdated testdata/testcharts/alpine -o json --exit-code %s", repoFlags))
+	if err != nil || strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected no dependencies, got %v: %s", err, out)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const dependencyVerifyDesc = `
+Verify the charts/ directory against the Chart.lock file.
+
+'helm dependency update' records the digest of the archive of each dependency
+it downloads in the lock file. Verify checks that the archives in the charts/
+directory are exactly the ones that were locked, without reaching any
+repository.
+
+This will produce an error if an archive is missing or does not match its
+digest, or if a dependency was locked without digest. Dependencies that are not
+fetched from repositories, such as 'file://' dependencies, are skipped.
+`
+
+func newDependencyVerifyCmd(out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+
+	cmd := &cobra.Command{
+		Use:   "verify CHART",
+		Short: "verify the charts/ directory against the Chart.lock file",
+		Long:  dependencyVerifyDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			chartpath := "."
+			if len(args) > 0 {
+				chartpath = filepath.Clean(args[0])
+			}
+			return client.VerifyLock(chartpath, out)
+		},
+	}
+
+	f := cmd.Flags()
+	f.UintVar(&client.ColumnWidth, "max-col-width", 80, "maximum column width for output table")
+	return cmd
+}
@@ -0,0 +1,144 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/internal/resolver"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// saveLockedChart saves a chart depending on signtest, along with its archive
+// in the charts directory and a lock file with the given digest, and returns
+// the path of the chart.
+func saveLockedChart(t *testing.T, name, digest string) string {
+	t.Helper()
+	dir := t.TempDir()
+	deps := []*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts"},
+		{Name: "local", Version: "0.1.0", Repository: "file://../local"},
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:   chart.APIVersionV2,
+			Name:         name,
+			Version:      "0.1.0",
+			Dependencies: deps,
+		},
+	}
+	if err := chartutil.SaveDir(c, dir); err != nil {
+		t.Fatal(err)
+	}
+	local := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "local", Version: "0.1.0"}}
+	chartpath := filepath.Join(dir, name)
+	if _, err := chartutil.Save(local, filepath.Join(chartpath, "charts")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("testdata/testcharts/signtest-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(chartpath, "charts", "signtest-0.1.0.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := []*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts", Digest: digest},
+		{Name: "local", Version: "0.1.0", Repository: "file://../local"},
+	}
+	sum, err := resolver.HashReq(deps, locked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = yaml.Marshal(&chart.Lock{Digest: sum, Dependencies: locked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(chartpath, "Chart.lock"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return chartpath
+}
+
+func TestDependencyVerifyCmd(t *testing.T) {
+	digest, err := resolver.HashArchive("testdata/testcharts/signtest-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified := saveLockedChart(t, "verified", digest)
+	mismatched := saveLockedChart(t, "mismatched", "sha256:0000")
+	unlocked := saveLockedChart(t, "unlocked", "")
+	if err := os.Remove(filepath.Join(unlocked, "Chart.lock")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []cmdTestCase{{
+		name:   "verified dependencies",
+		cmd:    fmt.Sprintf("dependency verify '%s'", verified),
+		golden: "output/dependency-verify.txt",
+	}, {
+		name:      "mismatched dependencies",
+		cmd:       fmt.Sprintf("dependency verify '%s'", mismatched),
+		golden:    "output/dependency-verify-mismatch.txt",
+		wantError: true,
+	}, {
+		name:   "no dependencies",
+		cmd:    "dependency verify testdata/testcharts/alpine",
+		golden: "output/dependency-verify-no-dependencies.txt",
+	}}
+	runTestCmd(t, tests)
+
+	if _, _, err := executeActionCommand(fmt.Sprintf("dependency verify '%s'", unlocked)); err == nil || !strings.Contains(err.Error(), "Chart.lock not found") {
+		t.Errorf("expected missing lock file error, got %v", err)
+	}
+
+	t.Run("package", func(t *testing.T) {
+		dest := t.TempDir()
+		if _, out, err := executeActionCommand(fmt.Sprintf("package '%s' -d '%s' --verify-dependencies", verified, dest)); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+		_, _, err := executeActionCommand(fmt.Sprintf("package '%s' -d '%s' --verify-dependencies", mismatched, dest))
+		if err == nil || !strings.Contains(err.Error(), "signtest (digest mismatch)") {
+			t.Errorf("expected digest mismatch error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "mismatched-0.1.0.tgz")); !os.IsNotExist(err) {
+			t.Error("expected the chart not to be packaged")
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		if _, out, err := executeActionCommand(fmt.Sprintf("lint '%s' --verify-dependencies", verified)); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+		_, out, err := executeActionCommand(fmt.Sprintf("lint '%s' --verify-dependencies", mismatched))
+		if err == nil || !strings.Contains(out, "signtest (digest mismatch)") {
+			t.Errorf("expected digest mismatch error, got %v:\n%s", err, out)
+		}
+		// The check is optional.
+		if _, out, err := executeActionCommand(fmt.Sprintf("lint '%s'", mismatched)); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	})
+}
@@ -34,6 +34,7 @@ notes, hooks, supplied values, and generated manifest file of the given release.
 
 func newGetAllCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var template string
+	var showSensitive bool
 	client := action.NewGet(cfg)
 
 	cmd := &cobra.Command{
@@ -52,13 +53,16 @@ func newGetAllCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if !showSensitive {
+				res = action.RedactRelease(res)
+			}
 			if template != "" {
 				data := map[string]interface{}{
 					"Release": res,
 				}
 				return tpl(template, data, out)
 			}
-			return output.Table.Write(out, &statusPrinter{res, true, false, false, true, false})
+			return output.Table.Write(out, &statusPrinter{res, true, false, false, true, false, true})
 		},
 	}
 
@@ -76,6 +80,7 @@ func newGetAllCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	}
 
 	f.StringVar(&template, "template", "", "go template for formatting the output, eg: {{.Release.Name}}")
+	f.BoolVar(&showSensitive, "show-sensitive", false, "show the values the chart marks as sensitive instead of redacting them")
 
 	return cmd
 }
@@ -33,6 +33,11 @@ func TestGetCmd(t *testing.T) {
 		cmd:    "get all elevated-turkey --template {{.Release.Chart.Metadata.Version}}",
 		golden: "output/get-release-template.txt",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "elevated-turkey"})},
+	}, {
+		name:   "get all redacts sensitive values",
+		cmd:    "get all thomas-guide",
+		golden: "output/get-release-sensitive.txt",
+		rels:   []*release.Release{sensitiveReleaseMock("thomas-guide")},
 	}, {
 		name:      "get all requires release name arg",
 		cmd:       "get all",
@@ -75,6 +75,7 @@ func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	}
 
 	f.BoolVarP(&client.AllValues, "all", "a", false, "dump all (computed) values")
+	f.BoolVar(&client.ShowSensitive, "show-sensitive", false, "show the values the chart marks as sensitive instead of redacting them")
 	bindOutputFlag(cmd, &outfmt)
 
 	return cmd
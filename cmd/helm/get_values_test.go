@@ -19,6 +19,7 @@ package main
 import (
 	"testing"
 
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
 )
 
@@ -39,6 +40,16 @@ func TestGetValuesCmd(t *testing.T) {
 		cmd:    "get values thomas-guide --all",
 		golden: "output/get-values-all.txt",
 		rels:   []*release.Release{release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})},
+	}, {
+		name:   "get values redacts sensitive values",
+		cmd:    "get values thomas-guide",
+		golden: "output/get-values-sensitive.txt",
+		rels:   []*release.Release{sensitiveReleaseMock("thomas-guide")},
+	}, {
+		name:   "get values shows sensitive values on demand",
+		cmd:    "get values thomas-guide --show-sensitive",
+		golden: "output/get-values.txt",
+		rels:   []*release.Release{sensitiveReleaseMock("thomas-guide")},
 	}, {
 		name:   "get values to json",
 		cmd:    "get values thomas-guide --output json",
@@ -53,6 +64,14 @@ func TestGetValuesCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+// sensitiveReleaseMock returns a release whose chart marks its "name" value as
+// sensitive.
+func sensitiveReleaseMock(name string) *release.Release {
+	rel := release.Mock(&release.MockReleaseOptions{Name: name})
+	rel.Chart.Metadata.Annotations = map[string]string{chartutil.SensitiveValuesAnnotation: "name"}
+	return rel
+}
+
 func TestGetValuesCompletion(t *testing.T) {
 	checkReleaseCompletion(t, "get values", false)
 }
@@ -139,7 +139,8 @@ func getReleaseHistory(rls []*release.Release) (history releaseHistory) {
 		c := formatChartname(r.Chart)
 		s := r.Info.Status.String()
 		v := r.Version
-		d := r.Info.Description
+		// Failure descriptions may quote the values of the release.
+		d := action.RedactRelease(r).Info.Description
 		a := formatAppVersion(r.Chart)
 
 		rInfo := releaseInfo{
@@ -0,0 +1,157 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const imagesDesc = `
+List the container images referenced by a chart.
+
+The chart is rendered locally with the given values, as with 'helm template',
+including its hooks and subcharts. Images are then collected from the pod specs
+of all workload kinds, including CronJobs, and from the fields of well-known
+custom resources such as Tekton Tasks, Argo Workflows and Prometheus Operator
+resources.
+
+Each image is listed once, along with the resources using it.
+
+    $ helm images ./mychart -f values.yaml
+    $ helm images oci://registry.example.com/charts/mychart --version 1.2.3 -o json
+`
+
+func newImagesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var validate bool
+	var skipTests bool
+	var outfmt output.Format
+	client := action.NewInstall(cfg)
+	valueOpts := &values.Options{}
+	var kubeVersion string
+	var extraAPIs []string
+
+	cmd := &cobra.Command{
+		Use:   "images [NAME] [CHART]",
+		Short: "list the container images referenced by a chart",
+		Long:  imagesDesc,
+		Args:  require.MinimumNArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return compInstall(args, toComplete, client)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if kubeVersion != "" {
+				parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
+				if err != nil {
+					return fmt.Errorf("invalid kube version '%s': %s", kubeVersion, err)
+				}
+				client.KubeVersion = parsedKubeVersion
+			}
+
+			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
+				client.InsecureSkipTLSverify, client.PlainHTTP)
+			if err != nil {
+				return fmt.Errorf("missing registry client: %w", err)
+			}
+			client.SetRegistryClient(registryClient)
+
+			client.DryRunOption = "true"
+			client.DryRun = true
+			client.ReleaseName = "release-name"
+			client.Replace = true // Skip the name check
+			client.ClientOnly = !validate
+			client.APIVersions = chartutil.VersionSet(extraAPIs)
+			rel, err := runInstall(args, client, valueOpts, out)
+			if err != nil {
+				return err
+			}
+
+			images, err := releaseutil.ExtractImages(releaseManifests(rel, skipTests))
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &imagesWriter{images})
+		},
+	}
+
+	f := cmd.Flags()
+	addInstallFlags(cmd, f, client, valueOpts)
+	f.BoolVar(&validate, "validate", false, "validate your manifests against the Kubernetes cluster you are currently pointing at. This is the same validation performed on an install")
+	f.BoolVar(&skipTests, "skip-tests", false, "skip the images of tests")
+	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
+	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
+	bindOutputFlag(cmd, &outfmt)
+	bindPostRenderFlag(cmd, &client.PostRenderer)
+
+	return cmd
+}
+
+// releaseManifests returns the manifest of rel followed by the manifests of its
+// hooks, skipping tests if skipTests is true.
+func releaseManifests(rel *release.Release, skipTests bool) string {
+	var manifests strings.Builder
+	fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
+	for _, h := range rel.Hooks {
+		if skipTests && isTestHook(h) {
+			continue
+		}
+		fmt.Fprintf(&manifests, "---\n# Source: %s\n%s\n", h.Path, h.Manifest)
+	}
+	return manifests.String()
+}
+
+type imagesWriter struct {
+	images []releaseutil.Image
+}
+
+func (w *imagesWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("IMAGE", "RESOURCES")
+	for _, img := range w.images {
+		table.AddRow(img.Image, strings.Join(img.Resources, ", "))
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (w *imagesWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, w.list())
+}
+
+func (w *imagesWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, w.list())
+}
+
+// list returns the images, as an empty list rather than null when there are
+// none.
+func (w *imagesWriter) list() []releaseutil.Image {
+	if w.images == nil {
+		return []releaseutil.Image{}
+	}
+	return w.images
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestImagesCmd(t *testing.T) {
+	imagesChart := "testdata/testcharts/chart-with-images"
+
+	tests := []cmdTestCase{
+		{
+			name:   "list images",
+			cmd:    fmt.Sprintf("images '%s'", imagesChart),
+			golden: "output/images.txt",
+		},
+		{
+			name:   "list images with values",
+			cmd:    fmt.Sprintf("images '%s' --set image.tag=2.0 --skip-tests", imagesChart),
+			golden: "output/images-set.txt",
+		},
+		{
+			name:   "list images in json",
+			cmd:    fmt.Sprintf("images '%s' -o json", imagesChart),
+			golden: "output/images.json",
+		},
+		{
+			name:   "list images in yaml",
+			cmd:    fmt.Sprintf("images '%s' -o yaml", imagesChart),
+			golden: "output/images.yaml",
+		},
+		{
+			name:   "list no images",
+			cmd:    fmt.Sprintf("images '%s' -o json", "testdata/testcharts/empty"),
+			golden: "output/images-none.json",
+		},
+		{
+			name:      "check no args",
+			cmd:       "images",
+			wantError: true,
+			golden:    "output/images-no-args.txt",
+		},
+	}
+	runTestCmd(t, tests)
+}
+
+func TestImagesOutputCompletion(t *testing.T) {
+	outputFlagCompletionTest(t, "images")
+}
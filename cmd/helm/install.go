@@ -34,6 +34,7 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli/output"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/downloader"
@@ -103,7 +104,7 @@ which can contain sensitive values. To hide Kubernetes Secrets use the
 If --verify is set, the chart MUST have a provenance file, and the provenance
 file MUST pass all verification steps.
 
-There are six different ways you can express the chart you want to install:
+There are eight different ways you can express the chart you want to install:
 
 1. By chart reference: helm install mymaria example/mariadb
 2. By path to a packaged chart: helm install mynginx ./nginx-1.2.3.tgz
@@ -111,6 +112,8 @@ There are six different ways you can express the chart you want to install:
 4. By absolute URL: helm install mynginx https://example.com/charts/nginx-1.2.3.tgz
 5. By chart reference and repo url: helm install --repo https://example.com/charts/ mynginx nginx
 6. By OCI registries: helm install mynginx --version 1.2.3 oci://example.com/charts/nginx
+7. By path to a bundle: helm install mynginx ./nginx-1.2.3.bundle.tar
+8. By OCI image layout directories: helm install mynginx --version 1.2.3 oci-layout://./layout/nginx
 
 CHART REFERENCES
 
@@ -158,7 +161,7 @@ func newInstallCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				return errors.Wrap(err, "INSTALLATION FAILED")
 			}
 
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, client.HideNotes})
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, client.HideNotes, false})
 		},
 	}
 
@@ -201,6 +204,7 @@ func addInstallFlags(cmd *cobra.Command, f *pflag.FlagSet, client *action.Instal
 	f.StringToStringVarP(&client.Labels, "labels", "l", nil, "Labels that would be added to release metadata. Should be divided by comma.")
 	f.BoolVar(&client.EnableDNS, "enable-dns", false, "enable DNS lookups when rendering templates")
 	f.BoolVar(&client.HideNotes, "hide-notes", false, "if set, do not show notes in install output. Does not affect presence in chart metadata")
+	f.BoolVar(&client.HashSensitiveValues, "hash-sensitive-values", false, "store a keyed hash of the values the chart marks as sensitive instead of the values themselves. The key of each release is kept in a Secret apart from the release")
 	addValueOptionsFlags(f, valueOpts)
 	addChartPathOptionsFlags(f, &client.ChartPathOptions)
 
@@ -241,10 +245,6 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 	debug("CHART PATH: %s\n", cp)
 
 	p := getter.All(settings)
-	vals, err := valueOpts.MergeValues(p)
-	if err != nil {
-		return nil, err
-	}
 
 	// Check chart dependencies to make sure all are present in /charts
 	chartRequested, err := loader.Load(cp)
@@ -271,10 +271,13 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 					Out:              out,
 					ChartPath:        cp,
 					Keyring:          client.ChartPathOptions.Keyring,
+					TrustPolicy:      settings.TrustPolicy,
 					SkipUpdate:       false,
 					Getters:          p,
 					RepositoryConfig: settings.RepositoryConfig,
 					RepositoryCache:  settings.RepositoryCache,
+					ChartCache:       settings.ChartCache,
+					Offline:          settings.Offline,
 					Debug:            settings.Debug,
 					RegistryClient:   client.GetRegistryClient(),
 				}
@@ -291,6 +294,12 @@ func runInstall(args []string, client *action.Install, valueOpts *values.Options
 		}
 	}
 
+	valueOpts.MergeKeys = chartutil.ChartMergeKeys(chartRequested)
+	vals, err := valueOpts.MergeValues(p)
+	if err != nil {
+		return nil, err
+	}
+
 	client.Namespace = settings.Namespace()
 
 	// Validate DryRunOption member is one of the allowed values
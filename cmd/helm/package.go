@@ -21,6 +21,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -45,8 +47,39 @@ provide '--keyring path/to/secret/keys' and '--key keyname'.
 
 If '--keyring' is not specified, Helm usually defaults to the public keyring
 unless your environment is otherwise configured.
+
+Charts can also be signed with an SSH key, such as an ed25519 key, by giving
+the path of the private key to '--key'. The provenance file then holds an SSH
+signature in the "helm" namespace, as made by 'ssh-keygen -Y sign'.
+
+  $ helm package --sign ./mychart --key ~/.ssh/id_ed25519
+
+Archives are reproducible by default: packaging the same chart twice yields
+identical archives, as files are written in order with fixed ownership,
+permissions and modification time. The modification time is the Unix epoch,
+or the time set by the SOURCE_DATE_EPOCH environment variable. Use
+'--reproducible=false' to record the time of packaging instead.
 `
 
+// sourceDateEpochEnvVar is the environment variable setting the modification
+// time of the files of reproducible archives, in seconds since the Unix epoch.
+// See https://reproducible-builds.org/specs/source-date-epoch/.
+const sourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable, if it is set.
+func sourceDateEpoch() (time.Time, bool, error) {
+	epoch, ok := os.LookupEnv(sourceDateEpochEnvVar)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, false, errors.Wrapf(err, "invalid %s", sourceDateEpochEnvVar)
+	}
+	return time.Unix(seconds, 0), true, nil
+}
+
 func newPackageCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	client := action.NewPackage()
 	valueOpts := &values.Options{}
@@ -67,6 +100,15 @@ func newPackageCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					return errors.New("--keyring is required for signing a package")
 				}
 			}
+			if client.Reproducible {
+				modTime, ok, err := sourceDateEpoch()
+				if err != nil {
+					return err
+				}
+				if ok {
+					client.ModTime = modTime
+				}
+			}
 			client.RepositoryConfig = settings.RepositoryConfig
 			client.RepositoryCache = settings.RepositoryCache
 			p := getter.All(settings)
@@ -89,11 +131,14 @@ func newPackageCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 						Out:              io.Discard,
 						ChartPath:        path,
 						Keyring:          client.Keyring,
+						TrustPolicy:      settings.TrustPolicy,
 						Getters:          p,
 						Debug:            settings.Debug,
 						RegistryClient:   cfg.RegistryClient,
 						RepositoryConfig: settings.RepositoryConfig,
 						RepositoryCache:  settings.RepositoryCache,
+						ChartCache:       settings.ChartCache,
+						Offline:          settings.Offline,
 					}
 
 					if err := downloadManager.Update(); err != nil {
@@ -111,14 +156,16 @@ func newPackageCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	}
 
 	f := cmd.Flags()
-	f.BoolVar(&client.Sign, "sign", false, "use a PGP private key to sign this package")
-	f.StringVar(&client.Key, "key", "", "name of the key to use when signing. Used if --sign is true")
+	f.BoolVar(&client.Sign, "sign", false, "sign this package with a PGP key or an SSH private key")
+	f.StringVar(&client.Key, "key", "", "name of the key to use when signing, or path of an SSH private key. Used if --sign is true")
 	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "location of a public keyring")
 	f.StringVar(&client.PassphraseFile, "passphrase-file", "", `location of a file which contains the passphrase for the signing key. Use "-" in order to read from stdin.`)
 	f.StringVar(&client.Version, "version", "", "set the version on the chart to this semver version")
 	f.StringVar(&client.AppVersion, "app-version", "", "set the appVersion on the chart to this version")
 	f.StringVarP(&client.Destination, "destination", "d", ".", "location to write the chart.")
+	f.BoolVar(&client.Reproducible, "reproducible", true, "write the same archive for the same chart, with files in order and normalized metadata")
 	f.BoolVarP(&client.DependencyUpdate, "dependency-update", "u", false, `update dependencies from "Chart.yaml" to dir "charts/" before packaging`)
+	f.BoolVar(&client.VerifyDependencies, "verify-dependencies", false, `verify the archives in "charts/" against the digests of the lock file before packaging`)
 
 	return cmd
 }
@@ -16,6 +16,7 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -191,6 +192,61 @@ func TestSetAppVersion(t *testing.T) {
 	}
 }
 
+func TestPackageReproducible(t *testing.T) {
+	chartToPackage := "testdata/testcharts/alpine"
+	packageTo := func(dir string) []byte {
+		t.Helper()
+		if _, output, err := executeActionCommand(fmt.Sprintf("package %s --destination=%s", chartToPackage, dir)); err != nil {
+			t.Logf("Output: %s", output)
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "alpine-0.1.0.tgz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := packageTo(t.TempDir())
+	if !bytes.Equal(first, packageTo(t.TempDir())) {
+		t.Error("Expected packaging the same chart twice to yield identical archives")
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if bytes.Equal(first, packageTo(t.TempDir())) {
+		t.Error("Expected SOURCE_DATE_EPOCH to change the modification time of the files")
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, _, err := executeActionCommand(fmt.Sprintf("package %s --destination=%s", chartToPackage, t.TempDir())); err == nil {
+		t.Error("Expected an invalid SOURCE_DATE_EPOCH to fail")
+	}
+}
+
+func TestPackageSignSSH(t *testing.T) {
+	dir := t.TempDir()
+	cmd := fmt.Sprintf("package testdata/testcharts/alpine --destination=%s --sign --key=testdata/helm-test-ssh-key", dir)
+	if _, output, err := executeActionCommand(cmd); err != nil {
+		t.Logf("Output: %s", output)
+		t.Fatal(err)
+	}
+
+	chartfile := filepath.Join(dir, "alpine-0.1.0.tgz")
+	_, out, err := executeActionCommand("verify --keyring=testdata/allowed_signers " + chartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "Signed by: helm-testing@helm.sh\nUsing Key With Fingerprint: SHA256:mmvS5ZSyFNEi3SoAfxW8R+QtjhtJNByxO01czMeQ4cU\nChart Hash Verified: sha256:"
+	if !strings.HasPrefix(out, expect) {
+		t.Errorf("Expected %q, got %q", expect, out)
+	}
+
+	// PGP keyrings do not verify SSH signatures.
+	if _, _, err := executeActionCommand("verify --keyring=testdata/helm-test-key.pub " + chartfile); err == nil {
+		t.Error("Expected verification against a PGP keyring to fail")
+	}
+}
+
 func TestPackageFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "package", true)
 	checkFileCompletion(t, "package mypath", true) // Multiple paths can be given
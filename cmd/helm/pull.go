@@ -34,6 +34,15 @@ This is useful for fetching packages to inspect, modify, or repackage. It can
 also be used to perform cryptographic verification of a chart without installing
 the chart.
 
+The chart of a bundle made with 'helm bundle create' is retrieved when given the
+path of the bundle.
+
+Charts pushed to an OCI image layout directory with 'helm push' are retrieved
+with the 'oci-layout://' scheme, giving the path of the layout followed by the
+name of the chart:
+
+    $ helm pull oci-layout://./layout/mychart --version 1.2.3
+
 There are options for unpacking the chart after download. This will create a
 directory for the chart and uncompress into that directory.
 
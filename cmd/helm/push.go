@@ -32,6 +32,15 @@ Upload a chart to a registry.
 
 If the chart has an associated provenance file,
 it will also be uploaded.
+
+The remote is either an OCI registry, with the 'oci://' scheme, or the path of
+an OCI image layout directory on disk, with the 'oci-layout://' scheme. The
+directory is created if it does not exist:
+
+    $ helm push mychart-0.1.0.tgz oci://example.com/charts
+    $ helm push mychart-0.1.0.tgz oci-layout://./layout
+
+The charts of a layout are listed with 'helm search layout'.
 `
 
 type registryPushOptions struct {
@@ -17,9 +17,49 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+func TestPushOCILayout(t *testing.T) {
+	layout := filepath.Join(t.TempDir(), "layout")
+	for _, chart := range []string{"compressedchart-0.1.0.tgz", "compressedchart-0.2.0.tgz", "signtest-0.1.0.tgz"} {
+		cmd := fmt.Sprintf("push testdata/testcharts/%s oci-layout://%s", chart, layout)
+		if _, out, err := executeActionCommand(cmd); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		args   string
+		expect string
+	}{
+		{"latest version", "oci-layout://%s/compressedchart", "compressedchart-0.2.0.tgz"},
+		{"version constraint", "oci-layout://%s/compressedchart --version '<0.2.0'", "compressedchart-0.1.0.tgz"},
+		{"version in reference", "oci-layout://%s/compressedchart:0.1.0", "compressedchart-0.1.0.tgz"},
+		{"verified chart", "oci-layout://%s/signtest --verify --keyring testdata/helm-test-key.pub", "signtest-0.1.0.tgz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			cmd := fmt.Sprintf("pull "+tt.args+" -d '%s'", layout, dest)
+			if _, out, err := executeActionCommand(cmd); err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			if _, err := os.Stat(filepath.Join(dest, tt.expect)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if _, _, err := executeActionCommand(fmt.Sprintf("pull oci-layout://%s/compressedchart --version 0.3.0", layout)); err == nil {
+		t.Error("expected an error pulling a missing version")
+	}
+}
+
 func TestPushFileCompletion(t *testing.T) {
 	checkFileCompletion(t, "push", true)
 	checkFileCompletion(t, "push package.tgz", false)
@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -45,6 +46,7 @@ var deprecatedRepos = map[string]string{
 type repoAddOptions struct {
 	name                 string
 	url                  string
+	mirrors              []string
 	username             string
 	password             string
 	passwordFromStdinOpt bool
@@ -99,6 +101,7 @@ func newRepoAddCmd(out io.Writer) *cobra.Command {
 	f.BoolVar(&o.insecureSkipTLSverify, "insecure-skip-tls-verify", false, "skip tls certificate checks for the repository")
 	f.BoolVar(&o.allowDeprecatedRepos, "allow-deprecated-repos", false, "by default, this command will not allow adding official repos that have been permanently deleted. This disables that behavior")
 	f.BoolVar(&o.passCredentialsAll, "pass-credentials", false, "pass credentials to all domains")
+	f.StringArrayVar(&o.mirrors, "mirror", nil, "URL of a mirror of the repository, used when the repository is unavailable (can specify multiple, tried in order). Credentials are only sent to mirrors with --pass-credentials")
 
 	return cmd
 }
@@ -180,6 +183,9 @@ func (o *repoAddOptions) run(out io.Writer) error {
 		CAFile:                o.caFile,
 		InsecureSkipTLSverify: o.insecureSkipTLSverify,
 	}
+	if len(o.mirrors) > 0 {
+		c.Mirrors = o.mirrors
+	}
 
 	// Check if the repo name is legal
 	if strings.Contains(o.name, "/") {
@@ -191,7 +197,7 @@ func (o *repoAddOptions) run(out io.Writer) error {
 	// 2. When the config is different require --force-update
 	if !o.forceUpdate && f.Has(o.name) {
 		existing := f.Get(o.name)
-		if c != *existing {
+		if !reflect.DeepEqual(c, *existing) {
 
 			// The input coming in for the name is different from what is already
 			// configured. Return an error.
@@ -211,6 +217,7 @@ func (o *repoAddOptions) run(out io.Writer) error {
 	if o.repoCache != "" {
 		r.CachePath = o.repoCache
 	}
+	r.Log = debug
 	if _, err := r.DownloadIndexFile(); err != nil {
 		return errors.Wrapf(err, "looks like %q is not a valid chart repository or cannot be reached", o.url)
 	}
@@ -17,10 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -134,6 +138,56 @@ func TestRepoAdd(t *testing.T) {
 	}
 }
 
+func TestRepoAddWithMirrors(t *testing.T) {
+	ts, err := repotest.NewTempServerWithCleanup(t, "testdata/testserver/*.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Stop()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	rootDir := t.TempDir()
+	repoFile := filepath.Join(rootDir, "repositories.yaml")
+
+	const testRepoName = "test-name"
+
+	o := &repoAddOptions{
+		name:      testRepoName,
+		url:       down.URL,
+		mirrors:   []string{ts.URL()},
+		repoFile:  repoFile,
+		repoCache: rootDir,
+	}
+
+	if err := o.run(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := repo.LoadFile(repoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := f.Get(testRepoName); e == nil || !reflect.DeepEqual(e.Mirrors, o.mirrors) {
+		t.Errorf("Expected %s to be added with the mirrors %v, got %v", testRepoName, o.mirrors, e)
+	}
+
+	var out bytes.Buffer
+	if err := o.run(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "already exists with the same configuration") {
+		t.Errorf("Expected the repository to be left unchanged, got %q", out.String())
+	}
+
+	o.mirrors = nil
+	if err := o.run(io.Discard); err == nil {
+		t.Error("Expected an error adding the repository without its mirrors")
+	}
+}
+
 func TestRepoAddCheckLegalName(t *testing.T) {
 	ts, err := repotest.NewTempServerWithCleanup(t, "testdata/testserver/*.*")
 	if err != nil {
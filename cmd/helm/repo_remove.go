@@ -87,6 +87,7 @@ func removeRepoCache(root, name string) error {
 	}
 
 	idx = filepath.Join(root, helmpath.CacheIndexFile(name))
+	os.Remove(repo.IndexCacheFile(idx))
 	if _, err := os.Stat(idx); os.IsNotExist(err) {
 		return nil
 	} else if err != nil {
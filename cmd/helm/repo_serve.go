@@ -0,0 +1,149 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/internal/reposerver"
+)
+
+const repoServeDesc = `
+Serve a directory of packaged charts as a chart repository, for local
+development and testing.
+
+The index of the repository is generated from the charts found in the directory,
+as by 'helm repo index', and generated again when they change. To set an
+absolute URL to the charts in the index, use the '--url' flag.
+
+When '--username' and '--password' are set, charts can be uploaded to the
+repository with basic auth, as with ChartMuseum:
+
+    $ curl -u user:pass --data-binary @mychart-0.1.0.tgz http://127.0.0.1:8879/api/charts
+
+To serve the repository over HTTPS, use the '--cert-file' and '--key-file' flags.
+`
+
+type repoServeOptions struct {
+	dir      string
+	address  string
+	url      string
+	username string
+	password string
+	certFile string
+	keyFile  string
+}
+
+func newRepoServeCmd(out io.Writer) *cobra.Command {
+	o := &repoServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve [DIR]",
+		Short: "serve a directory of packaged charts as a chart repository",
+		Long:  repoServeDesc,
+		Args:  require.MaximumNArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				// Allow file completion when completing the argument for the directory
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			// No more completions, so disable file completion
+			return noMoreArgsComp()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			o.dir = "."
+			if len(args) > 0 {
+				o.dir = args[0]
+			}
+			srv, l, err := o.listen(out)
+			if err != nil {
+				return err
+			}
+			if o.certFile != "" {
+				return srv.ServeTLS(l, o.certFile, o.keyFile)
+			}
+			return srv.Serve(l)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.address, "address", "127.0.0.1:8879", "address to listen on")
+	f.StringVar(&o.url, "url", "", "url of chart repository, the URLs of the charts are relative to the index if not set")
+	f.StringVar(&o.username, "username", "", "username required to upload charts")
+	f.StringVar(&o.password, "password", "", "password required to upload charts")
+	f.StringVar(&o.certFile, "cert-file", "", "serve HTTPS using this SSL certificate file")
+	f.StringVar(&o.keyFile, "key-file", "", "serve HTTPS using this SSL key file")
+
+	return cmd
+}
+
+// listen validates the options and listens on the address, returning the
+// server to serve the repository with.
+func (o *repoServeOptions) listen(out io.Writer) (*http.Server, net.Listener, error) {
+	dir, err := filepath.Abs(o.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return nil, nil, err
+	} else if !fi.IsDir() {
+		return nil, nil, errors.Errorf("%s is not a directory", o.dir)
+	}
+	if (o.certFile == "") != (o.keyFile == "") {
+		return nil, nil, errors.New("both --cert-file and --key-file are required to serve HTTPS")
+	}
+	if (o.username == "") != (o.password == "") {
+		return nil, nil, errors.New("both --username and --password are required to accept uploads")
+	}
+
+	l, err := net.Listen("tcp", o.address)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := "http"
+	if o.certFile != "" {
+		scheme = "https"
+	}
+	fmt.Fprintf(out, "Serving %s at %s://%s\n", dir, scheme, l.Addr())
+	if o.username != "" && o.certFile == "" {
+		fmt.Fprintln(out, "WARNING: uploads are accepted over HTTP, the credentials are sent in clear text")
+	}
+
+	srv := &http.Server{
+		Handler: &reposerver.Server{
+			Dir:      dir,
+			URL:      o.url,
+			Username: o.username,
+			Password: o.password,
+			Log: func(format string, v ...interface{}) {
+				fmt.Fprintf(out, format+"\n", v...)
+			},
+		},
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	return srv, l, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoServeCmd(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"signtest-0.1.0.tgz", "signtest-0.1.0.tgz.prov"} {
+		data, err := os.ReadFile(filepath.Join("testdata/testcharts", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    repoServeOptions
+		tls     bool
+		wantErr string
+	}{
+		{
+			name: "serve HTTP",
+			opts: repoServeOptions{dir: dir, address: "127.0.0.1:0"},
+		},
+		{
+			name: "serve HTTPS",
+			opts: repoServeOptions{dir: dir, address: "127.0.0.1:0", certFile: "../../testdata/crt.pem", keyFile: "../../testdata/key.pem"},
+			tls:  true,
+		},
+		{
+			name:    "missing directory",
+			opts:    repoServeOptions{dir: filepath.Join(dir, "missing"), address: "127.0.0.1:0"},
+			wantErr: "no such file or directory",
+		},
+		{
+			name:    "not a directory",
+			opts:    repoServeOptions{dir: filepath.Join(dir, "signtest-0.1.0.tgz"), address: "127.0.0.1:0"},
+			wantErr: "is not a directory",
+		},
+		{
+			name:    "missing key file",
+			opts:    repoServeOptions{dir: dir, address: "127.0.0.1:0", certFile: "../../testdata/crt.pem"},
+			wantErr: "both --cert-file and --key-file are required",
+		},
+		{
+			name:    "missing password",
+			opts:    repoServeOptions{dir: dir, address: "127.0.0.1:0", username: "username"},
+			wantErr: "both --username and --password are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			srv, l, err := tt.opts.listen(&out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				if tt.tls {
+					srv.ServeTLS(l, tt.opts.certFile, tt.opts.keyFile)
+				} else {
+					srv.Serve(l)
+				}
+			}()
+			defer srv.Close()
+
+			u := "http://" + l.Addr().String()
+			tlsFlags := ""
+			if tt.tls {
+				u = "https://" + l.Addr().String()
+				tlsFlags = "--ca-file ../../testdata/rootca.crt"
+			}
+			if !strings.HasPrefix(out.String(), fmt.Sprintf("Serving %s at %s\n", dir, u)) {
+				t.Errorf("unexpected output %q", out.String())
+			}
+
+			repoDir := t.TempDir()
+			repoFlags := fmt.Sprintf("--repository-config %s --repository-cache %s", filepath.Join(repoDir, "repositories.yaml"), repoDir)
+			if _, out, err := executeActionCommand(fmt.Sprintf("repo add local %s %s %s", u, tlsFlags, repoFlags)); err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			cmd := fmt.Sprintf("pull local/signtest --verify --keyring testdata/helm-test-key.pub -d %s %s", repoDir, repoFlags)
+			if _, out, err := executeActionCommand(cmd); err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			if _, err := os.Stat(filepath.Join(repoDir, "signtest-0.1.0.tgz")); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRepoServeUpload(t *testing.T) {
+	o := repoServeOptions{dir: t.TempDir(), address: "127.0.0.1:0", username: "username", password: "password"}
+	var out bytes.Buffer
+	srv, l, err := o.listen(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+	if !strings.Contains(out.String(), "WARNING: uploads are accepted over HTTP") {
+		t.Errorf("expected a warning about uploads over HTTP, got %q", out.String())
+	}
+
+	data, err := os.ReadFile("testdata/testcharts/compressedchart-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+"/api/charts", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("username", "password")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected the chart to be uploaded, got %s", resp.Status)
+	}
+	if _, err := os.Stat(filepath.Join(o.dir, "compressedchart-0.1.0.tgz")); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), "Uploaded compressedchart-0.1.0.tgz\n") {
+		t.Errorf("expected the upload to be logged, got %q", out.String())
+	}
+}
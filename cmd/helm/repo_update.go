@@ -107,6 +107,7 @@ func (o *repoUpdateOptions) run(out io.Writer) error {
 			if o.repoCache != "" {
 				r.CachePath = o.repoCache
 			}
+			r.Log = debug
 			repos = append(repos, r)
 		}
 	}
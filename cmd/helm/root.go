@@ -60,6 +60,9 @@ Environment variables:
 | $HELM_REGISTRY_CONFIG              | set the path to the registry config file.                                                                  |
 | $HELM_REPOSITORY_CACHE             | set the path to the repository cache directory                                                             |
 | $HELM_REPOSITORY_CONFIG            | set the path to the repositories file.                                                                     |
+| $HELM_TRUST_POLICY                 | set the path to the trust policy file.                                                                     |
+| $HELM_CHART_CACHE                  | set the path to the cache of chart archives.                                                               |
+| $HELM_OFFLINE                      | indicate whether charts are only fetched from the chart cache.                                             |
 | $KUBECONFIG                        | set an alternative Kubernetes configuration file (default "~/.kube/config")                                |
 | $HELM_KUBEAPISERVER                | set the Kubernetes API Server Endpoint for authentication                                                  |
 | $HELM_KUBECAFILE                   | set the Kubernetes certificate authority file.                                                             |
@@ -162,23 +165,29 @@ func newRootCmd(actionConfig *action.Configuration, out io.Writer, args []string
 	// Add subcommands
 	cmd.AddCommand(
 		// chart commands
+		newBundleCmd(actionConfig, out),
+		newCacheCmd(out),
 		newCreateCmd(out),
 		newDependencyCmd(actionConfig, out),
 		newPullCmd(actionConfig, out),
 		newShowCmd(actionConfig, out),
+		newValuesCmd(actionConfig, out),
 		newLintCmd(out),
 		newPackageCmd(actionConfig, out),
 		newRepoCmd(out),
+		newSchemaCmd(out),
 		newSearchCmd(out),
 		newVerifyCmd(out),
 
 		// release commands
 		newGetCmd(actionConfig, out),
 		newHistoryCmd(actionConfig, out),
+		newImagesCmd(actionConfig, out),
 		newInstallCmd(actionConfig, out),
 		newListCmd(actionConfig, out),
 		newReleaseTestCmd(actionConfig, out),
 		newRollbackCmd(actionConfig, out),
+		newSBOMCmd(actionConfig, out),
 		newStatusCmd(actionConfig, out),
 		newTemplateCmd(actionConfig, out),
 		newUninstallCmd(actionConfig, out),
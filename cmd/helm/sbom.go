@@ -0,0 +1,167 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/internal/sbom"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+const sbomDesc = `
+Generate a software bill of materials (SBOM) of a chart or a release.
+
+The document describes the chart, the tree of its dependencies from Chart.lock
+along with the digests of their archives, and the container images found in
+the rendered manifests, including the ones of hooks.
+
+The argument is a chart: a path, a chart reference such as 'repo/mychart' or
+'oci://...', or a chart name with '--repo'. Charts are rendered locally with
+the given values, as with 'helm template'. With '--release', the argument is
+the name of a release instead, described from its stored manifest without the
+digests of dependencies.
+
+Documents are in the CycloneDX 1.5 JSON format by default. Use '--format spdx'
+for the SPDX 2.3 JSON format. The creation time of the documents of charts is
+the current time, or the time set by the SOURCE_DATE_EPOCH environment variable.
+
+    $ helm sbom ./mychart -f values.yaml > sbom.cdx.json
+    $ helm sbom --release myrelease --format spdx > sbom.spdx.json
+`
+
+func newSBOMCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewSBOM(cfg)
+	install := action.NewInstall(cfg)
+	valueOpts := &values.Options{}
+	var kubeVersion string
+	var extraAPIs []string
+	var isRelease bool
+
+	cmd := &cobra.Command{
+		Use:   "sbom [CHART|--release RELEASE]",
+		Short: "generate a software bill of materials of a chart or a release",
+		Long:  sbomDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			if isRelease {
+				return compListReleases(toComplete, args, cfg)
+			}
+			// Allow file completion when completing the argument for the chart
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if client.Format != sbom.CycloneDX && client.Format != sbom.SPDX {
+				return fmt.Errorf("invalid format %q, expected %s or %s", client.Format, sbom.CycloneDX, sbom.SPDX)
+			}
+
+			if client.Version != 0 && !isRelease {
+				return errors.New("--revision requires --release")
+			}
+
+			var doc []byte
+			var err error
+			if isRelease {
+				doc, err = client.Run(args[0])
+			} else {
+				doc, err = runChartSBOM(args[0], client, install, valueOpts, kubeVersion, extraAPIs, out)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(doc))
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&client.Format, "format", sbom.CycloneDX, "format of the document: cyclonedx or spdx")
+	f.BoolVar(&isRelease, "release", false, "describe the release named by the argument instead of a chart")
+	f.IntVar(&client.Version, "revision", 0, "describe the named release with revision, with --release")
+	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
+	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
+	addValueOptionsFlags(f, valueOpts)
+	addChartPathOptionsFlags(f, &install.ChartPathOptions)
+	f.BoolVar(&install.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
+
+	err := cmd.RegisterFlagCompletionFunc("format", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{sbom.CycloneDX, sbom.SPDX}, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cmd
+}
+
+// runChartSBOM renders the chart ref locally, and returns its software bill of
+// materials.
+func runChartSBOM(ref string, client *action.SBOM, install *action.Install, valueOpts *values.Options, kubeVersion string, extraAPIs []string, out io.Writer) ([]byte, error) {
+	if kubeVersion != "" {
+		parsedKubeVersion, err := chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version '%s': %s", kubeVersion, err)
+		}
+		install.KubeVersion = parsedKubeVersion
+	}
+	created, ok, err := sourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		client.Created = created
+	}
+
+	registryClient, err := newRegistryClient(install.CertFile, install.KeyFile, install.CaFile,
+		install.InsecureSkipTLSverify, install.PlainHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("missing registry client: %w", err)
+	}
+	install.SetRegistryClient(registryClient)
+
+	if install.Version == "" && install.Devel {
+		install.Version = ">0.0.0-0"
+	}
+	cp, err := install.ChartPathOptions.LocateChart(ref, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	install.DryRunOption = "true"
+	install.DryRun = true
+	install.ReleaseName = "release-name"
+	install.Replace = true // Skip the name check
+	install.ClientOnly = true
+	install.APIVersions = chartutil.VersionSet(extraAPIs)
+	install.Verify = false // The chart was verified when located
+	rel, err := runInstall([]string{cp}, install, valueOpts, out)
+	if err != nil {
+		return nil, err
+	}
+	return client.RunChart(rel, cp)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestSBOMCmd(t *testing.T) {
+	imagesChart := "testdata/testcharts/chart-with-images"
+
+	rel := release.Mock(&release.MockReleaseOptions{Name: "thomas-guide"})
+	rel.Manifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`
+	rels := []*release.Release{rel}
+
+	tests := []cmdTestCase{
+		{
+			name:   "sbom of a chart",
+			cmd:    fmt.Sprintf("sbom '%s'", imagesChart),
+			golden: "output/sbom-chart.json",
+		},
+		{
+			name:   "sbom of a chart with values in spdx",
+			cmd:    fmt.Sprintf("sbom '%s' --set image.tag=2.0 --format spdx", imagesChart),
+			golden: "output/sbom-chart-spdx.json",
+		},
+		{
+			name:   "sbom of a release",
+			cmd:    "sbom --release thomas-guide",
+			golden: "output/sbom-release.json",
+			rels:   rels,
+		},
+		{
+			name:   "sbom of a release in spdx",
+			cmd:    "sbom --release thomas-guide --format spdx",
+			golden: "output/sbom-release-spdx.json",
+			rels:   rels,
+		},
+		{
+			name:      "sbom of a missing release",
+			cmd:       "sbom --release nobody",
+			golden:    "output/sbom-missing-release.txt",
+			wantError: true,
+		},
+		{
+			name:      "sbom in an invalid format",
+			cmd:       fmt.Sprintf("sbom '%s' --format swid", imagesChart),
+			golden:    "output/sbom-invalid-format.txt",
+			wantError: true,
+		},
+		{
+			name:      "sbom of a missing chart",
+			cmd:       "sbom nobody",
+			golden:    "output/sbom-missing-chart.txt",
+			wantError: true,
+		},
+		{
+			name:      "revision of a chart",
+			cmd:       fmt.Sprintf("sbom '%s' --revision 2", imagesChart),
+			golden:    "output/sbom-chart-revision.txt",
+			wantError: true,
+		},
+		{
+			name:      "check no args",
+			cmd:       "sbom",
+			golden:    "output/sbom-no-args.txt",
+			wantError: true,
+		},
+	}
+	runTestCmd(t, tests)
+}
+
+func TestSBOMSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	_, out, err := executeActionCommand("sbom testdata/testcharts/empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `"timestamp": "2023-11-14T22:13:20Z"`; !strings.Contains(out, expect) {
+		t.Errorf("expected %s in:\n%s", expect, out)
+	}
+}
+
+func TestSBOMCompletion(t *testing.T) {
+	checkReleaseCompletion(t, "sbom --release", false)
+}
+
+func TestSBOMFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "sbom", true)
+	checkFileCompletion(t, "sbom mychart", false)
+}
+
+func TestSBOMFormatCompletion(t *testing.T) {
+	tests := []cmdTestCase{{
+		name:   "completion for sbom format",
+		cmd:    "__complete sbom --format ''",
+		golden: "output/sbom-format-completion.txt",
+	}}
+	runTestCmd(t, tests)
+}
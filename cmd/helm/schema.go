@@ -0,0 +1,98 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const schemaDesc = `
+Manage the values schema of a chart.
+
+Helm validates the values of a chart against its 'values.schema.json' file.
+The schema commands keep that file in sync with 'values.yaml'.
+`
+
+const schemaGenerateDesc = `
+Generate 'values.schema.json' from the 'values.yaml' file of a chart.
+
+The type of every key is inferred from its value in 'values.yaml'. Comment
+lines starting with '@schema' add JSON Schema keywords to the key they
+precede, or to the key on the same line:
+
+    # @schema description: Number of pods to run
+    # @schema minimum: 1
+    replicaCount: 1
+    image:
+      # @schema required: true
+      repository: nginx
+      pullPolicy: IfNotPresent # @schema enum: [Always, IfNotPresent, Never]
+
+The supported keywords are description, enum, type, format, pattern, minimum,
+maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength, minItems,
+maxItems, x-helm-merge-key and x-helm-sensitive. 'required: true' lists the
+key as required by its parent.
+
+An existing 'values.schema.json' is only overwritten with '--force'.
+
+With '--check', the schema is not written. Instead, the command fails when the
+existing 'values.schema.json' does not match the one generated from
+'values.yaml'. This is useful in CI.
+`
+
+func newSchemaCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "manage a chart's values schema",
+		Long:  schemaDesc,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(newSchemaGenerateCmd(out))
+
+	return cmd
+}
+
+func newSchemaGenerateCmd(out io.Writer) *cobra.Command {
+	client := action.NewSchema()
+
+	cmd := &cobra.Command{
+		Use:   "generate CHART",
+		Short: "generate values.schema.json from values.yaml",
+		Long:  schemaGenerateDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			chartpath := "."
+			if len(args) > 0 {
+				chartpath = filepath.Clean(args[0])
+			}
+			return client.Generate(chartpath, out)
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.Check, "check", false, "fail if values.schema.json does not match values.yaml instead of writing it")
+	f.BoolVar(&client.Force, "force", false, "overwrite an existing values.schema.json")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaGenerateCmd(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicaCount: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := executeActionCommand("schema generate --check " + dir)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("Expected missing schema error, got %v", err)
+	}
+
+	if _, _, err := executeActionCommand("schema generate " + dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := executeActionCommand("schema generate --check " + dir); err != nil {
+		t.Fatalf("Expected generated schema to pass the check, got %s", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicaCount: one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = executeActionCommand("schema generate --check " + dir)
+	if err == nil || !strings.Contains(err.Error(), "does not match values.yaml") {
+		t.Fatalf("Expected schema drift error, got %v", err)
+	}
+
+	_, _, err = executeActionCommand("schema generate " + dir)
+	if err == nil || !strings.Contains(err.Error(), "use --force to overwrite it") {
+		t.Fatalf("Expected an error overwriting the schema, got %v", err)
+	}
+	if _, _, err := executeActionCommand("schema generate --force " + dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := executeActionCommand("schema generate --check " + dir); err != nil {
+		t.Fatalf("Expected the overwritten schema to pass the check, got %s", err)
+	}
+}
+
+func TestSchemaGenerateFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "schema", false)
+	checkFileCompletion(t, "schema generate", true)
+}
@@ -38,6 +38,7 @@ func newSearchCmd(out io.Writer) *cobra.Command {
 
 	cmd.AddCommand(newSearchHubCmd(out))
 	cmd.AddCommand(newSearchRepoCmd(out))
+	cmd.AddCommand(newSearchLayoutCmd(out))
 
 	return cmd
 }
@@ -0,0 +1,126 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/cmd/helm/search"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const searchLayoutDesc = `
+Search the charts of an OCI image layout directory, as written by
+'helm push mychart-0.1.0.tgz oci-layout://./layout'.
+
+With no keyword, all the charts of the layout are listed. The charts are
+named by their 'oci-layout://' reference, which can be given to 'helm pull',
+'helm install' and the other commands that take charts.
+
+    $ helm search layout ./layout
+    $ helm search layout ./layout nginx --versions
+`
+
+type searchLayoutOptions struct {
+	versions       bool
+	regexp         bool
+	maxColWidth    uint
+	outputFormat   output.Format
+	failOnNoResult bool
+}
+
+func newSearchLayoutCmd(out io.Writer) *cobra.Command {
+	o := &searchLayoutOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "layout DIR [keyword]",
+		Short: "search an OCI image layout directory for a keyword in charts",
+		Long:  searchLayoutDesc,
+		Args:  require.MinimumNArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+			return noMoreArgsComp()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return o.run(out, args[0], args[1:])
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&o.regexp, "regexp", "r", false, "use regular expressions for searching the layout")
+	f.BoolVarP(&o.versions, "versions", "l", false, "show the long listing, with each version of each chart on its own line")
+	f.UintVar(&o.maxColWidth, "max-col-width", 50, "maximum column width for output table")
+	f.BoolVar(&o.failOnNoResult, "fail-on-no-result", false, "search fails if no results are found")
+
+	bindOutputFlag(cmd, &o.outputFormat)
+
+	return cmd
+}
+
+func (o *searchLayoutOptions) run(out io.Writer, dir string, args []string) error {
+	ind, err := layoutIndex(dir)
+	if err != nil {
+		return err
+	}
+	i := search.NewIndex()
+	i.AddRepo("", ind, o.versions)
+
+	var res []*search.Result
+	if len(args) == 0 {
+		res = i.All()
+	} else {
+		res, err = i.Search(strings.Join(args, " "), searchMaxScore, o.regexp)
+		if err != nil {
+			return err
+		}
+	}
+	search.SortScore(res)
+	for _, r := range res {
+		r.Name = fmt.Sprintf("%s://%s", registry.OCILayoutScheme, path.Join(dir, r.Name))
+	}
+
+	return o.outputFormat.Write(out, &repoSearchWriter{res, o.maxColWidth, o.failOnNoResult})
+}
+
+// layoutIndex returns an index of the charts of the OCI image layout in dir.
+func layoutIndex(dir string) (*repo.IndexFile, error) {
+	l, err := registry.LoadLayoutDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ind := repo.NewIndexFile()
+	for _, ref := range l.Charts() {
+		meta, err := l.ChartMetadata(ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := ind.MustAdd(meta, "", "", ""); err != nil {
+			return nil, err
+		}
+	}
+	return ind, nil
+}
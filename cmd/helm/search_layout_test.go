@@ -0,0 +1,84 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchLayoutCmd(t *testing.T) {
+	layout := filepath.Join(t.TempDir(), "layout")
+	for _, chart := range []string{"compressedchart-0.1.0.tgz", "compressedchart-0.2.0.tgz", "signtest-0.1.0.tgz"} {
+		cmd := fmt.Sprintf("push testdata/testcharts/%s oci-layout://%s", chart, layout)
+		if _, out, err := executeActionCommand(cmd); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		args   string
+		expect []string
+	}{
+		{"all charts", "", []string{
+			"oci-layout://%s/compressedchart\t0.2.0",
+			"oci-layout://%s/signtest\t0.1.0",
+		}},
+		{"keyword", "signtest", []string{
+			"oci-layout://%s/signtest\t0.1.0",
+		}},
+		{"versions", "compressedchart --versions", []string{
+			"oci-layout://%s/compressedchart\t0.2.0",
+			"oci-layout://%s/compressedchart\t0.1.0",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := fmt.Sprintf("search layout '%s' %s --output json", layout, tt.args)
+			_, out, err := executeActionCommand(cmd)
+			if err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			var charts []repoChartElement
+			if err := json.Unmarshal([]byte(out), &charts); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range charts {
+				got = append(got, c.Name+"\t"+c.Version)
+			}
+			var expect []string
+			for _, e := range tt.expect {
+				expect = append(expect, fmt.Sprintf(e, layout))
+			}
+			if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+				t.Errorf("expected %q, got %q", expect, got)
+			}
+		})
+	}
+
+	if _, _, err := executeActionCommand(fmt.Sprintf("search layout '%s' nothing --fail-on-no-result", layout)); err == nil {
+		t.Error("expected an error with no results")
+	}
+	if _, _, err := executeActionCommand(fmt.Sprintf("search layout '%s'", t.TempDir())); err == nil {
+		t.Error("expected an error searching a directory that is not a layout")
+	}
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v3/cmd/helm/search"
+	"helm.sh/helm/v3/internal/version"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli/output"
 	"helm.sh/helm/v3/pkg/helmpath"
 	"helm.sh/helm/v3/pkg/repo"
@@ -43,7 +45,8 @@ the system.
 
 It will display the latest stable versions of the charts found. If you
 specify the --devel flag, the output will include pre-release versions.
-If you want to search using a version constraint, use --version.
+If you want to search using a version constraint, use --version. To skip the
+chart versions requiring another version of Helm, use --compatible.
 
 Examples:
 
@@ -56,6 +59,9 @@ Examples:
     # Search for the latest stable release for nginx-ingress with a major version of 1
     $ helm search repo nginx-ingress --version ^1.0.0
 
+    # Search for the latest stable release of nginx this version of Helm can install
+    $ helm search repo nginx --compatible
+
 Repositories are managed with 'helm repo' commands.
 `
 
@@ -67,6 +73,7 @@ type searchRepoOptions struct {
 	regexp         bool
 	devel          bool
 	version        string
+	compatible     bool
 	maxColWidth    uint
 	repoFile       string
 	repoCacheDir   string
@@ -93,6 +100,7 @@ func newSearchRepoCmd(out io.Writer) *cobra.Command {
 	f.BoolVarP(&o.versions, "versions", "l", false, "show the long listing, with each version of each chart on its own line, for repositories you have added")
 	f.BoolVar(&o.devel, "devel", false, "use development versions (alpha, beta, and release candidate releases), too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
 	f.StringVar(&o.version, "version", "", "search using semantic versioning constraints on repositories you have added")
+	f.BoolVar(&o.compatible, "compatible", false, "only show chart versions compatible with this version of Helm, as declared by their helmVersion")
 	f.UintVar(&o.maxColWidth, "max-col-width", 50, "maximum column width for output table")
 	f.BoolVar(&o.failOnNoResult, "fail-on-no-result", false, "search fails if no results are found")
 
@@ -167,6 +175,9 @@ func (o *searchRepoOptions) applyConstraint(res []*search.Result) ([]*search.Res
 		if err != nil {
 			continue
 		}
+		if o.compatible && !loader.IsHelmVersionCompatible(r.Chart.HelmVersion, version.GetVersion()) {
+			continue
+		}
 		if constraint.Check(v) {
 			data = append(data, r)
 			foundNames[r.Name] = true
@@ -36,6 +36,10 @@ func TestSearchRepositoriesCmd(t *testing.T) {
 		name:   "search for 'alpine' with versions, expect three matches",
 		cmd:    "search repo alpine --versions",
 		golden: "output/search-multiple-versions.txt",
+	}, {
+		name:   "search for 'alpine' compatible with this version of Helm",
+		cmd:    "search repo alpine --compatible",
+		golden: "output/search-compatible.txt",
 	}, {
 		name:   "search for 'alpine' with version constraint, expect one match with version 0.1.0",
 		cmd:    "search repo alpine --version '>= 0.1, < 0.2'",
@@ -80,7 +80,7 @@ func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			// strip chart metadata from the output
 			rel.Chart = nil
 
-			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription, client.ShowResources, false, false})
+			return outfmt.Write(out, &statusPrinter{rel, false, client.ShowDescription, client.ShowResources, false, false, false})
 		},
 	}
 
@@ -114,20 +114,31 @@ type statusPrinter struct {
 	showResources   bool
 	showMetadata    bool
 	hideNotes       bool
+	showSensitive   bool
+}
+
+// releaseToPrint returns the release with its sensitive values redacted,
+// unless they were asked for.
+func (s statusPrinter) releaseToPrint() *release.Release {
+	if s.showSensitive {
+		return s.release
+	}
+	return action.RedactRelease(s.release)
 }
 
 func (s statusPrinter) WriteJSON(out io.Writer) error {
-	return output.EncodeJSON(out, s.release)
+	return output.EncodeJSON(out, s.releaseToPrint())
 }
 
 func (s statusPrinter) WriteYAML(out io.Writer) error {
-	return output.EncodeYAML(out, s.release)
+	return output.EncodeYAML(out, s.releaseToPrint())
 }
 
 func (s statusPrinter) WriteTable(out io.Writer) error {
 	if s.release == nil {
 		return nil
 	}
+	s.release = s.releaseToPrint()
 	_, _ = fmt.Fprintf(out, "NAME: %s\n", s.release.Name)
 	if !s.release.Info.LastDeployed.IsZero() {
 		_, _ = fmt.Fprintf(out, "LAST DEPLOYED: %s\n", s.release.Info.LastDeployed.Format(time.ANSIC))
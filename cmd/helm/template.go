@@ -55,6 +55,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	var kubeVersion string
 	var extraAPIs []string
 	var showFiles []string
+	var previousRelease string
 
 	cmd := &cobra.Command{
 		Use:   "template [NAME] [CHART]",
@@ -80,6 +81,14 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 			client.SetRegistryClient(registryClient)
 
+			if previousRelease != "" {
+				rel, err := cfg.Releases.Last(previousRelease)
+				if err != nil {
+					return fmt.Errorf("unable to load previous release %q: %w", previousRelease, err)
+				}
+				client.PreviousRelease = rel
+			}
+
 			// This is for the case where "" is specifically passed in as a
 			// value. When there is no value passed in NoOptDefVal will be used
 			// and it is set to client. See addInstallFlags.
@@ -104,6 +113,11 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			// We ignore a potential error here because, when the --debug flag was specified,
 			// we always want to print the YAML, even if it is not valid. The error is still returned afterwards.
 			if rel != nil {
+				if settings.Debug {
+					// The output of --debug, which includes invalid YAML, must
+					// not reveal sensitive values.
+					rel = action.RedactRelease(rel)
+				}
 				var manifests bytes.Buffer
 				fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
 				if !client.DisableHooks {
@@ -197,6 +211,7 @@ func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.BoolVar(&includeCrds, "include-crds", false, "include CRDs in the templated output")
 	f.BoolVar(&skipTests, "skip-tests", false, "skip tests from templated output")
 	f.BoolVar(&client.IsUpgrade, "is-upgrade", false, "set .Release.IsUpgrade instead of .Release.IsInstall")
+	f.StringVar(&previousRelease, "previous-release", "", "name of a stored release whose last revision is exposed as .Release.Previous. Requires access to the cluster")
 	f.StringVar(&kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
 	f.StringSliceVarP(&extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
 	f.BoolVar(&client.UseReleaseName, "release-name", false, "use release name in the output-dir path.")
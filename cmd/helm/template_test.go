@@ -18,7 +18,9 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -76,6 +78,11 @@ func TestTemplateCmd(t *testing.T) {
 			cmd:    fmt.Sprintf("template '%s'", "testdata/testcharts/chart-with-template-lib-archive-dep"),
 			golden: "output/template-chart-with-template-lib-archive-dep.txt",
 		},
+		{
+			name:      "check missing previous release",
+			cmd:       fmt.Sprintf("template '%s' --previous-release missing", chartPath),
+			wantError: true,
+		},
 		{
 			name:   "check kube version",
 			cmd:    fmt.Sprintf("template --kube-version 1.16.0 '%s'", chartPath),
@@ -128,6 +135,11 @@ func TestTemplateCmd(t *testing.T) {
 			wantError: true,
 			golden:    "output/template-with-invalid-yaml-debug.txt",
 		},
+		{
+			name:   "chart with sensitive values (--debug)",
+			cmd:    fmt.Sprintf("template '%s' --debug", "testdata/testcharts/chart-with-sensitive-values"),
+			golden: "output/template-with-sensitive-values-debug.txt",
+		},
 		{
 			name:   "template skip-tests",
 			cmd:    fmt.Sprintf(`template '%s' --skip-tests`, chartPath),
@@ -165,6 +177,61 @@ func TestTemplateCmd(t *testing.T) {
 	runTestCmd(t, tests)
 }
 
+func TestTemplateCmdWithMergeKeys(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"keyed/Chart.yaml": `apiVersion: v2
+name: keyed
+version: 0.1.0
+annotations:
+  helm.sh/merge-keys: env=name
+`,
+		"keyed/values.yaml": `env:
+  - name: LOG_LEVEL
+    value: info
+`,
+		"keyed/templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: env
+data:
+{{- range .Values.env }}
+  {{ .name }}: {{ .value | quote }}
+{{- end }}
+`,
+		"base.yaml": `env:
+  - name: REGION
+    value: eu
+  - name: REPLICAS
+    value: "1"
+`,
+		"prod.yaml": `env:
+  - name: REPLICAS
+    value: "3"
+`,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := fmt.Sprintf("template '%s' -f '%s' -f '%s'", filepath.Join(dir, "keyed"), filepath.Join(dir, "base.yaml"), filepath.Join(dir, "prod.yaml"))
+	_, out, err := executeActionCommand(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`LOG_LEVEL: "info"`, `REGION: "eu"`, `REPLICAS: "3"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the output, got %q", want, out)
+		}
+	}
+}
+
 func TestTemplateVersionCompletion(t *testing.T) {
 	repoFile := "testdata/helmhome/helm/repositories.yaml"
 	repoCache := "testdata/helmhome/helm/repository"
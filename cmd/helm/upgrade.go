@@ -32,6 +32,7 @@ import (
 	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli/output"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/downloader"
@@ -74,6 +75,12 @@ or '--set' flags. Priority is given to new values.
 
     $ helm upgrade --reuse-values --set foo=bar --set foo=newbar redis ./redis
 
+Charts may ship values migrations in their 'migrations/' directory to move, rename,
+delete or set default values when their structure changes between versions. The
+migrations introduced after the version of the chart of the release, or whose
+'from' range includes it, are applied to its previous values, and the migrated
+values are listed in the output. Use '--dry-run' to review them before upgrading.
+
 The --dry-run flag will output all generated chart manifests, including Secrets
 which can contain sensitive values. To hide Kubernetes Secrets use the
 --hide-secret flag. Please carefully consider how and when these flags are used.
@@ -151,6 +158,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					instClient.Labels = client.Labels
 					instClient.EnableDNS = client.EnableDNS
 					instClient.HideSecret = client.HideSecret
+					instClient.HashSensitiveValues = client.HashSensitiveValues
 
 					if isReleaseUninstalled(versions) {
 						instClient.Replace = true
@@ -160,7 +168,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 					if err != nil {
 						return err
 					}
-					return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, instClient.HideNotes})
+					return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, instClient.HideNotes, false})
 				} else if err != nil {
 					return err
 				}
@@ -181,10 +189,6 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 			}
 
 			p := getter.All(settings)
-			vals, err := valueOpts.MergeValues(p)
-			if err != nil {
-				return err
-			}
 
 			// Check chart dependencies to make sure all are present in /charts
 			ch, err := loader.Load(chartPath)
@@ -199,10 +203,13 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 							Out:              out,
 							ChartPath:        chartPath,
 							Keyring:          client.ChartPathOptions.Keyring,
+							TrustPolicy:      settings.TrustPolicy,
 							SkipUpdate:       false,
 							Getters:          p,
 							RepositoryConfig: settings.RepositoryConfig,
 							RepositoryCache:  settings.RepositoryCache,
+							ChartCache:       settings.ChartCache,
+							Offline:          settings.Offline,
 							Debug:            settings.Debug,
 						}
 						if err := man.Update(); err != nil {
@@ -222,6 +229,12 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 				warning("This chart is deprecated")
 			}
 
+			valueOpts.MergeKeys = chartutil.ChartMergeKeys(ch)
+			vals, err := valueOpts.MergeValues(p)
+			if err != nil {
+				return err
+			}
+
 			// Create context and prepare the handle of SIGTERM
 			ctx := context.Background()
 			ctx, cancel := context.WithCancel(ctx)
@@ -245,9 +258,15 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 
 			if outfmt == output.Table {
 				fmt.Fprintf(out, "Release %q has been upgraded. Happy Helming!\n", args[0])
+				if len(client.MigratedValues) > 0 {
+					fmt.Fprintln(out, "MIGRATED VALUES:")
+					for _, m := range client.MigratedValues {
+						fmt.Fprintf(out, "  %s\n", m)
+					}
+				}
 			}
 
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, client.HideNotes})
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, false, false, client.HideNotes, false})
 		},
 	}
 
@@ -267,6 +286,7 @@ func newUpgradeCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
 	f.BoolVar(&client.ResetValues, "reset-values", false, "when upgrading, reset the values to the ones built into the chart")
 	f.BoolVar(&client.ReuseValues, "reuse-values", false, "when upgrading, reuse the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' is specified, this is ignored")
+	f.BoolVar(&client.HashSensitiveValues, "hash-sensitive-values", false, "store a keyed hash of the values the chart marks as sensitive instead of the values themselves. The key of each release is kept in a Secret apart from the release. Upgrades reusing the values of such a release must set them again")
 	f.BoolVar(&client.ResetThenReuseValues, "reset-then-reuse-values", false, "when upgrading, reset the values to the ones built into the chart, apply the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' or '--reuse-values' is specified, this is ignored")
 	f.BoolVar(&client.Wait, "wait", false, "if set, will wait until all Pods, PVCs, Services, and minimum number of Pods of a Deployment, StatefulSet, or ReplicaSet are in a ready state before marking the release as successful. It will wait for as long as --timeout")
 	f.BoolVar(&client.WaitForJobs, "wait-for-jobs", false, "if set and --wait enabled, will wait until all Jobs have been completed before marking the release as successful. It will wait for as long as --timeout")
@@ -212,6 +212,26 @@ func TestUpgradeWithValue(t *testing.T) {
 
 }
 
+func TestUpgradeWithValuesMigrations(t *testing.T) {
+	rel := release.Mock(&release.MockReleaseOptions{
+		Name: "migrated",
+		Chart: &chart.Chart{Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       "chart-with-values-migrations",
+			Version:    "1.0.0",
+		}},
+	})
+	rel.Config = map[string]interface{}{"image": map[string]interface{}{"name": "httpd"}, "name": "value"}
+
+	tests := []cmdTestCase{{
+		name:   "upgrade with values migrations",
+		cmd:    "upgrade migrated testdata/testcharts/chart-with-values-migrations --dry-run",
+		golden: "output/upgrade-with-values-migrations.txt",
+		rels:   []*release.Release{rel},
+	}}
+	runTestCmd(t, tests)
+}
+
 func TestUpgradeWithStringValue(t *testing.T) {
 	releaseName := "funny-bunny-v3"
 	relMock, ch, chartPath := prepareMockRelease(releaseName, t)
@@ -0,0 +1,223 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+const valuesDesc = `
+This command consists of multiple subcommands to work with the values of a chart.
+`
+
+const valuesEncryptionDesc = `
+Values files holding credentials can be kept encrypted. Helm decrypts them when
+they are passed with '-f', so they can be used like any other values file.
+
+Encrypted files start with a '$HELM_ENCRYPTED_VALUES' header. Files named with
+the '.enc.yaml' extension must be encrypted. They are encrypted with a
+symmetric key using XChaCha20-Poly1305. Files encrypted for age recipients are
+not supported.
+
+Keys are read from the '$HELM_VALUES_KEY' environment variable, which holds a
+key encoded in base64, and from the keyring file at '--values-keyring' (default
+'$HELM_VALUES_KEYRING', or 'values.keyring' in the Helm configuration
+directory). The keyring holds one key encoded in base64 per line.
+`
+
+const valuesEncryptDesc = `
+This command encrypts a values file in place.
+
+The file is encrypted with the key from '$HELM_VALUES_KEY' or else the first key
+of the keyring. When there is no key, a new one is generated and added to the
+keyring. Share it with whoever must deploy with the file, and back it up: the
+file cannot be decrypted without it.
+` + valuesEncryptionDesc
+
+const valuesDecryptDesc = `
+This command prints the decrypted contents of an encrypted values file. With
+'--in-place', the file is decrypted in place instead.
+` + valuesEncryptionDesc
+
+const valuesEditDesc = `
+This command opens the decrypted contents of an encrypted values file in an
+editor, and encrypts them back into the file once the editor exits. The file is
+created if it does not exist.
+
+The editor is taken from '$HELM_EDITOR', '$EDITOR', or defaults to vi.
+` + valuesEncryptionDesc
+
+const valuesExplainDesc = `
+This command computes the values of a chart (directory, file, or URL) the way an
+install would, and shows where each of them was set: the values.yaml of the
+chart or of one of its dependencies, with the line of the key, a values file
+given with '-f', a '--set' flag, or the globals of a parent chart.
+
+Values set by more than one source list the sources they override. Values set
+by the user that match nothing in the defaults of the chart and its
+dependencies are flagged, as they are often typos.
+
+    $ helm values explain ./mychart -f prod.yaml --set image.tag=1.2.3
+`
+
+func newValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "values",
+		Short: "work with the values of a chart",
+		Long:  valuesDesc,
+		Args:  require.NoArgs,
+	}
+
+	cmd.AddCommand(
+		newValuesExplainCmd(cfg, out),
+		newValuesEncryptCmd(out),
+		newValuesDecryptCmd(out),
+		newValuesEditCmd(out),
+	)
+
+	return cmd
+}
+
+func newValuesExplainCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewValuesExplain(cfg)
+	valueOpts := &values.Options{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "explain CHART",
+		Short: "show where each value of a chart comes from",
+		Long:  valuesExplainDesc,
+		Args:  require.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return noMoreArgsComp()
+			}
+			return compListCharts(toComplete, true)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			registryClient, err := newRegistryClient(client.CertFile, client.KeyFile, client.CaFile,
+				client.InsecureSkipTLSverify, client.PlainHTTP)
+			if err != nil {
+				return fmt.Errorf("missing registry client: %w", err)
+			}
+			client.SetRegistryClient(registryClient)
+
+			if client.Version == "" && client.Devel {
+				debug("setting version to >0.0.0-0")
+				client.Version = ">0.0.0-0"
+			}
+			cp, err := client.ChartPathOptions.LocateChart(args[0], settings)
+			if err != nil {
+				return err
+			}
+			chrt, err := loader.Load(cp)
+			if err != nil {
+				return err
+			}
+
+			valueOpts.MergeKeys = chartutil.ChartMergeKeys(chrt)
+			vals, sources, err := valueOpts.MergeValuesWithSources(getter.All(settings))
+			if err != nil {
+				return err
+			}
+			explained, err := client.Run(chrt, vals, sources)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, valuesExplainWriter(explained))
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.Devel, "devel", false, "use development versions, too. Equivalent to version '>0.0.0-0'. If --version is set, this is ignored")
+	addValueOptionsFlags(f, valueOpts)
+	addChartPathOptionsFlags(f, &client.ChartPathOptions)
+	bindOutputFlag(cmd, &outfmt)
+
+	err := cmd.RegisterFlagCompletionFunc("version", func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return compVersionFlag(args[0], toComplete)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cmd
+}
+
+type valuesExplainWriter []chartutil.ExplainedValue
+
+func (v valuesExplainWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, v)
+}
+
+func (v valuesExplainWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, v)
+}
+
+func (v valuesExplainWriter) WriteTable(out io.Writer) error {
+	tbl := uitable.New()
+	tbl.AddRow("KEY", "VALUE", "SOURCE", "NOTES")
+	for _, ev := range v {
+		source := "<unknown>"
+		if ev.Source != nil {
+			source = ev.Source.String()
+		}
+		var notes []string
+		if ev.Unknown {
+			notes = append(notes, "not in chart defaults")
+		}
+		if len(ev.Overridden) > 0 {
+			overridden := make([]string, len(ev.Overridden))
+			for i, s := range ev.Overridden {
+				overridden[i] = s.String()
+			}
+			notes = append(notes, "overrides "+strings.Join(overridden, ", "))
+		}
+		tbl.AddRow(ev.Path, formatExplainedValue(ev.Value), source, strings.Join(notes, "; "))
+	}
+	return output.EncodeTable(out, tbl)
+}
+
+// formatExplainedValue formats strings as they are and other values as JSON.
+func formatExplainedValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,270 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+func newValuesEncryptCmd(out io.Writer) *cobra.Command {
+	var keyring string
+
+	cmd := &cobra.Command{
+		Use:   "encrypt FILE",
+		Short: "encrypt a values file",
+		Long:  valuesEncryptDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			key, err := encryptionKey(out, valuesKeyring(keyring))
+			if err != nil {
+				return err
+			}
+			encrypted, err := values.Encrypt(data, key)
+			if err != nil {
+				return errors.Wrapf(err, "unable to encrypt %s", args[0])
+			}
+			if err := writeValuesFile(args[0], encrypted); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Encrypted %s\n", args[0])
+			return nil
+		},
+	}
+
+	addValuesKeyringFlag(cmd, &keyring)
+	return cmd
+}
+
+func newValuesDecryptCmd(out io.Writer) *cobra.Command {
+	var keyring string
+	var inPlace bool
+
+	cmd := &cobra.Command{
+		Use:   "decrypt FILE",
+		Short: "decrypt a values file",
+		Long:  valuesDecryptDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			plain, err := decryptValuesFile(args[0], valuesKeyring(keyring))
+			if err != nil {
+				return err
+			}
+			if !inPlace {
+				_, err := out.Write(plain)
+				return err
+			}
+			if err := writeValuesFile(args[0], plain); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Decrypted %s\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&inPlace, "in-place", "i", false, "decrypt the file in place instead of printing its contents")
+	addValuesKeyringFlag(cmd, &keyring)
+	return cmd
+}
+
+func newValuesEditCmd(out io.Writer) *cobra.Command {
+	var keyring string
+
+	cmd := &cobra.Command{
+		Use:   "edit FILE",
+		Short: "edit an encrypted values file",
+		Long:  valuesEditDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return editValuesFile(out, args[0], valuesKeyring(keyring))
+		},
+	}
+
+	addValuesKeyringFlag(cmd, &keyring)
+	return cmd
+}
+
+func addValuesKeyringFlag(cmd *cobra.Command, keyring *string) {
+	cmd.Flags().StringVar(keyring, "values-keyring", "", "location of the keys of encrypted values files (default \"$HELM_VALUES_KEYRING\" or \"values.keyring\" in the Helm configuration directory)")
+}
+
+// valuesKeyring returns the keyring set by the --values-keyring flag, or the
+// default one.
+func valuesKeyring(keyring string) string {
+	if keyring == "" {
+		return values.DefaultKeyring()
+	}
+	return keyring
+}
+
+// encryptionKey returns the key to encrypt values files with, generating one
+// when there is none.
+func encryptionKey(out io.Writer, keyring string) ([]byte, error) {
+	keys, err := values.LoadKeys(keyring)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return keys[0], nil
+	}
+
+	key, err := values.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := values.AddKey(keyring, key); err != nil {
+		return nil, errors.Wrap(err, "unable to save the new key")
+	}
+	fmt.Fprintf(out, "Generated a new key in %s. Back it up: encrypted files cannot be decrypted without it.\n", keyring)
+	if keys, err = values.LoadKeys(keyring); err != nil {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+func decryptValuesFile(path, keyring string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := values.LoadKeys(keyring)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := values.Decrypt(data, keys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decrypt %s", path)
+	}
+	return plain, nil
+}
+
+// writeValuesFile replaces the contents of the file at path, keeping its
+// permissions.
+func writeValuesFile(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+func editValuesFile(out io.Writer, path, keyring string) error {
+	var plain, key []byte
+	if data, err := os.ReadFile(path); err == nil {
+		keys, err := values.LoadKeys(keyring)
+		if err != nil {
+			return err
+		}
+		if plain, err = values.Decrypt(data, keys); err != nil {
+			return errors.Wrapf(err, "unable to decrypt %s", path)
+		}
+		// Encrypt the file back with the key it was encrypted with.
+		if key, err = values.FindKey(data, keys); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if key == nil {
+		var err error
+		if key, err = encryptionKey(out, keyring); err != nil {
+			return err
+		}
+	}
+
+	// The decrypted contents only live in a private temporary file for the
+	// time of the edit.
+	dir, err := os.MkdirTemp("", "helm-values-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(tmp, plain, 0600); err != nil {
+		return err
+	}
+
+	if err := runEditor(tmp); err != nil {
+		return err
+	}
+	edited, err := os.ReadFile(tmp)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, plain) {
+		fmt.Fprintf(out, "%s unchanged\n", path)
+		return nil
+	}
+	var check map[string]interface{}
+	if err := yaml.Unmarshal(edited, &check); err != nil {
+		return errors.Wrapf(err, "the edited values are not valid YAML; %s was left unchanged", path)
+	}
+
+	encrypted, err := values.Encrypt(edited, key)
+	if err != nil {
+		return err
+	}
+	if err := writeValuesFile(path, encrypted); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Encrypted %s\n", path)
+	return nil
+}
+
+// runEditor opens path in the editor of the user.
+func runEditor(path string) error {
+	editor := os.Getenv("HELM_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// Editors are often set with arguments, such as "code --wait".
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, editor+` "$1"`, "helm", path)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command(shell, flag, editor, path)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "editor %q failed", editor)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+func TestValuesExplainCmd(t *testing.T) {
+	chartPath := "testdata/testcharts/values-explain"
+	tests := []cmdTestCase{{
+		name:   "explain values",
+		cmd:    "values explain " + chartPath + " -f testdata/values-explain.yaml --set sub.port=8080",
+		golden: "output/values-explain.txt",
+	}, {
+		name:   "explain values as json",
+		cmd:    "values explain " + chartPath + " --set image.tag=2.0 -o json",
+		golden: "output/values-explain.json",
+	}, {
+		name:      "explain values with missing chart",
+		cmd:       "values explain testdata/testcharts/does-not-exist",
+		golden:    "output/values-explain-missing-chart.txt",
+		wantError: true,
+	}}
+	runTestCmd(t, tests)
+}
+
+func TestValuesExplainFileCompletion(t *testing.T) {
+	checkFileCompletion(t, "values", false)
+	checkFileCompletion(t, "values explain", true)
+	checkFileCompletion(t, "values explain mychart", false)
+}
+
+func TestValuesEncryptDecryptCmd(t *testing.T) {
+	t.Setenv(values.KeyEnvVar, "")
+	dir := t.TempDir()
+	keyring := filepath.Join(dir, "values.keyring")
+	file := filepath.Join(dir, "secrets.enc.yaml")
+	plain := "password: hunter22\n"
+	if err := os.WriteFile(file, []byte(plain), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, out, err := executeActionCommand("values encrypt " + file + " --values-keyring " + keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Generated a new key in "+keyring) {
+		t.Errorf("Expected a new key to be generated, got %q", out)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !values.IsEncrypted(data) {
+		t.Fatalf("Expected %s to be encrypted", file)
+	}
+
+	_, out, err = executeActionCommand("values decrypt " + file + " --values-keyring " + keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != plain {
+		t.Errorf("Expected %q, got %q", plain, out)
+	}
+
+	_, _, err = executeActionCommand("values decrypt " + file + " --values-keyring " + filepath.Join(dir, "missing"))
+	if err == nil || !strings.Contains(err.Error(), "no key with ID") {
+		t.Errorf("Expected a missing key error, got %v", err)
+	}
+
+	t.Setenv("HELM_EDITOR", "sed -i s/hunter22/hunter33/")
+	if _, _, err := executeActionCommand("values edit " + file + " --values-keyring " + keyring); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = executeActionCommand("values decrypt " + file + " -i --values-keyring " + keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "password: hunter33\n" {
+		t.Errorf("Expected the edited values to be decrypted in place, got %q", data)
+	}
+	// The default keyring is read from the environment when the command runs.
+	envKeyring := filepath.Join(dir, "env.keyring")
+	t.Setenv(values.KeyringEnvVar, envKeyring)
+	_, out, err = executeActionCommand("values encrypt " + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Generated a new key in "+envKeyring) {
+		t.Errorf("Expected a new key to be generated in %s, got %q", envKeyring, out)
+	}
+}
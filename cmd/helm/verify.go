@@ -34,6 +34,12 @@ tampered with, and was packaged by a trusted provider.
 This command can be used to verify a local chart. Several other commands provide
 '--verify' flags that run the same validation. To generate a signed package, use
 the 'helm package --sign' command.
+
+Charts signed with SSH keys are verified against an allowed signers file, in the
+format of 'ssh-keygen -Y verify', given to '--keyring'. Signers restricted to
+namespaces must be allowed the "helm" namespace.
+
+  $ helm verify --keyring ~/.config/helm/allowed_signers mychart-0.1.0.tgz
 `
 
 func newVerifyCmd(out io.Writer) *cobra.Command {
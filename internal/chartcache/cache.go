@@ -0,0 +1,297 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chartcache implements the cache of chart archives shared by the
+// commands fetching charts, keyed by the digests of the archives.
+package chartcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/fileutil"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// ErrNotFound indicates that a chart is not in the cache.
+var ErrNotFound = errors.New("not found in the chart cache")
+
+var hexDigestPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Cache is a directory of chart archives, stored under their sha256 digests,
+// along with their provenance files.
+//
+// References, such as the URLs the charts were downloaded from, are linked to
+// the digests of the charts, so that charts can be found by reference when
+// their digests cannot be looked up, as when offline.
+type Cache struct {
+	dir string
+}
+
+// Entry is a chart archive of the cache.
+type Entry struct {
+	// Digest is the digest of the archive, as "sha256:<hex>".
+	Digest string `json:"digest"`
+	// Name and Version are those of the chart, empty if the archive cannot be
+	// loaded.
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Size is the size of the archive and of its provenance file, in bytes.
+	Size int64 `json:"size"`
+	// Provenance indicates whether the provenance file of the chart is cached.
+	Provenance bool `json:"provenance"`
+	// LastUsed is the last time the archive was stored or read.
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// New returns the cache in dir.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Digest returns the digest of a chart archive, as "sha256:<hex>".
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Get returns the chart archive of the given digest, either "sha256:<hex>" or
+// the bare hex digest of chart repository indexes.
+func (c *Cache) Get(digest string) ([]byte, error) {
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if Digest(data) != normalize(digest) {
+		// The archive was corrupted, it is fetched again.
+		os.Remove(path)
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, nil
+}
+
+// Put stores a chart archive and returns its digest.
+func (c *Cache) Put(data []byte) (string, error) {
+	digest := Digest(data)
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return digest, fileutil.AtomicWriteFile(path, bytes.NewReader(data), 0644)
+}
+
+// GetProvenance returns the provenance file of the chart archive of the given
+// digest.
+func (c *Cache) GetProvenance(digest string) ([]byte, error) {
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path + ".prov")
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// PutProvenance stores the provenance file of the chart archive of the given
+// digest, which must be in the cache.
+func (c *Cache) PutProvenance(digest string, data []byte) error {
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return errors.Wrapf(err, "no chart archive for %s", digest)
+	}
+	return fileutil.AtomicWriteFile(path+".prov", bytes.NewReader(data), 0644)
+}
+
+// Link links ref to the chart archive of the given digest.
+func (c *Cache) Link(ref, digest string) error {
+	if _, err := c.archivePath(digest); err != nil {
+		return err
+	}
+	path := c.refPath(ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(path, strings.NewReader(normalize(digest)), 0644)
+}
+
+// Resolve returns the digest of the chart archive ref is linked to, if it is
+// in the cache.
+func (c *Cache) Resolve(ref string) (string, error) {
+	data, err := os.ReadFile(c.refPath(ref))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	digest := strings.TrimSpace(string(data))
+	path, err := c.archivePath(digest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return digest, nil
+}
+
+// List returns the chart archives of the cache, by name and version.
+func (c *Cache) List() ([]*Entry, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "sha256", "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, path := range files {
+		hexDigest := strings.TrimSuffix(filepath.Base(path), ".tgz")
+		if !hexDigestPattern.MatchString(hexDigest) {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		entry := &Entry{
+			Digest:   "sha256:" + hexDigest,
+			Size:     fi.Size(),
+			LastUsed: fi.ModTime(),
+		}
+		if prov, err := os.Stat(path + ".prov"); err == nil {
+			entry.Size += prov.Size()
+			entry.Provenance = true
+		}
+		if ch, err := loader.LoadFile(path); err == nil {
+			entry.Name = ch.Name()
+			entry.Version = ch.Metadata.Version
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		if entries[i].Version != entries[j].Version {
+			return entries[i].Version < entries[j].Version
+		}
+		return entries[i].Digest < entries[j].Digest
+	})
+	return entries, nil
+}
+
+// Prune removes the least recently used chart archives until the cache holds
+// at most maxSize bytes, along with the references linked to them. It returns
+// the removed archives.
+func (c *Cache) Prune(maxSize int64) ([]*Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+
+	var removed []*Entry
+	for _, entry := range entries {
+		if size <= maxSize {
+			break
+		}
+		path, _ := c.archivePath(entry.Digest)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		os.Remove(path + ".prov")
+		size -= entry.Size
+		removed = append(removed, entry)
+	}
+	return removed, c.removeDanglingRefs()
+}
+
+// removeDanglingRefs removes the references linked to chart archives no
+// longer in the cache.
+func (c *Cache) removeDanglingRefs() error {
+	refs, err := filepath.Glob(filepath.Join(c.dir, "refs", "*"))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			continue
+		}
+		path, err := c.archivePath(strings.TrimSpace(string(data)))
+		if err != nil {
+			os.Remove(ref)
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.Remove(ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archivePath returns the path of the chart archive of the given digest.
+func (c *Cache) archivePath(digest string) (string, error) {
+	hexDigest := strings.TrimPrefix(normalize(digest), "sha256:")
+	if !hexDigestPattern.MatchString(hexDigest) {
+		return "", errors.Errorf("invalid chart digest %q", digest)
+	}
+	return filepath.Join(c.dir, "sha256", hexDigest+".tgz"), nil
+}
+
+// refPath returns the path of the link of ref.
+func (c *Cache) refPath(ref string) string {
+	sum := sha256.Sum256([]byte(ref))
+	return filepath.Join(c.dir, "refs", hex.EncodeToString(sum[:]))
+}
+
+// normalize returns digest as "sha256:<hex>".
+func normalize(digest string) string {
+	if strings.Contains(digest, ":") {
+		return digest
+	}
+	return "sha256:" + digest
+}
@@ -0,0 +1,142 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartcache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	signtestArchive = "../../pkg/downloader/testdata/signtest-0.1.0.tgz"
+	subchartArchive = "../../pkg/downloader/testdata/local-subchart-0.1.0.tgz"
+)
+
+func TestCache(t *testing.T) {
+	is := assert.New(t)
+	c := New(t.TempDir())
+
+	data, err := os.ReadFile(signtestArchive)
+	require.NoError(t, err)
+	prov, err := os.ReadFile(signtestArchive + ".prov")
+	require.NoError(t, err)
+
+	digest := Digest(data)
+	_, err = c.Get(digest)
+	is.ErrorIs(err, ErrNotFound)
+	_, err = c.Get("md5:0123")
+	is.EqualError(err, `invalid chart digest "md5:0123"`)
+
+	stored, err := c.Put(data)
+	require.NoError(t, err)
+	is.Equal(digest, stored)
+	got, err := c.Get(digest)
+	require.NoError(t, err)
+	is.Equal(data, got)
+	// Digests of chart repository indexes have no algorithm.
+	got, err = c.Get(strings.TrimPrefix(digest, "sha256:"))
+	require.NoError(t, err)
+	is.Equal(data, got)
+
+	_, err = c.GetProvenance(digest)
+	is.ErrorIs(err, ErrNotFound)
+	require.NoError(t, c.PutProvenance(digest, prov))
+	got, err = c.GetProvenance(digest)
+	require.NoError(t, err)
+	is.Equal(prov, got)
+	is.Error(c.PutProvenance(Digest([]byte("missing")), prov))
+
+	const ref = "https://example.com/charts/signtest-0.1.0.tgz"
+	_, err = c.Resolve(ref)
+	is.ErrorIs(err, ErrNotFound)
+	require.NoError(t, c.Link(ref, digest))
+	resolved, err := c.Resolve(ref)
+	require.NoError(t, err)
+	is.Equal(digest, resolved)
+
+	// Corrupted archives are removed.
+	path, err := c.archivePath(digest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("corrupted"), 0644))
+	_, err = c.Get(digest)
+	is.ErrorIs(err, ErrNotFound)
+	_, err = c.Resolve(ref)
+	is.ErrorIs(err, ErrNotFound)
+}
+
+func TestCacheListAndPrune(t *testing.T) {
+	is := assert.New(t)
+	c := New(t.TempDir())
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	is.Empty(entries)
+
+	var digests []string
+	var sizes []int64
+	for i, archive := range []string{signtestArchive, subchartArchive} {
+		data, err := os.ReadFile(archive)
+		require.NoError(t, err)
+		digest, err := c.Put(data)
+		require.NoError(t, err)
+		require.NoError(t, c.Link(archive, digest))
+		digests = append(digests, digest)
+		sizes = append(sizes, int64(len(data)))
+
+		// The signtest chart is the least recently used.
+		used := time.Date(2024, 1, 2+i, 0, 0, 0, 0, time.UTC)
+		path, err := c.archivePath(digest)
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(path, used, used))
+	}
+
+	entries, err = c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	is.Equal("local-subchart", entries[0].Name)
+	is.Equal("0.1.0", entries[0].Version)
+	is.Equal(digests[1], entries[0].Digest)
+	is.Equal(sizes[1], entries[0].Size)
+	is.Equal("signtest", entries[1].Name)
+	is.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), entries[1].LastUsed.UTC())
+
+	// Reading a chart makes it the most recently used.
+	_, err = c.Get(digests[0])
+	require.NoError(t, err)
+	removed, err := c.Prune(sizes[0])
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	is.Equal("local-subchart", removed[0].Name)
+	_, err = c.Resolve(subchartArchive)
+	is.ErrorIs(err, ErrNotFound)
+	refs, err := filepath.Glob(filepath.Join(c.dir, "refs", "*"))
+	require.NoError(t, err)
+	is.Len(refs, 1)
+
+	removed, err = c.Prune(0)
+	require.NoError(t, err)
+	is.Len(removed, 1)
+	entries, err = c.List()
+	require.NoError(t, err)
+	is.Empty(entries)
+}
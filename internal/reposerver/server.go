@@ -0,0 +1,257 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reposerver implements a server serving a directory of chart archives
+// as a chart repository.
+package reposerver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/internal/fileutil"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// MaxUploadSize is the maximum size of an uploaded chart archive, along with
+// its provenance file.
+const MaxUploadSize = 64 << 20
+
+// Server serves a directory of chart archives as a chart repository.
+//
+// The index of the repository is generated from the archives in the directory
+// and its subdirectories, as by 'helm repo index', and generated again when
+// they change. The other files of the directory are served as they are.
+//
+// When credentials are set, charts can be uploaded with basic auth by POST
+// requests to /api/charts, as with ChartMuseum, whose body is either the chart
+// archive or a multipart form with the chart archive in the 'chart' field and
+// its provenance file in the optional 'prov' field.
+type Server struct {
+	// Dir is the directory of chart archives.
+	Dir string
+	// URL is the URL of the repository the URLs of the charts in the index
+	// are relative to. The URLs are relative to the index when empty.
+	URL string
+	// Username and Password are the credentials required to upload charts.
+	// Uploads are disabled when they are empty.
+	Username string
+	Password string
+	// Log receives a message for each generated index and uploaded chart. It
+	// may be nil.
+	Log func(format string, v ...interface{})
+
+	mu sync.Mutex
+	// state identifies the archives the index was generated from.
+	state   string
+	index   []byte
+	etag    string
+	modTime time.Time
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/charts":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		s.upload(w, r)
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	case r.URL.Path == "/index.yaml":
+		s.serveIndex(w, r)
+	default:
+		http.FileServer(http.Dir(s.Dir)).ServeHTTP(w, r)
+	}
+}
+
+// serveIndex serves the index of the repository, generating it again if the
+// archives have changed.
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	err := s.generateIndex()
+	index, etag, modTime := s.index, s.etag, s.modTime
+	s.mu.Unlock()
+	if err != nil {
+		s.logf("Failed to generate the index of %s: %s", s.Dir, err)
+		http.Error(w, "failed to generate the index", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "index.yaml", modTime, bytes.NewReader(index))
+}
+
+// generateIndex generates the index of the repository if the archives have
+// changed since it was last generated.
+func (s *Server) generateIndex() error {
+	archives, err := s.archives()
+	if err != nil {
+		return err
+	}
+	var state strings.Builder
+	for _, archive := range archives {
+		fi, err := os.Stat(archive)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&state, "%s %d %d\n", archive, fi.Size(), fi.ModTime().UnixNano())
+	}
+	if s.index != nil && state.String() == s.state {
+		return nil
+	}
+
+	index, err := repo.IndexDirectory(s.Dir, s.URL)
+	if err != nil {
+		return err
+	}
+	index.SortEntries()
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	s.state = state.String()
+	s.index = data
+	s.etag = `"` + hex.EncodeToString(sum[:]) + `"`
+	s.modTime = time.Now()
+	s.logf("Generated the index of %s with %d charts", s.Dir, len(index.Entries))
+	return nil
+}
+
+// archives returns the chart archives indexed by repo.IndexDirectory.
+func (s *Server) archives() ([]string, error) {
+	archives, err := filepath.Glob(filepath.Join(s.Dir, "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	moreArchives, err := filepath.Glob(filepath.Join(s.Dir, "**/*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	return append(archives, moreArchives...), nil
+}
+
+// upload saves the chart uploaded by r.
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	if s.Username == "" && s.Password == "" {
+		http.Error(w, "uploads are disabled", http.StatusForbidden)
+		return
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(username), []byte(s.Username)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(s.Password)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="helm"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadSize)
+	archive, prov, err := readUpload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ch, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "invalid chart archive").Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.tgz", ch.Name(), ch.Metadata.Version)
+	path := filepath.Join(s.Dir, name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		http.Error(w, fmt.Sprintf("%s already exists", name), http.StatusConflict)
+		return
+	}
+	if prov != nil {
+		if err := fileutil.AtomicWriteFile(path+".prov", bytes.NewReader(prov), 0644); err != nil {
+			s.logf("Failed to save %s: %s", name+".prov", err)
+			http.Error(w, "failed to save the provenance file", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := fileutil.AtomicWriteFile(path, bytes.NewReader(archive), 0644); err != nil {
+		s.logf("Failed to save %s: %s", name, err)
+		http.Error(w, "failed to save the chart", http.StatusInternalServerError)
+		return
+	}
+	s.logf("Uploaded %s", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, `{"saved":true}`)
+}
+
+// readUpload reads the chart archive and the provenance file, if any, uploaded
+// by r.
+func readUpload(r *http.Request) (archive, prov []byte, err error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		archive, err = io.ReadAll(r.Body)
+		return archive, nil, err
+	}
+
+	if err := r.ParseMultipartForm(MaxUploadSize); err != nil {
+		return nil, nil, err
+	}
+	defer r.MultipartForm.RemoveAll()
+	archive, err = readFormFile(r, "chart")
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := r.MultipartForm.File["prov"]; ok {
+		if prov, err = readFormFile(r, "prov"); err != nil {
+			return nil, nil, err
+		}
+	}
+	return archive, prov, nil
+}
+
+func readFormFile(r *http.Request, field string) ([]byte, error) {
+	f, _, err := r.FormFile(field)
+	if err != nil {
+		return nil, errors.Wrapf(err, "missing %s", field)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (s *Server) logf(format string, v ...interface{}) {
+	if s.Log != nil {
+		s.Log(format, v...)
+	}
+}
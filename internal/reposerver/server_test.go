@@ -0,0 +1,175 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reposerver
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const testdata = "../../pkg/downloader/testdata"
+
+func readFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testdata, name))
+	require.NoError(t, err)
+	return data
+}
+
+func getIndex(t *testing.T, url string) *repo.IndexFile {
+	t.Helper()
+	resp, err := http.Get(url + "/index.yaml")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	index := &repo.IndexFile{}
+	require.NoError(t, yaml.Unmarshal(data, index))
+	return index
+}
+
+func TestServerIndex(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "signtest-0.1.0.tgz"), readFile(t, "signtest-0.1.0.tgz"), 0644))
+
+	srv := httptest.NewServer(&Server{Dir: dir})
+	defer srv.Close()
+
+	index := getIndex(t, srv.URL)
+	is.True(index.Has("signtest", "0.1.0"))
+	cv, err := index.Get("signtest", "0.1.0")
+	require.NoError(t, err)
+	is.Equal([]string{"signtest-0.1.0.tgz"}, cv.URLs)
+
+	// The index is only sent if it has changed.
+	resp, err := http.Get(srv.URL + "/index.yaml")
+	require.NoError(t, err)
+	resp.Body.Close()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/index.yaml", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	is.Equal(http.StatusNotModified, resp.StatusCode)
+
+	// The index is generated again when the charts change.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local-subchart-0.1.0.tgz"), readFile(t, "local-subchart-0.1.0.tgz"), 0644))
+	index = getIndex(t, srv.URL)
+	is.True(index.Has("signtest", "0.1.0"))
+	is.True(index.Has("local-subchart", "0.1.0"))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	is.Equal(http.StatusOK, resp.StatusCode)
+
+	// The charts are served as they are.
+	resp, err = http.Get(srv.URL + "/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	is.Equal(readFile(t, "signtest-0.1.0.tgz"), data)
+
+	req, err = http.NewRequest(http.MethodPut, srv.URL+"/index.yaml", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	is.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServerIndexURL(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "signtest-0.1.0.tgz"), readFile(t, "signtest-0.1.0.tgz"), 0644))
+
+	srv := httptest.NewServer(&Server{Dir: dir, URL: "https://charts.example.com"})
+	defer srv.Close()
+
+	cv, err := getIndex(t, srv.URL).Get("signtest", "0.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://charts.example.com/signtest-0.1.0.tgz"}, cv.URLs)
+}
+
+func TestServerUpload(t *testing.T) {
+	is := assert.New(t)
+	dir := t.TempDir()
+	s := &Server{Dir: dir}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	upload := func(body io.Reader, contentType, username, password string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/charts", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	chart := readFile(t, "local-subchart-0.1.0.tgz")
+
+	is.Equal(http.StatusForbidden, upload(bytes.NewReader(chart), "application/octet-stream", "", ""))
+
+	s.Username, s.Password = "username", "password"
+	is.Equal(http.StatusUnauthorized, upload(bytes.NewReader(chart), "application/octet-stream", "", ""))
+	is.Equal(http.StatusUnauthorized, upload(bytes.NewReader(chart), "application/octet-stream", "username", "wrong"))
+	is.Equal(http.StatusBadRequest, upload(bytes.NewReader([]byte("not a chart")), "application/octet-stream", "username", "password"))
+
+	is.Equal(http.StatusCreated, upload(bytes.NewReader(chart), "application/octet-stream", "username", "password"))
+	data, err := os.ReadFile(filepath.Join(dir, "local-subchart-0.1.0.tgz"))
+	require.NoError(t, err)
+	is.Equal(chart, data)
+	is.Equal(http.StatusConflict, upload(bytes.NewReader(chart), "application/octet-stream", "username", "password"))
+
+	// Provenance files are uploaded along with charts in multipart forms.
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	for field, name := range map[string]string{"chart": "signtest-0.1.0.tgz", "prov": "signtest-0.1.0.tgz.prov"} {
+		fw, err := w.CreateFormFile(field, name)
+		require.NoError(t, err)
+		_, err = fw.Write(readFile(t, name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	is.Equal(http.StatusCreated, upload(&form, w.FormDataContentType(), "username", "password"))
+	data, err = os.ReadFile(filepath.Join(dir, "signtest-0.1.0.tgz.prov"))
+	require.NoError(t, err)
+	is.Equal(readFile(t, "signtest-0.1.0.tgz.prov"), data)
+
+	index := getIndex(t, srv.URL)
+	is.True(index.Has("signtest", "0.1.0"))
+	is.True(index.Has("local-subchart", "0.1.0"))
+}
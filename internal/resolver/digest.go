@@ -0,0 +1,111 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+// Statuses of the dependencies of lock files, as returned by VerifyDigests.
+const (
+	// DigestOK is the status of dependencies whose archive matches their digest.
+	DigestOK = "ok"
+	// DigestMismatch is the status of dependencies whose archive does not
+	// match their digest.
+	DigestMismatch = "digest mismatch"
+	// DigestMissing is the status of dependencies without archive in the
+	// charts directory.
+	DigestMissing = "missing"
+	// DigestUnknown is the status of dependencies locked without digest.
+	DigestUnknown = "no digest"
+	// DigestSkipped is the status of local dependencies, which have no digest.
+	DigestSkipped = "skipped"
+)
+
+// HashArchive generates the digest of a chart archive, as recorded for the
+// dependencies of lock files.
+func HashArchive(path string) (string, error) {
+	s, err := provenance.DigestFile(path)
+	return "sha256:" + s, err
+}
+
+// IsLocalDependency returns whether the dependency is not fetched from a
+// repository, in which case it has no digest.
+func IsLocalDependency(dep *chart.Dependency) bool {
+	return dep.Repository == "" || strings.HasPrefix(dep.Repository, "file://")
+}
+
+// VerifyDigests checks the chart archives in the charts directory of the
+// chart at chartpath against the digests of the dependencies of lock.
+//
+// It returns the status of each dependency, in the order of the lock file,
+// and an error listing the dependencies that failed verification, if any.
+func VerifyDigests(chartpath string, lock *chart.Lock) ([]string, error) {
+	archives, err := filepath.Glob(filepath.Join(chartpath, "charts", "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]string{}
+	for _, archive := range archives {
+		c, err := loader.LoadFile(archive)
+		if err != nil {
+			continue
+		}
+		key := fmt.Sprintf("%s-%s", c.Name(), c.Metadata.Version)
+		if _, ok := found[key]; !ok {
+			found[key] = archive
+		}
+	}
+
+	statuses := make([]string, len(lock.Dependencies))
+	var failed []string
+	for i, dep := range lock.Dependencies {
+		archive, ok := found[fmt.Sprintf("%s-%s", dep.Name, dep.Version)]
+		switch {
+		case IsLocalDependency(dep):
+			statuses[i] = DigestSkipped
+		case dep.Digest == "":
+			statuses[i] = DigestUnknown
+		case !ok:
+			statuses[i] = DigestMissing
+		default:
+			digest, err := HashArchive(archive)
+			if err != nil {
+				return nil, err
+			}
+			statuses[i] = DigestOK
+			if digest != dep.Digest {
+				statuses[i] = DigestMismatch
+			}
+		}
+		if statuses[i] != DigestOK && statuses[i] != DigestSkipped {
+			failed = append(failed, fmt.Sprintf("%s (%s)", dep.Name, statuses[i]))
+		}
+	}
+	if len(failed) > 0 {
+		return statuses, errors.Errorf("dependencies do not match the lock file: %s", strings.Join(failed, ", "))
+	}
+	return statuses, nil
+}
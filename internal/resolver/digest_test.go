@@ -0,0 +1,76 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestVerifyDigests(t *testing.T) {
+	const archive = "../../pkg/downloader/testdata/signtest-0.1.0.tgz"
+	digest, err := HashArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chartpath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(chartpath, "charts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The archive is found by the name and version of its chart.
+	if err := os.WriteFile(filepath.Join(chartpath, "charts", "downloaded.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := &chart.Lock{Dependencies: []*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts", Digest: digest},
+	}}
+	statuses, err := VerifyDigests(chartpath, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(statuses, []string{DigestOK}) {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+
+	lock.Dependencies = []*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts", Digest: "sha256:0000"},
+		{Name: "signtest", Version: "0.2.0", Repository: "https://example.com/charts", Digest: digest},
+		{Name: "other", Version: "1.0.0", Repository: "oci://example.com/charts"},
+		{Name: "local", Version: "1.0.0", Repository: "file://../local"},
+		{Name: "vendored", Version: "1.0.0"},
+	}
+	statuses, err = VerifyDigests(chartpath, lock)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if expect := "dependencies do not match the lock file: signtest (digest mismatch), signtest (missing), other (no digest)"; err.Error() != expect {
+		t.Errorf("expected error %q, got %q", expect, err)
+	}
+	if expect := []string{DigestMismatch, DigestMissing, DigestUnknown, DigestSkipped, DigestSkipped}; !reflect.DeepEqual(statuses, expect) {
+		t.Errorf("expected statuses %v, got %v", expect, statuses)
+	}
+}
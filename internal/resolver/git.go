@@ -0,0 +1,171 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/vcs"
+	"github.com/pkg/errors"
+)
+
+// GitScheme is the prefix of the repositories of dependencies fetched from git
+// repositories, as in "git+https://example.com/charts.git//charts/lib?ref=v1.0.0".
+const GitScheme = "git+"
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsGitRepository returns whether the repository of a dependency is a git
+// repository.
+func IsGitRepository(repository string) bool {
+	return strings.HasPrefix(repository, GitScheme)
+}
+
+// GitSource is the location of a chart in a git repository.
+type GitSource struct {
+	// Remote is the URL of the git repository, without the "git+" prefix.
+	Remote string
+	// Path is the path of the chart in the repository, empty for the root of
+	// the repository.
+	Path string
+	// Ref is the branch, tag or commit of the chart, empty for the default
+	// branch of the repository.
+	Ref string
+}
+
+// ParseGitSource parses the repository of a dependency fetched from a git
+// repository.
+//
+// The path of the chart in the repository follows a double slash after the
+// repository URL, and the ref is set by the "ref" query parameter:
+//
+//	git+https://example.com/org/charts.git//charts/lib?ref=v1.2.0
+func ParseGitSource(repository string) (*GitSource, error) {
+	if !IsGitRepository(repository) {
+		return nil, errors.Errorf("%s is not a git repository", repository)
+	}
+	u, err := url.Parse(strings.TrimPrefix(repository, GitScheme))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid git repository %s", repository)
+	}
+	if u.Scheme == "" || (u.Host == "" && u.Scheme != "file") || u.Path == "" {
+		return nil, errors.Errorf("invalid git repository %s", repository)
+	}
+
+	src := &GitSource{Ref: u.Query().Get("ref")}
+	if strings.HasPrefix(src.Ref, "-") {
+		return nil, errors.Errorf("invalid ref %s of git repository %s", src.Ref, repository)
+	}
+	if i := strings.Index(u.Path, "//"); i >= 0 {
+		src.Path = path.Clean(strings.Trim(u.Path[i+2:], "/"))
+		u.Path = u.Path[:i]
+		if src.Path == "." {
+			src.Path = ""
+		}
+		if src.Path == ".." || strings.HasPrefix(src.Path, "../") {
+			return nil, errors.Errorf("path of git repository %s is outside of the repository", repository)
+		}
+	}
+	u.RawQuery = ""
+	u.RawPath = ""
+	u.Fragment = ""
+	src.Remote = u.String()
+	return src, nil
+}
+
+// String returns the repository of a dependency fetched from src.
+func (src *GitSource) String() string {
+	s := GitScheme + src.Remote
+	if src.Path != "" {
+		s += "//" + src.Path
+	}
+	if src.Ref != "" {
+		s += "?ref=" + url.QueryEscape(src.Ref)
+	}
+	return s
+}
+
+// Checkout checks out the ref of src in a clone of its repository cached in the
+// git directory of cachepath.
+//
+// It returns the directory of the chart and the commit checked out. The
+// repository is fetched unless the ref is a commit already in the clone.
+func (src *GitSource) Checkout(cachepath string) (string, string, error) {
+	sum := sha256.Sum256([]byte(src.Remote))
+	local := filepath.Join(cachepath, "git", hex.EncodeToString(sum[:]))
+	repo, err := vcs.NewGitRepo(src.Remote, local)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "unable to use git repository %s", src.Remote)
+	}
+
+	if _, err := os.Stat(local); os.IsNotExist(err) {
+		if err := repo.Get(); err != nil {
+			return "", "", errors.Wrapf(err, "unable to clone git repository %s", src.Remote)
+		}
+	} else if err != nil {
+		return "", "", err
+	} else if !src.isCommitIn(repo) {
+		if out, err := repo.RunFromDir("git", "fetch", "--force", "--tags", "origin"); err != nil {
+			return "", "", errors.Wrapf(err, "unable to fetch git repository %s: %s", src.Remote, out)
+		}
+	}
+
+	commit, err := src.commit(repo)
+	if err != nil {
+		return "", "", err
+	}
+	if out, err := repo.RunFromDir("git", "checkout", "--force", "--detach", commit); err != nil {
+		return "", "", errors.Wrapf(err, "unable to check out %s of git repository %s: %s", commit, src.Remote, out)
+	}
+	return filepath.Join(local, filepath.FromSlash(src.Path)), commit, nil
+}
+
+// isCommitIn returns whether the ref of src is a commit already in repo, which
+// then needs not be fetched.
+func (src *GitSource) isCommitIn(repo *vcs.GitRepo) bool {
+	if !commitPattern.MatchString(src.Ref) {
+		return false
+	}
+	_, err := src.commit(repo)
+	return err == nil
+}
+
+// commit resolves the ref of src to a commit of repo, preferring the
+// branches of the remote to the local ones.
+func (src *GitSource) commit(repo *vcs.GitRepo) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if src.Ref != "" {
+		candidates = []string{"origin/" + src.Ref, src.Ref}
+	}
+	for _, ref := range candidates {
+		out, err := repo.RunFromDir("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	if src.Ref == "" {
+		return "", errors.Errorf("unable to find the default branch of git repository %s", src.Remote)
+	}
+	return "", errors.Errorf("unable to find ref %s in git repository %s", src.Ref, src.Remote)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// gitRepo creates a git repository with a library chart in charts/lib, at
+// version 1.0.0 tagged v1.0.0 then at version 1.1.0 on the main branch. It
+// returns the directory of the repository.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "--quiet", "--initial-branch=main")
+	gitCommitChart(t, dir, "1.0.0")
+	gitRun(t, dir, "tag", "v1.0.0")
+	gitCommitChart(t, dir, "1.1.0")
+	return dir
+}
+
+// gitCommitChart commits the chart in charts/lib of the repository dir at
+// version, and returns the commit.
+func gitCommitChart(t *testing.T, dir, version string) string {
+	t.Helper()
+	chartDir := filepath.Join(dir, "charts", "lib")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := fmt.Sprintf("apiVersion: v2\nname: lib\ntype: library\nversion: %s\n", version)
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "--all")
+	gitRun(t, dir, "commit", "--quiet", "--message", "lib "+version)
+	return gitRun(t, dir, "rev-parse", "HEAD")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=Helm", "-c", "user.email=helm@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		repository string
+		expect     GitSource
+		str        string
+		err        bool
+	}{
+		{
+			repository: "git+https://example.com/org/charts.git//charts/lib?ref=v1.2.0",
+			expect:     GitSource{Remote: "https://example.com/org/charts.git", Path: "charts/lib", Ref: "v1.2.0"},
+		},
+		{
+			repository: "git+https://example.com/org/lib.git",
+			expect:     GitSource{Remote: "https://example.com/org/lib.git"},
+		},
+		{
+			repository: "git+ssh://git@example.com/org/charts.git//lib/?ref=feature/x",
+			expect:     GitSource{Remote: "ssh://git@example.com/org/charts.git", Path: "lib", Ref: "feature/x"},
+			str:        "git+ssh://git@example.com/org/charts.git//lib?ref=feature%2Fx",
+		},
+		{
+			repository: "git+file:///srv/charts//lib?ref=main",
+			expect:     GitSource{Remote: "file:///srv/charts", Path: "lib", Ref: "main"},
+		},
+		{repository: "https://example.com/charts", err: true},
+		{repository: "git+example.com/charts", err: true},
+		{repository: "git+https://example.com/charts.git//../lib", err: true},
+		{repository: "git+https://example.com/charts.git?ref=--upload-pack=x", err: true},
+	}
+	for _, tt := range tests {
+		src, err := ParseGitSource(tt.repository)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected an error parsing %s", tt.repository)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsing %s: %s", tt.repository, err)
+			continue
+		}
+		if *src != tt.expect {
+			t.Errorf("parsing %s: expected %+v, got %+v", tt.repository, tt.expect, *src)
+		}
+		str := tt.str
+		if str == "" {
+			str = tt.repository
+		}
+		if src.String() != str {
+			t.Errorf("expected %s, got %s", str, src.String())
+		}
+	}
+}
+
+func TestGitSourceCheckout(t *testing.T) {
+	remote := gitRepo(t)
+	tagged := gitRun(t, remote, "rev-parse", "v1.0.0")
+	head := gitRun(t, remote, "rev-parse", "HEAD")
+	cachepath := t.TempDir()
+
+	checkout := func(ref, expectCommit, expectVersion string) {
+		t.Helper()
+		src := &GitSource{Remote: "file://" + remote, Path: "charts/lib", Ref: ref}
+		chartpath, commit, err := src.Checkout(cachepath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commit != expectCommit {
+			t.Errorf("checking out %q: expected commit %s, got %s", ref, expectCommit, commit)
+		}
+		ch, err := loader.LoadDir(chartpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ch.Metadata.Version != expectVersion {
+			t.Errorf("checking out %q: expected version %s, got %s", ref, expectVersion, ch.Metadata.Version)
+		}
+	}
+
+	checkout("", head, "1.1.0")
+	checkout("v1.0.0", tagged, "1.0.0")
+	checkout("main", head, "1.1.0")
+	checkout(tagged, tagged, "1.0.0")
+
+	// Branches are fetched again from the remote.
+	next := gitCommitChart(t, remote, "1.2.0")
+	checkout("main", next, "1.2.0")
+
+	src := &GitSource{Remote: "file://" + remote, Ref: "missing"}
+	if _, _, err := src.Checkout(cachepath); err == nil || !strings.Contains(err.Error(), "unable to find ref missing") {
+		t.Errorf("expected a missing ref error, got %v", err)
+	}
+}
+
+func TestResolveGit(t *testing.T) {
+	remote := gitRepo(t)
+	tagged := gitRun(t, remote, "rev-parse", "v1.0.0")
+	r := New("testdata/chartpath", t.TempDir(), nil)
+
+	repository := fmt.Sprintf("git+file://%s//charts/lib?ref=v1.0.0", remote)
+	lock, err := r.Resolve([]*chart.Dependency{{Name: "lib", Version: "^1.0.0", Repository: repository}}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := fmt.Sprintf("git+file://%s//charts/lib?ref=%s", remote, tagged)
+	if dep := lock.Dependencies[0]; dep.Repository != expect || dep.Version != "1.0.0" {
+		t.Errorf("expected lib 1.0.0 from %s, got %s %s", expect, dep.Version, dep.Repository)
+	}
+
+	_, err = r.Resolve([]*chart.Dependency{{Name: "lib", Version: "^2.0.0", Repository: repository}}, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "can't get a valid version for 1 subchart(s)") {
+		t.Errorf("expected a missing version error, got %v", err)
+	}
+}
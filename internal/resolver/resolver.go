@@ -40,6 +40,10 @@ type Resolver struct {
 	chartpath      string
 	cachepath      string
 	registryClient *registry.Client
+
+	// Offline prevents listing the tags of registries, so that the
+	// dependencies on charts of registries must have exact versions.
+	Offline bool
 }
 
 // New creates a new resolver for a given chart, helm home and registry client.
@@ -76,6 +80,34 @@ func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string
 			}
 			continue
 		}
+		if IsGitRepository(d.Repository) {
+			src, err := ParseGitSource(d.Repository)
+			if err != nil {
+				return nil, err
+			}
+			chartpath, commit, err := src.Checkout(r.cachepath)
+			if err != nil {
+				return nil, err
+			}
+			ch, err := loader.LoadDir(chartpath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to load chart %s from %s", d.Name, d.Repository)
+			}
+			v, err := semver.NewVersion(ch.Metadata.Version)
+			if err != nil || !constraint.Check(v) {
+				missing = append(missing, fmt.Sprintf("%q (repository %q, version %q)", d.Name, d.Repository, d.Version))
+				continue
+			}
+
+			// The lock file pins the commit the ref was resolved to.
+			src.Ref = commit
+			locked[i] = &chart.Dependency{
+				Name:       d.Name,
+				Repository: src.String(),
+				Version:    ch.Metadata.Version,
+			}
+			continue
+		}
 		if strings.HasPrefix(d.Repository, "file://") {
 			chartpath, err := GetLocalPath(d.Repository, r.chartpath)
 			if err != nil {
@@ -146,6 +178,8 @@ func (r *Resolver) Resolve(reqs []*chart.Dependency, repoNames map[string]string
 					},
 				}}
 
+			} else if r.Offline {
+				return nil, errors.Errorf("unable to look up the versions of %s in %s offline, an exact version is required", d.Name, d.Repository)
 			} else {
 				// Retrieve list of tags for repository
 				ref := fmt.Sprintf("%s/%s", strings.TrimPrefix(d.Repository, fmt.Sprintf("%s://", registry.OCIScheme)), d.Name)
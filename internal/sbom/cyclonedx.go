@@ -0,0 +1,130 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// cycloneDXHashAlgorithms maps digest algorithms to CycloneDX hash algorithms.
+var cycloneDXHashAlgorithms = map[string]string{
+	"sha1":   "SHA-1",
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+}
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []*cdxComponent `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     cdxTools      `json:"tools"`
+	Component *cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []*cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	BOMRef             string        `json:"bom-ref,omitempty"`
+	Type               string        `json:"type"`
+	Name               string        `json:"name"`
+	Version            string        `json:"version,omitempty"`
+	Description        string        `json:"description,omitempty"`
+	Hashes             []cdxHash     `json:"hashes,omitempty"`
+	ExternalReferences []cdxExtRef   `json:"externalReferences,omitempty"`
+	Properties         []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxExtRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func encodeCycloneDX(d *Document) ([]byte, error) {
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: d.Created.UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []*cdxComponent{
+				{Type: KindApplication, Name: d.Tool, Version: d.ToolVersion},
+			}},
+			Component: cycloneDXComponent(d.Root),
+		},
+		Components:   []*cdxComponent{},
+		Dependencies: []cdxDependency{},
+	}
+	for _, c := range append([]*Component{d.Root}, d.Components...) {
+		if c != d.Root {
+			bom.Components = append(bom.Components, cycloneDXComponent(c))
+		}
+		dependsOn := c.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: c.ID, DependsOn: dependsOn})
+	}
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+func cycloneDXComponent(c *Component) *cdxComponent {
+	out := &cdxComponent{
+		BOMRef:      c.ID,
+		Type:        c.Kind,
+		Name:        c.Name,
+		Version:     c.Version,
+		Description: c.Description,
+	}
+	if alg, value, ok := splitDigest(c.Digest); ok {
+		if cdxAlg, ok := cycloneDXHashAlgorithms[alg]; ok {
+			out.Hashes = []cdxHash{{Alg: cdxAlg, Content: value}}
+		}
+	}
+	if c.Repository != "" {
+		out.ExternalReferences = []cdxExtRef{{Type: "distribution", URL: c.Repository}}
+	}
+	for _, p := range c.Properties {
+		out.Properties = append(out.Properties, cdxProperty{Name: p.Name, Value: p.Value})
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package sbom encodes software bills of materials of charts and releases in the
+CycloneDX and SPDX formats.
+*/
+package sbom
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Formats of software bills of materials.
+const (
+	// CycloneDX is the CycloneDX 1.5 JSON format.
+	CycloneDX = "cyclonedx"
+	// SPDX is the SPDX 2.3 JSON format.
+	SPDX = "spdx"
+)
+
+// Kinds of components.
+const (
+	KindApplication = "application"
+	KindLibrary     = "library"
+	KindContainer   = "container"
+)
+
+// Document is a software bill of materials, in no particular format.
+type Document struct {
+	// Name is the name of the document.
+	Name string
+	// Created is the creation time of the document.
+	Created time.Time
+	// Tool is the name and the version of the tool creating the document.
+	Tool, ToolVersion string
+	// Root is the component the document describes.
+	Root *Component
+	// Components are the components of Root, and of one another.
+	Components []*Component
+}
+
+// Component is a chart or a container image.
+type Component struct {
+	// ID identifies the component in the document.
+	ID string
+	// Kind is the kind of the component.
+	Kind string
+	// Name, Version and Description describe the component.
+	Name, Version, Description string
+	// Repository is the location of the component, if known.
+	Repository string
+	// Digest is the digest of the component, as "algorithm:hex", if known.
+	Digest string
+	// Properties are additional facts about the component, in order.
+	Properties []Property
+	// DependsOn are the IDs of the components this one depends on.
+	DependsOn []string
+}
+
+// Property is a named fact about a component.
+type Property struct {
+	Name, Value string
+}
+
+// Encode encodes d in the given format.
+func Encode(d *Document, format string) ([]byte, error) {
+	switch format {
+	case CycloneDX:
+		return encodeCycloneDX(d)
+	case SPDX:
+		return encodeSPDX(d)
+	}
+	return nil, errors.Errorf("unknown SBOM format %q, expected %s or %s", format, CycloneDX, SPDX)
+}
+
+// splitDigest splits a digest into its algorithm and its hex value.
+func splitDigest(digest string) (string, string, bool) {
+	alg, value, ok := strings.Cut(digest, ":")
+	if !ok || value == "" {
+		return "", "", false
+	}
+	return strings.ToLower(alg), value, true
+}
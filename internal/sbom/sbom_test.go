@@ -0,0 +1,164 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDocument() *Document {
+	root := &Component{
+		ID:          "chart:app@1.0.0",
+		Kind:        KindApplication,
+		Name:        "app",
+		Version:     "1.0.0",
+		Description: "An application",
+		Digest:      "sha256:abcd",
+		Properties:  []Property{{Name: "helm:appVersion", Value: "2.0"}},
+		DependsOn:   []string{"chart:app/charts/lib@0.1.0", "image:nginx:1.25"},
+	}
+	return &Document{
+		Name:        "app-1.0.0",
+		Created:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tool:        "helm",
+		ToolVersion: "v3.99",
+		Root:        root,
+		Components: []*Component{
+			{ID: "chart:app/charts/lib@0.1.0", Kind: KindLibrary, Name: "lib", Version: "0.1.0", Repository: "https://charts.example.com", Digest: "md5:ignored"},
+			{ID: "image:nginx:1.25", Kind: KindContainer, Name: "nginx", Version: "1.25", Properties: []Property{{Name: "helm:resource", Value: "Deployment/web"}}},
+		},
+	}
+}
+
+func TestEncodeCycloneDX(t *testing.T) {
+	data, err := Encode(testDocument(), CycloneDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bom cdxBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatal(err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("unexpected format %s %s", bom.BOMFormat, bom.SpecVersion)
+	}
+	if bom.Metadata.Timestamp != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected timestamp %s", bom.Metadata.Timestamp)
+	}
+	if c := bom.Metadata.Tools.Components[0]; c.Name != "helm" || c.Version != "v3.99" {
+		t.Errorf("unexpected tool %+v", c)
+	}
+	root := bom.Metadata.Component
+	if root.BOMRef != "chart:app@1.0.0" || !reflect.DeepEqual(root.Hashes, []cdxHash{{Alg: "SHA-256", Content: "abcd"}}) {
+		t.Errorf("unexpected root component %+v", root)
+	}
+	if len(bom.Components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(bom.Components))
+	}
+	lib := bom.Components[0]
+	if lib.Type != KindLibrary || lib.Hashes != nil || lib.ExternalReferences[0].URL != "https://charts.example.com" {
+		t.Errorf("unexpected library component %+v", lib)
+	}
+	expect := []cdxDependency{
+		{Ref: "chart:app@1.0.0", DependsOn: []string{"chart:app/charts/lib@0.1.0", "image:nginx:1.25"}},
+		{Ref: "chart:app/charts/lib@0.1.0", DependsOn: []string{}},
+		{Ref: "image:nginx:1.25", DependsOn: []string{}},
+	}
+	if !reflect.DeepEqual(bom.Dependencies, expect) {
+		t.Errorf("expected dependencies %v, got %v", expect, bom.Dependencies)
+	}
+}
+
+func TestEncodeSPDX(t *testing.T) {
+	data, err := Encode(testDocument(), SPDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	if doc.CreationInfo.Creators[0] != "Tool: helm-v3.99" {
+		t.Errorf("unexpected creators %v", doc.CreationInfo.Creators)
+	}
+	var ids []string
+	for _, p := range doc.Packages {
+		ids = append(ids, p.SPDXID)
+	}
+	if expect := []string{"SPDXRef-chart-app-1.0.0", "SPDXRef-chart-app-charts-lib-0.1.0", "SPDXRef-image-nginx-1.25"}; !reflect.DeepEqual(ids, expect) {
+		t.Errorf("expected package ids %v, got %v", expect, ids)
+	}
+	if p := doc.Packages[0]; p.PrimaryPackagePurpose != "APPLICATION" || p.Checksums[0].Algorithm != "SHA256" || p.DownloadLocation != "NOASSERTION" {
+		t.Errorf("unexpected root package %+v", p)
+	}
+	if p := doc.Packages[1]; p.DownloadLocation != "https://charts.example.com" || p.Checksums != nil {
+		t.Errorf("unexpected library package %+v", p)
+	}
+	if a := doc.Packages[2].Annotations; len(a) != 1 || a[0].Comment != "helm:resource=Deployment/web" {
+		t.Errorf("unexpected image annotations %+v", a)
+	}
+	expect := []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-chart-app-1.0.0"},
+		{SPDXElementID: "SPDXRef-chart-app-1.0.0", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-chart-app-charts-lib-0.1.0"},
+		{SPDXElementID: "SPDXRef-chart-app-1.0.0", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-image-nginx-1.25"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, expect) {
+		t.Errorf("expected relationships %v, got %v", expect, doc.Relationships)
+	}
+
+	// The namespace depends on the contents only.
+	again, err := Encode(testDocument(), SPDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Error("expected encoding the same document twice to yield the same result")
+	}
+}
+
+func TestEncodeSPDXUniqueIDs(t *testing.T) {
+	d := testDocument()
+	d.Components = []*Component{
+		{ID: "image:a/b", Kind: KindContainer, Name: "a/b"},
+		{ID: "image:a:b", Kind: KindContainer, Name: "a"},
+	}
+	data, err := Encode(d, SPDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Packages[1].SPDXID != "SPDXRef-image-a-b" || doc.Packages[2].SPDXID != "SPDXRef-image-a-b-2" {
+		t.Errorf("expected unique ids, got %s and %s", doc.Packages[1].SPDXID, doc.Packages[2].SPDXID)
+	}
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	if _, err := Encode(testDocument(), "swid"); err == nil {
+		t.Error("expected unknown format to fail")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+	// spdxNamespacePrefix prefixes the namespaces of documents, which must be
+	// unique URIs but need not be resolvable.
+	spdxNamespacePrefix = "https://helm.sh/spdx/"
+)
+
+// spdxHashAlgorithms maps digest algorithms to SPDX checksum algorithms.
+var spdxHashAlgorithms = map[string]string{
+	"sha1":   "SHA1",
+	"sha256": "SHA256",
+	"sha384": "SHA384",
+	"sha512": "SHA512",
+}
+
+var spdxPurposes = map[string]string{
+	KindApplication: "APPLICATION",
+	KindLibrary:     "LIBRARY",
+	KindContainer:   "CONTAINER",
+}
+
+// spdxIDInvalidChars matches the characters not allowed in SPDX identifiers.
+var spdxIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string           `json:"SPDXID"`
+	Name                  string           `json:"name"`
+	VersionInfo           string           `json:"versionInfo,omitempty"`
+	Description           string           `json:"description,omitempty"`
+	DownloadLocation      string           `json:"downloadLocation"`
+	FilesAnalyzed         bool             `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum   `json:"checksums,omitempty"`
+	PrimaryPackagePurpose string           `json:"primaryPackagePurpose,omitempty"`
+	Annotations           []spdxAnnotation `json:"annotations,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxAnnotation struct {
+	AnnotationDate string `json:"annotationDate"`
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	Comment        string `json:"comment"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func encodeSPDX(d *Document) ([]byte, error) {
+	created := d.Created.UTC().Format(time.RFC3339)
+	creator := fmt.Sprintf("Tool: %s-%s", d.Tool, d.ToolVersion)
+
+	// Identifiers of components are made of the characters SPDX allows, with a
+	// counter making them unique again if needed.
+	ids := map[string]string{}
+	used := map[string]bool{}
+	components := append([]*Component{d.Root}, d.Components...)
+	for _, c := range components {
+		base := "SPDXRef-" + spdxIDPart(c.ID)
+		id := base
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		ids[c.ID] = id
+		used[id] = true
+	}
+
+	doc := spdxDocument{
+		SPDXVersion:  "SPDX-2.3",
+		DataLicense:  "CC0-1.0",
+		SPDXID:       spdxDocumentID,
+		Name:         d.Name,
+		CreationInfo: spdxCreationInfo{Created: created, Creators: []string{creator}},
+		Packages:     []spdxPackage{},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: ids[d.Root.ID]},
+		},
+	}
+
+	h := sha256.New()
+	for _, c := range components {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", c.ID, c.Version, c.Digest)
+
+		pkg := spdxPackage{
+			SPDXID:                ids[c.ID],
+			Name:                  c.Name,
+			VersionInfo:           c.Version,
+			Description:           c.Description,
+			DownloadLocation:      spdxNoAssertion,
+			PrimaryPackagePurpose: spdxPurposes[c.Kind],
+		}
+		if c.Repository != "" {
+			pkg.DownloadLocation = c.Repository
+		}
+		if alg, value, ok := splitDigest(c.Digest); ok {
+			if spdxAlg, ok := spdxHashAlgorithms[alg]; ok {
+				pkg.Checksums = []spdxChecksum{{Algorithm: spdxAlg, ChecksumValue: value}}
+			}
+		}
+		for _, p := range c.Properties {
+			pkg.Annotations = append(pkg.Annotations, spdxAnnotation{
+				AnnotationDate: created,
+				AnnotationType: "OTHER",
+				Annotator:      creator,
+				Comment:        p.Name + "=" + p.Value,
+			})
+		}
+		doc.Packages = append(doc.Packages, pkg)
+
+		for _, dep := range c.DependsOn {
+			if id, ok := ids[dep]; ok {
+				doc.Relationships = append(doc.Relationships, spdxRelationship{
+					SPDXElementID:      ids[c.ID],
+					RelationshipType:   "DEPENDS_ON",
+					RelatedSPDXElement: id,
+				})
+			}
+		}
+	}
+	fmt.Fprint(h, created)
+	doc.DocumentNamespace = spdxNamespacePrefix + spdxIDPart(d.Name) + "-" + hex.EncodeToString(h.Sum(nil))[:16]
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// spdxIDPart returns s with the characters not allowed in SPDX identifiers
+// replaced by dashes.
+func spdxIDPart(s string) string {
+	return strings.Trim(spdxIDInvalidChars.ReplaceAllString(s, "-"), "-")
+}
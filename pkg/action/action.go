@@ -33,6 +33,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/engine"
 	"helm.sh/helm/v3/pkg/kube"
@@ -94,6 +95,10 @@ type Configuration struct {
 	// Capabilities describes the capabilities of the Kubernetes cluster.
 	Capabilities *chartutil.Capabilities
 
+	// SensitiveValuesKeys stores the keys hashing the sensitive values of
+	// releases, apart from the release records.
+	SensitiveValuesKeys SensitiveValuesKeys
+
 	Log func(string, ...interface{})
 }
 
@@ -117,6 +122,9 @@ func (cfg *Configuration) renderResources(ch *chart.Chart, values chartutil.Valu
 			return hs, b, "", errors.Errorf("chart requires kubeVersion: %s which is incompatible with Kubernetes %s", ch.Metadata.KubeVersion, caps.KubeVersion.String())
 		}
 	}
+	if err := loader.CheckHelmVersion(ch); err != nil {
+		return hs, b, "", err
+	}
 
 	var files map[string]string
 	var err2 error
@@ -362,6 +370,29 @@ func GetVersionSet(client discovery.ServerResourcesInterface) (chartutil.Version
 	return chartutil.VersionSet(versions), nil
 }
 
+// previousRelease builds the .Release.Previous template object from a stored release.
+func previousRelease(rel *release.Release) (*chartutil.PreviousRelease, error) {
+	if rel == nil {
+		return nil, nil
+	}
+	prev := &chartutil.PreviousRelease{
+		Values:   chartutil.Values{},
+		Revision: rel.Version,
+	}
+	if rel.Chart == nil {
+		return prev, nil
+	}
+	if rel.Chart.Metadata != nil {
+		prev.ChartVersion = rel.Chart.Metadata.Version
+	}
+	vals, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to compute values of release %q revision %d", rel.Name, rel.Version)
+	}
+	prev.Values = vals
+	return prev, nil
+}
+
 // recordRelease with an update operation in case reuse has been set.
 func (cfg *Configuration) recordRelease(r *release.Release) {
 	if err := cfg.Releases.Update(r); err != nil {
@@ -380,6 +411,7 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	}
 
 	var store *storage.Storage
+	var keys SensitiveValuesKeys = NewSecretSensitiveValuesKeys(newSecretClient(lazyClient))
 	switch helmDriver {
 	case "secret", "secrets", "":
 		d := driver.NewSecrets(newSecretClient(lazyClient))
@@ -404,6 +436,11 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 		}
 		d.SetNamespace(namespace)
 		store = storage.Init(d)
+		if mem, ok := cfg.SensitiveValuesKeys.(*MemorySensitiveValuesKeys); ok {
+			keys = mem
+		} else {
+			keys = NewMemorySensitiveValuesKeys()
+		}
 	case "sql":
 		d, err := driver.NewSQL(
 			os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"),
@@ -421,6 +458,7 @@ func (cfg *Configuration) Init(getter genericclioptions.RESTClientGetter, namesp
 	cfg.RESTClientGetter = getter
 	cfg.KubeClient = kc
 	cfg.Releases = store
+	cfg.SensitiveValuesKeys = keys
 	cfg.Log = log
 
 	return nil
@@ -45,10 +45,11 @@ func actionConfigFixture(t *testing.T) *Configuration {
 	}
 
 	return &Configuration{
-		Releases:       storage.Init(driver.NewMemory()),
-		KubeClient:     &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard}},
-		Capabilities:   chartutil.DefaultCapabilities,
-		RegistryClient: registryClient,
+		Releases:            storage.Init(driver.NewMemory()),
+		KubeClient:          &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard}},
+		Capabilities:        chartutil.DefaultCapabilities,
+		RegistryClient:      registryClient,
+		SensitiveValuesKeys: NewMemorySensitiveValuesKeys(),
 		Log: func(format string, v ...interface{}) {
 			t.Helper()
 			if *verbose {
@@ -227,6 +228,51 @@ func withMultipleManifestTemplate() chartOption {
 	}
 }
 
+func withPreviousReleaseTemplate() chartOption {
+	return func(opts *chartOptions) {
+		opts.Templates = append(opts.Templates, &chart.File{
+			Name: "templates/previous",
+			Data: []byte(`previous: "{{ .Release.Previous.Values.name }}-{{ .Release.Previous.ChartVersion }}-{{ .Release.Previous.Revision }}"`),
+		})
+	}
+}
+
+func withSensitiveValues(paths string) chartOption {
+	return func(opts *chartOptions) {
+		if opts.Metadata.Annotations == nil {
+			opts.Metadata.Annotations = map[string]string{}
+		}
+		opts.Metadata.Annotations[chartutil.SensitiveValuesAnnotation] = paths
+	}
+}
+
+func withMergeKeys(keys string) chartOption {
+	return func(opts *chartOptions) {
+		if opts.Metadata.Annotations == nil {
+			opts.Metadata.Annotations = map[string]string{}
+		}
+		opts.Metadata.Annotations[chartutil.MergeKeysAnnotation] = keys
+	}
+}
+
+func withVersion(version string) chartOption {
+	return func(opts *chartOptions) {
+		opts.Metadata.Version = version
+	}
+}
+
+func withFile(name, data string) chartOption {
+	return func(opts *chartOptions) {
+		opts.Files = append(opts.Files, &chart.File{Name: name, Data: []byte(data)})
+	}
+}
+
+func withHelmVersion(constraint string) chartOption {
+	return func(opts *chartOptions) {
+		opts.Metadata.HelmVersion = constraint
+	}
+}
+
 func withKube(version string) chartOption {
 	return func(opts *chartOptions) {
 		opts.Metadata.KubeVersion = version
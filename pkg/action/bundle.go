@@ -0,0 +1,357 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/fileutil"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const (
+	// BundleChartAnnotation is the annotation of the index of a bundle holding
+	// the reference of the chart of the bundle, as in "mychart:1.2.3".
+	BundleChartAnnotation = "sh.helm.bundle.chart"
+
+	// BundleImagesArtifactType is the artifact type of the manifest listing the
+	// container images of the chart of a bundle.
+	BundleImagesArtifactType = "application/vnd.cncf.helm.bundle.images.v1+json"
+)
+
+// BundleCreate is the action for creating bundles.
+//
+// A bundle is a tar archive of an OCI image layout holding a chart, the charts
+// of its dependencies, their provenance files and the list of the container
+// images of the chart, for installing charts where repositories cannot be
+// reached.
+//
+// It provides the implementation of 'helm bundle create'.
+type BundleCreate struct {
+	cfg *Configuration
+
+	// Destination is the path of the bundle. It defaults to
+	// "<name>-<version>.bundle.tar" in the current directory.
+	Destination string
+	// Created is the creation time recorded in the bundle. The current time is
+	// used when zero.
+	Created time.Time
+	// KubeVersion and APIVersions are the capabilities the chart is rendered
+	// with to find its images.
+	KubeVersion *chartutil.KubeVersion
+	APIVersions chartutil.VersionSet
+}
+
+// NewBundleCreate creates a new BundleCreate object with the given configuration.
+func NewBundleCreate(cfg *Configuration) *BundleCreate {
+	return &BundleCreate{cfg: cfg}
+}
+
+// Run creates the bundle of the chart at chartPath, and returns its path.
+//
+// chartPath is a chart archive, whose provenance file is bundled if found next
+// to it, or a chart directory whose dependencies are in its charts directory.
+// The images of the chart are the ones of its manifests rendered with vals.
+func (b *BundleCreate) Run(chartPath string, vals map[string]interface{}) (string, error) {
+	data, prov, err := b.chartArchive(chartPath)
+	if err != nil {
+		return "", err
+	}
+	ch, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if ch.Metadata.Dependencies != nil {
+		if err := CheckDependencies(ch, ch.Metadata.Dependencies); err != nil {
+			return "", err
+		}
+	}
+
+	created := b.Created
+	if created.IsZero() {
+		created = b.cfg.Now().Time
+	}
+	creationTime := created.UTC().Format(time.RFC3339)
+
+	layout := registry.NewLayout()
+	root, err := layout.PushChart(data, prov, creationTime)
+	if err != nil {
+		return "", err
+	}
+	if err := pushNestedCharts(layout, data, creationTime); err != nil {
+		return "", errors.Wrapf(err, "unable to bundle the dependencies of %s", ch.Name())
+	}
+	// Packaging unpacks the archives of dependencies, so the ones of chart
+	// directories are read from their charts directory.
+	if fi, err := os.Stat(chartPath); err == nil && fi.IsDir() {
+		if err := pushChartsDir(layout, filepath.Join(chartPath, "charts"), creationTime); err != nil {
+			return "", errors.Wrapf(err, "unable to bundle the dependencies of %s", ch.Name())
+		}
+	}
+
+	images, err := b.images(ch, vals)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to list the images of the chart")
+	}
+	imageList, err := json.Marshal(images)
+	if err != nil {
+		return "", err
+	}
+	_, err = layout.AddManifest(ocispec.Manifest{
+		ArtifactType: BundleImagesArtifactType,
+		Config:       layout.AddBlob(ocispec.MediaTypeEmptyJSON, []byte("{}")),
+		Layers:       []ocispec.Descriptor{layout.AddBlob("application/json", imageList)},
+		Subject:      &root,
+		Annotations:  map[string]string{ocispec.AnnotationCreated: creationTime},
+	}, "")
+	if err != nil {
+		return "", err
+	}
+	layout.Index.Annotations = map[string]string{
+		BundleChartAnnotation: registry.LayoutChartRef(ch.Name(), ch.Metadata.Version),
+	}
+
+	dest := b.Destination
+	if dest == "" {
+		dest = fmt.Sprintf("%s-%s.bundle.tar", ch.Name(), ch.Metadata.Version)
+	}
+	var buf bytes.Buffer
+	if err := layout.WriteArchive(&buf); err != nil {
+		return "", err
+	}
+	if err := fileutil.AtomicWriteFile(dest, &buf, 0644); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// chartArchive returns the chart archive at chartPath, along with its
+// provenance file if any, packaging chart directories first.
+func (b *BundleCreate) chartArchive(chartPath string) (data, prov []byte, err error) {
+	fi, err := os.Stat(chartPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.IsDir() {
+		tmp, err := os.MkdirTemp("", "helm-bundle-")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer os.RemoveAll(tmp)
+
+		p := NewPackage()
+		p.Destination = tmp
+		p.Reproducible = true
+		if chartPath, err = p.Run(chartPath, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if data, err = os.ReadFile(chartPath); err != nil {
+		return nil, nil, err
+	}
+	prov, err = os.ReadFile(chartPath + ".prov")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	return data, prov, nil
+}
+
+// images returns the container images of ch rendered with vals.
+func (b *BundleCreate) images(ch *chart.Chart, vals map[string]interface{}) ([]releaseutil.Image, error) {
+	install := NewInstall(b.cfg)
+	install.DryRunOption = "true"
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = "release-name"
+	install.KubeVersion = b.KubeVersion
+	install.APIVersions = b.APIVersions
+	rel, err := install.Run(ch, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []string{rel.Manifest}
+	for _, h := range rel.Hooks {
+		manifests = append(manifests, h.Manifest)
+	}
+	images, err := releaseutil.ExtractImages(strings.Join(manifests, "\n---\n"))
+	if images == nil {
+		images = []releaseutil.Image{}
+	}
+	return images, err
+}
+
+// pushNestedCharts adds the chart archives held in the charts directories of
+// the chart archive data to layout, recursively, along with the provenance
+// files found next to them.
+func pushNestedCharts(layout *registry.Layout, data []byte, creationTime string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	archives := map[string][]byte{}
+	provs := map[string][]byte{}
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hd.Typeflag != tar.TypeReg || path.Base(path.Dir(hd.Name)) != "charts" {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(hd.Name, ".tgz"):
+			if archives[hd.Name], err = io.ReadAll(tr); err != nil {
+				return err
+			}
+			names = append(names, hd.Name)
+		case strings.HasSuffix(hd.Name, ".tgz.prov"):
+			if provs[strings.TrimSuffix(hd.Name, ".prov")], err = io.ReadAll(tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, err := layout.PushChart(archives[name], provs[name], creationTime); err != nil {
+			return errors.Wrapf(err, "unable to bundle %s", name)
+		}
+		if err := pushNestedCharts(layout, archives[name], creationTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushChartsDir adds the chart archives of the charts directory dir to layout,
+// recursively, along with the provenance files found next to them.
+func pushChartsDir(layout *registry.Layout, dir, creationTime string) error {
+	archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		data, err := os.ReadFile(archive)
+		if err != nil {
+			return err
+		}
+		prov, err := os.ReadFile(archive + ".prov")
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if _, err := layout.PushChart(data, prov, creationTime); err != nil {
+			return errors.Wrapf(err, "unable to bundle %s", archive)
+		}
+		if err := pushNestedCharts(layout, data, creationTime); err != nil {
+			return errors.Wrapf(err, "unable to bundle the dependencies of %s", archive)
+		}
+	}
+	return nil
+}
+
+// IsBundle returns whether the file at path is a bundle.
+func IsBundle(path string) bool {
+	return registry.IsLayoutArchive(path)
+}
+
+// loadBundle returns the layout of the bundle at bundlePath, along with the
+// reference of its chart.
+func loadBundle(bundlePath string) (*registry.Layout, string, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	layout, err := registry.LoadLayoutArchive(f)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to load bundle %s", bundlePath)
+	}
+	ref := layout.Index.Annotations[BundleChartAnnotation]
+	if ref == "" {
+		return nil, "", errors.Errorf("%s is not a bundle: missing the %s annotation", bundlePath, BundleChartAnnotation)
+	}
+	return layout, ref, nil
+}
+
+// extractBundleChart writes the chart archive of the bundle at bundlePath to
+// dest, and returns its path. Its provenance file is written too, and
+// verified against keyring, as for charts downloaded with the given
+// verification strategy.
+func extractBundleChart(bundlePath, dest string, verify downloader.VerificationStrategy, keyring string) (string, *provenance.Verification, error) {
+	layout, ref, err := loadBundle(bundlePath)
+	if err != nil {
+		return "", nil, err
+	}
+	data, prov, err := layout.PullChart(ref)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unable to load the chart of bundle %s", bundlePath)
+	}
+
+	name := strings.Replace(ref, ":", "-", 1) + ".tgz"
+	destfile := filepath.Join(dest, name)
+	if err := fileutil.AtomicWriteFile(destfile, bytes.NewReader(data), 0644); err != nil {
+		return destfile, nil, err
+	}
+
+	ver := &provenance.Verification{}
+	if verify > downloader.VerifyNever {
+		if prov == nil {
+			if verify == downloader.VerifyAlways {
+				return destfile, ver, errors.Errorf("bundle %s has no provenance file for %s", bundlePath, ref)
+			}
+			return destfile, ver, nil
+		}
+		if err := fileutil.AtomicWriteFile(destfile+".prov", bytes.NewReader(prov), 0644); err != nil {
+			return destfile, nil, err
+		}
+		if verify != downloader.VerifyLater {
+			if ver, err = downloader.VerifyChart(destfile, keyring); err != nil {
+				return destfile, ver, err
+			}
+		}
+	}
+	return destfile, ver, nil
+}
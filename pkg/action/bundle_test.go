@@ -0,0 +1,131 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const signtestArchive = "../downloader/testdata/signtest-0.1.0.tgz"
+
+func copyFile(t *testing.T, src, dest string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dest, data, 0644))
+}
+
+func TestBundleCreate(t *testing.T) {
+	is := assert.New(t)
+
+	// A chart directory with a dependency and its provenance file in its
+	// charts directory.
+	dir := t.TempDir()
+	c := buildChart(withName("app"))
+	c.Templates = append(c.Templates, &chart.File{
+		Name: "templates/deployment.yaml",
+		Data: []byte(manifestWithImages),
+	})
+	require.NoError(t, chartutil.SaveDir(c, dir))
+	chartDir := filepath.Join(dir, "app")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "charts"), 0755))
+	copyFile(t, signtestArchive, filepath.Join(chartDir, "charts", "signtest-0.1.0.tgz"))
+	copyFile(t, signtestArchive+".prov", filepath.Join(chartDir, "charts", "signtest-0.1.0.tgz.prov"))
+
+	client := NewBundleCreate(actionConfigFixture(t))
+	client.Destination = filepath.Join(t.TempDir(), "app.bundle.tar")
+	dest, err := client.Run(chartDir, map[string]interface{}{})
+	require.NoError(t, err)
+	is.Equal(client.Destination, dest)
+	is.True(IsBundle(dest))
+
+	layout, ref, err := loadBundle(dest)
+	require.NoError(t, err)
+	is.Equal("app:0.1.0", ref)
+	is.Equal([]string{"app:0.1.0", "signtest:0.1.0"}, layout.Charts())
+
+	_, prov, err := layout.PullChart("app:0.1.0")
+	require.NoError(t, err)
+	is.Nil(prov)
+	_, prov, err = layout.PullChart("signtest:0.1.0")
+	require.NoError(t, err)
+	expect, err := os.ReadFile(signtestArchive + ".prov")
+	require.NoError(t, err)
+	is.Equal(expect, prov)
+
+	root, _, err := layout.Manifest(ref)
+	require.NoError(t, err)
+	referrers, err := layout.Referrers(root, BundleImagesArtifactType)
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	data, err := layout.Blob(referrers[0].Layers[0])
+	require.NoError(t, err)
+	var images []releaseutil.Image
+	require.NoError(t, json.Unmarshal(data, &images))
+	is.Equal([]releaseutil.Image{
+		{Image: "alpine:3.3", Resources: []string{"Pod/signtest"}},
+		{Image: "example.com/proxy@sha256:0123", Resources: []string{"Deployment/web"}},
+		{Image: "nginx:1.25", Resources: []string{"Deployment/web"}},
+	}, images)
+}
+
+func TestExtractBundleChart(t *testing.T) {
+	is := assert.New(t)
+
+	// The provenance file of the archive is bundled along with it.
+	client := NewBundleCreate(actionConfigFixture(t))
+	client.Destination = filepath.Join(t.TempDir(), "signtest.bundle.tar")
+	bundle, err := client.Run(signtestArchive, nil)
+	require.NoError(t, err)
+
+	dest := t.TempDir()
+	saved, _, err := extractBundleChart(bundle, dest, downloader.VerifyNever, "")
+	require.NoError(t, err)
+	is.Equal(filepath.Join(dest, "signtest-0.1.0.tgz"), saved)
+	_, err = os.Stat(saved + ".prov")
+	is.True(os.IsNotExist(err))
+
+	_, _, err = extractBundleChart(bundle, dest, downloader.VerifyLater, "")
+	require.NoError(t, err)
+	_, err = os.Stat(saved + ".prov")
+	is.NoError(err)
+
+	_, ver, err := extractBundleChart(bundle, dest, downloader.VerifyAlways, "../downloader/testdata/helm-test-key.pub")
+	require.NoError(t, err)
+	is.NotNil(ver.SignedBy)
+
+	// Bundles of charts without provenance files cannot be verified.
+	client.Destination = filepath.Join(t.TempDir(), "subchart.bundle.tar")
+	bundle, err = client.Run("../downloader/testdata/local-subchart-0.1.0.tgz", nil)
+	require.NoError(t, err)
+	_, _, err = extractBundleChart(bundle, dest, downloader.VerifyAlways, "../downloader/testdata/helm-test-key.pub")
+	is.ErrorContains(err, "has no provenance file for local-subchart:0.1.0")
+
+	_, _, err = extractBundleChart(signtestArchive, dest, downloader.VerifyNever, "")
+	is.Error(err)
+}
@@ -25,9 +25,12 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/internal/resolver"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/downloader"
 )
 
 // Dependency is the action for building a given chart's dependency tree.
@@ -38,12 +41,16 @@ type Dependency struct {
 	Keyring     string
 	SkipRefresh bool
 	ColumnWidth uint
+	// MaxConcurrentDownloads is the maximum number of dependencies downloaded
+	// at the same time.
+	MaxConcurrentDownloads int
 }
 
 // NewDependency creates a new Dependency object with the given configuration.
 func NewDependency() *Dependency {
 	return &Dependency{
-		ColumnWidth: 80,
+		ColumnWidth:            80,
+		MaxConcurrentDownloads: downloader.DefaultMaxConcurrentDownloads,
 	}
 }
 
@@ -65,6 +72,47 @@ func (d *Dependency) List(chartpath string, out io.Writer) error {
 	return nil
 }
 
+// VerifyLock executes 'helm dependency verify'.
+//
+// It checks that the archives of the dependencies in the charts directory of
+// the chart directory at chartpath are the ones recorded in its lock file.
+func (d *Dependency) VerifyLock(chartpath string, out io.Writer) error {
+	c, err := loader.LoadDir(chartpath)
+	if err != nil {
+		return err
+	}
+	if c.Metadata.Dependencies == nil {
+		fmt.Fprintf(out, "WARNING: no dependencies at %s\n", filepath.Join(chartpath, "charts"))
+		return nil
+	}
+
+	statuses, err := verifyDependencyDigests(chartpath, c)
+	if statuses != nil {
+		table := uitable.New()
+		table.MaxColWidth = d.ColumnWidth
+		table.AddRow("NAME", "VERSION", "REPOSITORY", "STATUS")
+		for i, dep := range c.Lock.Dependencies {
+			table.AddRow(dep.Name, dep.Version, dep.Repository, statuses[i])
+		}
+		fmt.Fprintln(out, table)
+	}
+	return err
+}
+
+// verifyDependencyDigests checks the archives of the dependencies of the
+// chart c, loaded from the directory chartpath, against the digests of its
+// lock file, and returns the status of each locked dependency.
+func verifyDependencyDigests(chartpath string, c *chart.Chart) ([]string, error) {
+	lockfile := "Chart.lock"
+	if c.Metadata.APIVersion == chart.APIVersionV1 {
+		lockfile = "requirements.lock"
+	}
+	if c.Lock == nil {
+		return nil, errors.Errorf("%s not found in %s. Please update the dependencies", lockfile, chartpath)
+	}
+	return resolver.VerifyDigests(chartpath, c.Lock)
+}
+
 // dependencyStatus returns a string describing the status of a dependency viz a viz the parent chart.
 func (d *Dependency) dependencyStatus(chartpath string, dep *chart.Dependency, parent *chart.Chart) string {
 	filename := fmt.Sprintf("%s-%s.tgz", dep.Name, "*")
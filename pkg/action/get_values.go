@@ -18,6 +18,7 @@ package action
 
 import (
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 // GetValues is the action for checking a given release's values.
@@ -28,6 +29,9 @@ type GetValues struct {
 
 	Version   int
 	AllValues bool
+	// ShowSensitive shows the values the chart marks as sensitive instead of
+	// redacting them.
+	ShowSensitive bool
 }
 
 // NewGetValues creates a new GetValues object with the given configuration.
@@ -54,7 +58,14 @@ func (g *GetValues) Run(name string) (map[string]interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-		return cfg, nil
+		return g.redact(rel, cfg), nil
 	}
-	return rel.Config, nil
+	return g.redact(rel, rel.Config), nil
+}
+
+func (g *GetValues) redact(rel *release.Release, vals map[string]interface{}) map[string]interface{} {
+	if g.ShowSensitive {
+		return vals
+	}
+	return chartutil.RedactValues(rel.Chart, vals)
 }
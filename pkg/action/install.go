@@ -98,6 +98,9 @@ type Install struct {
 	DisableOpenAPIValidation bool
 	IncludeCRDs              bool
 	Labels                   map[string]string
+	// HashSensitiveValues stores a keyed hash of the values the chart marks as
+	// sensitive instead of the values themselves.
+	HashSensitiveValues bool
 	// KubeVersion allows specifying a custom kubernetes version to use and
 	// APIVersions allows a manual set of supported API Versions to be passed
 	// (for things like templating). These are ignored if ClientOnly is false
@@ -105,6 +108,9 @@ type Install struct {
 	APIVersions chartutil.VersionSet
 	// Used by helm template to render charts with .Release.IsUpgrade. Ignored if Dry-Run is false
 	IsUpgrade bool
+	// PreviousRelease, if set, is exposed to templates as .Release.Previous.
+	// Used by helm template to render charts against a stored release.
+	PreviousRelease *release.Release
 	// Enable DNS lookups when rendering templates
 	EnableDNS bool
 	// Used by helm template to add the release as part of OutputDir path
@@ -294,12 +300,17 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 
 	// special case for helm template --is-upgrade
 	isUpgrade := i.IsUpgrade && i.isDryRun()
+	previous, err := previousRelease(i.PreviousRelease)
+	if err != nil {
+		return nil, err
+	}
 	options := chartutil.ReleaseOptions{
 		Name:      i.ReleaseName,
 		Namespace: i.Namespace,
 		Revision:  1,
 		IsInstall: !isUpgrade,
 		IsUpgrade: isUpgrade,
+		Previous:  previous,
 	}
 	valuesToRender, err := chartutil.ToRenderValuesWithSchemaValidation(chrt, vals, options, caps, i.SkipSchemaValidation)
 	if err != nil {
@@ -310,7 +321,11 @@ func (i *Install) RunWithContext(ctx context.Context, chrt *chart.Chart, vals ma
 		return nil, fmt.Errorf("user suplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
 
-	rel := i.createRelease(chrt, vals, i.Labels)
+	config, err := i.cfg.hashSensitiveValues(i.HashSensitiveValues, i.isDryRun(), chrt, i.ReleaseName, vals)
+	if err != nil {
+		return nil, err
+	}
+	rel := i.createRelease(chrt, config, i.Labels)
 
 	var manifestDoc *bytes.Buffer
 	rel.Hooks, manifestDoc, rel.Info.Notes, err = i.cfg.renderResources(chrt, valuesToRender, i.ReleaseName, i.OutputDir, i.SubNotes, i.UseReleaseName, i.IncludeCRDs, i.PostRenderer, interactWithRemote, i.EnableDNS, i.HideSecret)
@@ -732,7 +747,7 @@ OUTER:
 // This does not ensure that the chart is well-formed; only that the requested filename exists.
 //
 // Order of resolution:
-// - relative to current working directory
+// - relative to current working directory, extracting the chart of bundles to the repository cache
 // - if path is absolute or begins with '.', error out here
 // - URL
 //
@@ -750,6 +765,17 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		if err != nil {
 			return abs, err
 		}
+		if IsBundle(abs) {
+			verify := downloader.VerifyNever
+			if c.Verify {
+				verify = downloader.VerifyAlways
+			}
+			if err := os.MkdirAll(settings.RepositoryCache, 0755); err != nil {
+				return "", err
+			}
+			chartPath, _, err := extractBundleChart(abs, settings.RepositoryCache, verify, c.Keyring)
+			return chartPath, err
+		}
 		if c.Verify {
 			if _, err := downloader.VerifyChart(abs, c.Keyring); err != nil {
 				return "", err
@@ -762,9 +788,10 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 	}
 
 	dl := downloader.ChartDownloader{
-		Out:     os.Stdout,
-		Keyring: c.Keyring,
-		Getters: getter.All(settings),
+		Out:         os.Stdout,
+		Keyring:     c.Keyring,
+		TrustPolicy: settings.TrustPolicy,
+		Getters:     getter.All(settings),
 		Options: []getter.Option{
 			getter.WithPassCredentialsAll(c.PassCredentialsAll),
 			getter.WithTLSClientConfig(c.CertFile, c.KeyFile, c.CaFile),
@@ -773,6 +800,9 @@ func (c *ChartPathOptions) LocateChart(name string, settings *cli.EnvSettings) (
 		},
 		RepositoryConfig: settings.RepositoryConfig,
 		RepositoryCache:  settings.RepositoryCache,
+		ChartCache:       settings.ChartCache,
+		Offline:          settings.Offline,
+		Debug:            settings.Debug,
 		RegistryClient:   c.registryClient,
 	}
 
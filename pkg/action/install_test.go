@@ -136,6 +136,45 @@ func TestInstallReleaseClientOnly(t *testing.T) {
 	is.Equal(instAction.cfg.KubeClient, &kubefake.PrintingKubeClient{Out: io.Discard})
 }
 
+func TestInstallRelease_Previous(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	res, err := instAction.Run(buildChart(withPreviousReleaseTemplate()), nil)
+	req.NoError(err)
+	is.Contains(res.Manifest, `previous: "--0"`)
+
+	instAction = installAction(t)
+	instAction.DryRun = true
+	instAction.IsUpgrade = true
+	instAction.PreviousRelease = releaseStub()
+	res, err = instAction.Run(buildChart(withPreviousReleaseTemplate()), nil)
+	req.NoError(err)
+	is.Contains(res.Manifest, `previous: "value-0.1.0-1"`)
+}
+
+func TestInstallRelease_HashSensitiveValues(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	instAction := installAction(t)
+	instAction.HashSensitiveValues = true
+	vals := map[string]interface{}{"password": "hunter22", "user": "admin"}
+	res, err := instAction.Run(buildChart(withSensitiveValues("password")), vals)
+	req.NoError(err)
+
+	rel, err := instAction.cfg.Releases.Get(res.Name, res.Version)
+	req.NoError(err)
+	is.Equal("admin", rel.Config["user"])
+	is.Equal([]string{"password"}, chartutil.HashedValuePaths(rel.Config))
+	is.Equal("hunter22", vals["password"], "the supplied values must not be altered")
+
+	key, err := instAction.cfg.SensitiveValuesKeys.Key(res.Name)
+	req.NoError(err)
+	is.Equal(chartutil.HashSensitiveValues(rel.Chart, vals, key), rel.Config, "the values must be hashed with the key of the release")
+}
+
 func TestInstallRelease_NoName(t *testing.T) {
 	instAction := installAction(t)
 	instAction.ReleaseName = ""
@@ -400,6 +439,20 @@ func TestInstallRelease_KubeVersion(t *testing.T) {
 	is.Contains(err.Error(), "chart requires kubeVersion")
 }
 
+func TestInstallRelease_HelmVersion(t *testing.T) {
+	is := assert.New(t)
+	instAction := installAction(t)
+	vals := map[string]interface{}{}
+	_, err := instAction.Run(buildChart(withHelmVersion(">=3.0.0")), vals)
+	is.NoError(err)
+
+	instAction.ReleaseName = "should-fail"
+	vals = map[string]interface{}{}
+	_, err = instAction.Run(buildChart(withDependency(withName("future"), withHelmVersion(">=99.0.0"))), vals)
+	is.Error(err)
+	is.Contains(err.Error(), "chart hello/charts/future requires Helm >=99.0.0")
+}
+
 func TestInstallRelease_Wait(t *testing.T) {
 	is := assert.New(t)
 	instAction := installAction(t)
@@ -25,6 +25,7 @@ import (
 
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/lint"
+	"helm.sh/helm/v3/pkg/lint/rules"
 	"helm.sh/helm/v3/pkg/lint/support"
 )
 
@@ -38,6 +39,9 @@ type Lint struct {
 	Quiet                bool
 	SkipSchemaValidation bool
 	KubeVersion          *chartutil.KubeVersion
+	// VerifyDependencies checks the archives of the dependencies of chart
+	// directories against the digests of their lock files.
+	VerifyDependencies bool
 }
 
 // LintResult is the result of Lint
@@ -65,6 +69,9 @@ func (l *Lint) Run(paths []string, vals map[string]interface{}) *LintResult {
 			result.Errors = append(result.Errors, err)
 			continue
 		}
+		if l.VerifyDependencies && !isChartArchive(path) {
+			rules.DependencyDigests(&linter)
+		}
 
 		result.Messages = append(result.Messages, linter.Messages...)
 		result.TotalChartsLinted++
@@ -87,11 +94,16 @@ func HasWarningsOrErrors(result *LintResult) bool {
 	return len(result.Errors) > 0
 }
 
+// isChartArchive returns whether the path given to lint is a chart archive.
+func isChartArchive(path string) bool {
+	return strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz")
+}
+
 func lintChart(path string, vals map[string]interface{}, namespace string, kubeVersion *chartutil.KubeVersion, skipSchemaValidation bool) (support.Linter, error) {
 	var chartPath string
 	linter := support.Linter{}
 
-	if strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz") {
+	if isChartArchive(path) {
 		tempDir, err := os.MkdirTemp("", "helm-lint")
 		if err != nil {
 			return linter, errors.Wrap(err, "unable to create temp dir to extract tarball")
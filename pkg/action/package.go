@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/pkg/errors"
@@ -43,6 +44,15 @@ type Package struct {
 	AppVersion       string
 	Destination      string
 	DependencyUpdate bool
+	// VerifyDependencies checks the archives of the dependencies in the
+	// charts directory against the digests of the lock file of the chart.
+	VerifyDependencies bool
+	// Reproducible makes the archive depend on the contents of the chart
+	// only, so that packaging the same chart twice yields identical archives.
+	Reproducible bool
+	// ModTime is the modification time of the files of reproducible
+	// archives. The zero value stands for the Unix epoch.
+	ModTime time.Time
 
 	RepositoryConfig string
 	RepositoryCache  string
@@ -77,6 +87,11 @@ func (p *Package) Run(path string, _ map[string]interface{}) (string, error) {
 		if err := CheckDependencies(ch, reqs); err != nil {
 			return "", err
 		}
+		if p.VerifyDependencies {
+			if _, err := verifyDependencyDigests(path, ch); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	var dest string
@@ -91,7 +106,10 @@ func (p *Package) Run(path string, _ map[string]interface{}) (string, error) {
 		dest = p.Destination
 	}
 
-	name, err := chartutil.Save(ch, dest)
+	name, err := chartutil.SaveWithOptions(ch, dest, chartutil.SaveOptions{
+		Reproducible: p.Reproducible,
+		ModTime:      p.ModTime,
+	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to save")
 	}
@@ -112,26 +130,26 @@ func validateVersion(ver string) error {
 }
 
 // Clearsign signs a chart
+//
+// If Key is the path of an SSH private key, the chart is signed with it.
+// Otherwise, Key names the PGP key of Keyring to sign the chart with.
 func (p *Package) Clearsign(filename string) error {
-	// Load keyring
-	signer, err := provenance.NewFromKeyring(p.Keyring, p.Key)
-	if err != nil {
-		return err
-	}
-
 	passphraseFetcher := promptUser
 	if p.PassphraseFile != "" {
+		var err error
 		passphraseFetcher, err = passphraseFileFetcher(p.PassphraseFile, os.Stdin)
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := signer.DecryptKey(passphraseFetcher); err != nil {
-		return err
+	var sig string
+	var err error
+	if provenance.IsSSHPrivateKeyFile(p.Key) {
+		sig, err = clearsignSSH(filename, p.Key, passphraseFetcher)
+	} else {
+		sig, err = clearsignPGP(filename, p.Keyring, p.Key, passphraseFetcher)
 	}
-
-	sig, err := signer.ClearSign(filename)
 	if err != nil {
 		return err
 	}
@@ -139,6 +157,28 @@ func (p *Package) Clearsign(filename string) error {
 	return os.WriteFile(filename+".prov", []byte(sig), 0644)
 }
 
+func clearsignPGP(filename, keyring, key string, fn provenance.PassphraseFetcher) (string, error) {
+	// Load keyring
+	signer, err := provenance.NewFromKeyring(keyring, key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := signer.DecryptKey(fn); err != nil {
+		return "", err
+	}
+
+	return signer.ClearSign(filename)
+}
+
+func clearsignSSH(filename, keyfile string, fn provenance.PassphraseFetcher) (string, error) {
+	signer, err := provenance.NewSSHFromKeyFile(keyfile, fn)
+	if err != nil {
+		return "", err
+	}
+	return signer.ClearSign(filename)
+}
+
 // promptUser implements provenance.PassphraseFetcher
 func promptUser(name string) ([]byte, error) {
 	fmt.Printf("Password for key %q >  ", name)
@@ -17,7 +17,6 @@ limitations under the License.
 package action
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,6 +27,7 @@ import (
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
 )
@@ -77,14 +77,17 @@ func (p *Pull) SetRegistryClient(client *registry.Client) {
 }
 
 // Run executes 'helm pull' against the given release.
+//
+// chartRef may also be the path of a bundle, whose chart is pulled.
 func (p *Pull) Run(chartRef string) (string, error) {
 	var out strings.Builder
 
 	c := downloader.ChartDownloader{
-		Out:     &out,
-		Keyring: p.Keyring,
-		Verify:  downloader.VerifyNever,
-		Getters: getter.All(p.Settings),
+		Out:         &out,
+		Keyring:     p.Keyring,
+		TrustPolicy: p.Settings.TrustPolicy,
+		Verify:      downloader.VerifyNever,
+		Getters:     getter.All(p.Settings),
 		Options: []getter.Option{
 			getter.WithBasicAuth(p.Username, p.Password),
 			getter.WithPassCredentialsAll(p.PassCredentialsAll),
@@ -95,6 +98,9 @@ func (p *Pull) Run(chartRef string) (string, error) {
 		RegistryClient:   p.cfg.RegistryClient,
 		RepositoryConfig: p.Settings.RepositoryConfig,
 		RepositoryCache:  p.Settings.RepositoryCache,
+		ChartCache:       p.Settings.ChartCache,
+		Offline:          p.Settings.Offline,
+		Debug:            p.Settings.Debug,
 	}
 
 	if registry.IsOCI(chartRef) {
@@ -129,17 +135,20 @@ func (p *Pull) Run(chartRef string) (string, error) {
 		chartRef = chartURL
 	}
 
-	saved, v, err := c.DownloadTo(chartRef, p.Version, dest)
+	var saved string
+	var v *provenance.Verification
+	var err error
+	if IsBundle(chartRef) {
+		saved, v, err = extractBundleChart(chartRef, dest, c.Verify, p.Keyring)
+	} else {
+		saved, v, err = c.DownloadTo(chartRef, p.Version, dest)
+	}
 	if err != nil {
 		return out.String(), err
 	}
 
 	if p.Verify {
-		for name := range v.SignedBy.Identities {
-			fmt.Fprintf(&out, "Signed by: %v\n", name)
-		}
-		fmt.Fprintf(&out, "Using Key With Fingerprint: %X\n", v.SignedBy.PrimaryKey.Fingerprint)
-		fmt.Fprintf(&out, "Chart Hash Verified: %s\n", v.FileHash)
+		writeVerification(&out, v)
 	}
 
 	// After verification, untar the chart into the requested directory.
@@ -0,0 +1,392 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/sbom"
+	"helm.sh/helm/v3/internal/version"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// SBOM is the action for generating software bills of materials of charts and
+// releases.
+//
+// It provides the implementation of 'helm sbom'.
+type SBOM struct {
+	cfg *Configuration
+
+	// Format is the format of the documents, cyclonedx or spdx.
+	Format string
+	// Initializing Version to 0 will get the latest revision of the release.
+	Version int
+	// Created is the creation time of the documents of charts. The current
+	// time is used when zero.
+	Created time.Time
+}
+
+// NewSBOM creates a new SBOM object with the given configuration.
+func NewSBOM(cfg *Configuration) *SBOM {
+	return &SBOM{
+		cfg:    cfg,
+		Format: sbom.CycloneDX,
+	}
+}
+
+// Run returns the software bill of materials of the given release.
+//
+// Releases do not keep the charts of their dependencies, so the dependencies
+// are the ones of the lock file of the chart, without digests.
+func (s *SBOM) Run(name string) ([]byte, error) {
+	if err := s.cfg.KubeClient.IsReachable(); err != nil {
+		return nil, err
+	}
+	rel, err := s.cfg.releaseContent(name, s.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(rel, "")
+	if err != nil {
+		return nil, err
+	}
+	doc.Name = fmt.Sprintf("%s.v%d", rel.Name, rel.Version)
+	doc.Created = rel.Info.LastDeployed.Time
+	doc.Root.Properties = append(doc.Root.Properties,
+		sbom.Property{Name: "helm:release", Value: rel.Name},
+		sbom.Property{Name: "helm:namespace", Value: rel.Namespace},
+		sbom.Property{Name: "helm:revision", Value: strconv.Itoa(rel.Version)},
+	)
+	return sbom.Encode(doc, s.Format)
+}
+
+// RunChart returns the software bill of materials of a chart, given a release
+// rendering it, such as the one of a client-only dry-run install.
+//
+// chartPath is the chart directory or archive the chart was loaded from. The
+// digests of the chart archives found there are recorded.
+func (s *SBOM) RunChart(rel *release.Release, chartPath string) ([]byte, error) {
+	doc, err := s.document(rel, chartPath)
+	if err != nil {
+		return nil, err
+	}
+	doc.Name = fmt.Sprintf("%s-%s", rel.Chart.Name(), rel.Chart.Metadata.Version)
+	doc.Created = s.Created
+	if doc.Created.IsZero() {
+		doc.Created = s.cfg.Now().Time
+	}
+	return sbom.Encode(doc, s.Format)
+}
+
+// document returns the software bill of materials of the chart and the images
+// of rel.
+func (s *SBOM) document(rel *release.Release, chartPath string) (*sbom.Document, error) {
+	if rel.Chart == nil || rel.Chart.Metadata == nil {
+		return nil, errors.Errorf("release %q has no chart", rel.Name)
+	}
+
+	var rootDigest string
+	digests := map[string]string{}
+	if chartPath != "" {
+		var err error
+		if rootDigest, err = chartArchiveDigests(chartPath, digests); err != nil {
+			return nil, err
+		}
+	}
+
+	doc := &sbom.Document{Tool: "helm", ToolVersion: version.GetVersion()}
+	doc.Root = chartComponent(rel.Chart, rel.Chart.Name(), nil, digests)
+	doc.Root.Digest = rootDigest
+	if rel.Chart.Lock != nil && rel.Chart.Lock.Digest != "" {
+		doc.Root.Properties = append(doc.Root.Properties, sbom.Property{Name: "helm:lockDigest", Value: rel.Chart.Lock.Digest})
+	}
+	addChartDependencies(doc, doc.Root, rel.Chart, rel.Chart.Name(), digests)
+
+	manifests := []string{rel.Manifest}
+	for _, h := range rel.Hooks {
+		manifests = append(manifests, h.Manifest)
+	}
+	images, err := releaseutil.ExtractImages(strings.Join(manifests, "\n---\n"))
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range images {
+		c := imageComponent(img)
+		doc.Components = append(doc.Components, c)
+		doc.Root.DependsOn = append(doc.Root.DependsOn, c.ID)
+	}
+	return doc, nil
+}
+
+// addChartDependencies adds the dependencies of c, at fullPath in the tree of
+// charts, to doc as dependencies of parent.
+//
+// Dependencies are the ones of the metadata of c, with the versions of its lock
+// file, along with the ones found in its charts directory only. Subcharts are
+// named after the alias of their dependency, if any.
+func addChartDependencies(doc *sbom.Document, parent *sbom.Component, c *chart.Chart, fullPath string, digests map[string]string) {
+	loaded := map[string]*chart.Chart{}
+	for _, sub := range c.Dependencies() {
+		loaded[sub.Name()] = sub
+	}
+
+	var deps []*chart.Dependency
+	for _, dep := range c.Metadata.Dependencies {
+		if dep != nil {
+			deps = append(deps, dep)
+		}
+	}
+	if c.Lock != nil {
+		deps = lockedDependencies(deps, c.Lock.Dependencies)
+	}
+	add := func(sub *chart.Chart, name string, dep *chart.Dependency) {
+		subPath := fullPath + "/charts/" + name
+		var comp *sbom.Component
+		if sub != nil {
+			comp = chartComponent(sub, subPath, dep, digests)
+		} else {
+			comp = chartComponent(&chart.Chart{Metadata: &chart.Metadata{Name: dep.Name, Version: dep.Version}}, subPath, dep, digests)
+		}
+		doc.Components = append(doc.Components, comp)
+		parent.DependsOn = append(parent.DependsOn, comp.ID)
+		if sub != nil {
+			addChartDependencies(doc, comp, sub, subPath, digests)
+		}
+	}
+
+	listed := map[string]bool{}
+	for _, dep := range deps {
+		name := dep.Name
+		if dep.Alias != "" {
+			name = dep.Alias
+		}
+		if listed[name] {
+			continue
+		}
+		listed[name] = true
+		add(loaded[name], name, dep)
+	}
+	for _, sub := range c.Dependencies() {
+		if !listed[sub.Name()] {
+			add(sub, sub.Name(), &chart.Dependency{Name: sub.Name()})
+		}
+	}
+}
+
+// lockedDependencies returns the dependencies of the metadata of a chart with
+// the chart names, versions and repositories of the locked ones, followed by
+// the locked dependencies missing from the metadata.
+//
+// The lock file lists the dependencies of the metadata in order, by chart name
+// only, so aliased dependencies are matched by position when the lock file
+// lists as many dependencies.
+func lockedDependencies(deps, locked []*chart.Dependency) []*chart.Dependency {
+	used := make([]bool, len(locked))
+	match := func(i int, dep *chart.Dependency) *chart.Dependency {
+		if len(locked) == len(deps) && locked[i] != nil && (dep.Alias != "" || locked[i].Name == dep.Name) {
+			used[i] = true
+			return locked[i]
+		}
+		for j, l := range locked {
+			if l != nil && !used[j] && dep.Alias == "" && l.Name == dep.Name {
+				used[j] = true
+				return l
+			}
+		}
+		return nil
+	}
+
+	out := make([]*chart.Dependency, 0, len(deps)+len(locked))
+	for i, dep := range deps {
+		d := *dep
+		if l := match(i, dep); l != nil {
+			d.Name, d.Version, d.Repository = l.Name, l.Version, l.Repository
+		}
+		out = append(out, &d)
+	}
+	for j, l := range locked {
+		if l != nil && !used[j] {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// chartComponent returns the component of c, at fullPath in the tree of charts.
+// dep is the dependency c is resolved from, if any.
+func chartComponent(c *chart.Chart, fullPath string, dep *chart.Dependency, digests map[string]string) *sbom.Component {
+	// Subcharts are renamed after their alias, the dependency keeps the name
+	// of the chart.
+	name := c.Metadata.Name
+	if dep != nil && dep.Name != "" {
+		name = dep.Name
+	}
+	comp := &sbom.Component{
+		ID:          "chart:" + fullPath + "@" + c.Metadata.Version,
+		Kind:        sbom.KindApplication,
+		Name:        name,
+		Version:     c.Metadata.Version,
+		Description: c.Metadata.Description,
+		Digest:      digests[name+"@"+c.Metadata.Version],
+	}
+	if c.Metadata.Type == "library" {
+		comp.Kind = sbom.KindLibrary
+	}
+	if dep != nil {
+		comp.Repository = dep.Repository
+		if dep.Alias != "" {
+			comp.Properties = append(comp.Properties, sbom.Property{Name: "helm:alias", Value: dep.Alias})
+		}
+	}
+	if c.Metadata.AppVersion != "" {
+		comp.Properties = append(comp.Properties, sbom.Property{Name: "helm:appVersion", Value: c.Metadata.AppVersion})
+	}
+	return comp
+}
+
+// imageComponent returns the component of a container image.
+func imageComponent(img releaseutil.Image) *sbom.Component {
+	name, tag, digest := splitImageReference(img.Image)
+	comp := &sbom.Component{
+		ID:      "image:" + img.Image,
+		Kind:    sbom.KindContainer,
+		Name:    name,
+		Version: tag,
+		Digest:  digest,
+	}
+	if comp.Version == "" {
+		comp.Version = digest
+	}
+	for _, r := range img.Resources {
+		comp.Properties = append(comp.Properties, sbom.Property{Name: "helm:resource", Value: r})
+	}
+	return comp
+}
+
+// splitImageReference splits an image reference into its name, its tag and its
+// digest.
+func splitImageReference(ref string) (name, tag, digest string) {
+	name = ref
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	return name, tag, digest
+}
+
+// chartArchiveDigests records the digests of the chart archives in the charts
+// directories of the chart at chartPath, recursively, by chart name and version.
+// If chartPath is a chart archive, its digest is returned.
+func chartArchiveDigests(chartPath string, digests map[string]string) (string, error) {
+	fi, err := os.Stat(chartPath)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		archives, err := filepath.Glob(filepath.Join(chartPath, "charts", "*.tgz"))
+		if err != nil {
+			return "", err
+		}
+		for _, archive := range archives {
+			data, err := os.ReadFile(archive)
+			if err != nil {
+				return "", err
+			}
+			if err := recordArchiveDigests(data, digests); err != nil {
+				return "", errors.Wrapf(err, "unable to read %s", archive)
+			}
+		}
+		return "", nil
+	}
+
+	data, err := os.ReadFile(chartPath)
+	if err != nil {
+		return "", err
+	}
+	if err := recordNestedArchiveDigests(data, digests); err != nil {
+		return "", errors.Wrapf(err, "unable to read %s", chartPath)
+	}
+	digest, err := provenance.Digest(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + digest, nil
+}
+
+// recordArchiveDigests records the digest of the chart archive data, and of
+// the archives it holds.
+func recordArchiveDigests(data []byte, digests map[string]string) error {
+	c, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	digest, err := provenance.Digest(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	digests[c.Metadata.Name+"@"+c.Metadata.Version] = "sha256:" + digest
+	return recordNestedArchiveDigests(data, digests)
+}
+
+// recordNestedArchiveDigests records the digests of the chart archives held in
+// the charts directories of the chart archive data.
+func recordNestedArchiveDigests(data []byte, digests map[string]string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hd.Typeflag != tar.TypeReg || path.Ext(hd.Name) != ".tgz" || path.Base(path.Dir(hd.Name)) != "charts" {
+			continue
+		}
+		nested, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := recordArchiveDigests(nested, digests); err != nil {
+			return errors.Wrapf(err, "unable to read %s", hd.Name)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/internal/sbom"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+var manifestWithImages = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+      - name: sidecar
+        image: example.com/proxy@sha256:0123
+`
+
+type testBOM struct {
+	Metadata struct {
+		Timestamp string `json:"timestamp"`
+		Component struct {
+			BOMRef     string          `json:"bom-ref"`
+			Hashes     []interface{}   `json:"hashes"`
+			Properties []sbom.Property `json:"properties"`
+		} `json:"component"`
+	} `json:"metadata"`
+	Components []struct {
+		BOMRef  string `json:"bom-ref"`
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Hashes  []struct {
+			Content string `json:"content"`
+		} `json:"hashes"`
+		ExternalReferences []struct {
+			URL string `json:"url"`
+		} `json:"externalReferences"`
+	} `json:"components"`
+	Dependencies []struct {
+		Ref       string   `json:"ref"`
+		DependsOn []string `json:"dependsOn"`
+	} `json:"dependencies"`
+}
+
+func TestSBOMRunChart(t *testing.T) {
+	is := assert.New(t)
+
+	lib := buildChart(withName("lib"), withVersion("0.2.0"))
+	lib.Metadata.Type = "library"
+	c := buildChart(withName("app"), withDependency(withName("lib"), withVersion("0.2.0")))
+	c.Lock = &chart.Lock{
+		Digest: "sha256:lock",
+		Dependencies: []*chart.Dependency{
+			{Name: "lib", Version: "0.2.0", Repository: "https://charts.example.com"},
+			{Name: "missing", Version: "1.0.0", Repository: "https://charts.example.com"},
+		},
+	}
+
+	// The chart directory holds the archive of its dependency.
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts"), 0755))
+	archive, err := chartutil.Save(lib, filepath.Join(dir, "charts"))
+	require.NoError(t, err)
+	digest, err := provenance.DigestFile(archive)
+	require.NoError(t, err)
+
+	rel := releaseStub()
+	rel.Chart = c
+	rel.Manifest = manifestWithImages
+	rel.Hooks = nil
+
+	client := NewSBOM(actionConfigFixture(t))
+	client.Created = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := client.RunChart(rel, dir)
+	require.NoError(t, err)
+
+	var bom testBOM
+	require.NoError(t, json.Unmarshal(data, &bom))
+	is.Equal("2024-01-02T03:04:05Z", bom.Metadata.Timestamp)
+	is.Equal("chart:app@0.1.0", bom.Metadata.Component.BOMRef)
+	is.Nil(bom.Metadata.Component.Hashes)
+	is.Contains(bom.Metadata.Component.Properties, sbom.Property{Name: "helm:lockDigest", Value: "sha256:lock"})
+
+	require.Len(t, bom.Components, 4)
+	is.Equal("chart:app/charts/lib@0.2.0", bom.Components[0].BOMRef)
+	is.Equal(digest, bom.Components[0].Hashes[0].Content)
+	is.Equal("https://charts.example.com", bom.Components[0].ExternalReferences[0].URL)
+	is.Equal("chart:app/charts/missing@1.0.0", bom.Components[1].BOMRef)
+	is.Nil(bom.Components[1].Hashes)
+	is.Equal("example.com/proxy", bom.Components[2].Name)
+	is.Equal("0123", bom.Components[2].Hashes[0].Content)
+	is.Equal("image:nginx:1.25", bom.Components[3].BOMRef)
+	is.Equal("nginx", bom.Components[3].Name)
+	is.Equal("1.25", bom.Components[3].Version)
+
+	is.Equal([]string{
+		"chart:app/charts/lib@0.2.0",
+		"chart:app/charts/missing@1.0.0",
+		"image:example.com/proxy@sha256:0123",
+		"image:nginx:1.25",
+	}, bom.Dependencies[0].DependsOn)
+}
+
+func TestSBOMRunChartAliases(t *testing.T) {
+	is := assert.New(t)
+
+	c := buildChart(withName("app"),
+		withDependency(withName("lib"), withVersion("0.2.0")),
+		withMetadataDependency(chart.Dependency{Name: "lib", Version: "0.2.0", Alias: "cache", Repository: "https://charts.example.com"}),
+		withMetadataDependency(chart.Dependency{Name: "lib", Version: "0.2.0", Alias: "queue", Repository: "https://charts.example.com"}),
+	)
+	c.Lock = &chart.Lock{Dependencies: []*chart.Dependency{
+		{Name: "lib", Version: "0.2.0", Repository: "https://charts.example.com"},
+		{Name: "lib", Version: "0.2.0", Repository: "https://charts.example.com"},
+	}}
+	// Installs rename the subcharts after their alias.
+	require.NoError(t, chartutil.ProcessDependenciesWithMerge(c, map[string]interface{}{}))
+
+	rel := releaseStub()
+	rel.Chart = c
+	rel.Manifest = ""
+	rel.Hooks = nil
+
+	client := NewSBOM(actionConfigFixture(t))
+	data, err := client.RunChart(rel, t.TempDir())
+	require.NoError(t, err)
+
+	var bom testBOM
+	require.NoError(t, json.Unmarshal(data, &bom))
+	require.Len(t, bom.Components, 2)
+	for i, alias := range []string{"cache", "queue"} {
+		is.Equal("chart:app/charts/"+alias+"@0.2.0", bom.Components[i].BOMRef)
+		is.Equal("lib", bom.Components[i].Name)
+		is.Equal("0.2.0", bom.Components[i].Version)
+	}
+	is.Equal([]string{"chart:app/charts/cache@0.2.0", "chart:app/charts/queue@0.2.0"}, bom.Dependencies[0].DependsOn)
+}
+
+func TestSBOMRunChartArchive(t *testing.T) {
+	is := assert.New(t)
+
+	c := buildChart(withName("app"))
+	archive, err := chartutil.Save(c, t.TempDir())
+	require.NoError(t, err)
+	digest, err := provenance.DigestFile(archive)
+	require.NoError(t, err)
+
+	rel := releaseStub()
+	rel.Chart = c
+	rel.Manifest = manifestWithImages
+	rel.Hooks = nil
+
+	client := NewSBOM(actionConfigFixture(t))
+	client.Format = sbom.SPDX
+	data, err := client.RunChart(rel, archive)
+	require.NoError(t, err)
+
+	var doc struct {
+		Name     string `json:"name"`
+		Packages []struct {
+			Checksums []struct {
+				ChecksumValue string `json:"checksumValue"`
+			} `json:"checksums"`
+		} `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	is.Equal("app-0.1.0", doc.Name)
+	is.Len(doc.Packages, 3)
+	is.Equal(digest, doc.Packages[0].Checksums[0].ChecksumValue)
+}
+
+func TestSBOMRun(t *testing.T) {
+	is := assert.New(t)
+
+	cfg := actionConfigFixture(t)
+	rel := releaseStub()
+	rel.Namespace = "spaced"
+	rel.Manifest = manifestWithImages
+	rel.Hooks = []*release.Hook{{
+		Name:     "test",
+		Kind:     "Pod",
+		Path:     "test",
+		Manifest: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: test\nspec:\n  containers:\n  - name: test\n    image: busybox\n",
+		Events:   []release.HookEvent{release.HookTest},
+	}}
+	rel.Info.LastDeployed = rel.Info.LastDeployed.Round(time.Second)
+	require.NoError(t, cfg.Releases.Create(rel))
+
+	client := NewSBOM(cfg)
+	data, err := client.Run(rel.Name)
+	require.NoError(t, err)
+
+	var bom testBOM
+	require.NoError(t, json.Unmarshal(data, &bom))
+	is.Equal(rel.Info.LastDeployed.UTC().Format(time.RFC3339), bom.Metadata.Timestamp)
+	is.Contains(bom.Metadata.Component.Properties, sbom.Property{Name: "helm:release", Value: "angry-panda"})
+	is.Contains(bom.Metadata.Component.Properties, sbom.Property{Name: "helm:namespace", Value: "spaced"})
+	is.Contains(bom.Metadata.Component.Properties, sbom.Property{Name: "helm:revision", Value: "1"})
+
+	// The images of hooks are included.
+	var images []string
+	for _, c := range bom.Components {
+		images = append(images, c.BOMRef)
+	}
+	is.Equal([]string{"image:busybox", "image:example.com/proxy@sha256:0123", "image:nginx:1.25"}, images)
+
+	_, err = client.Run("nope")
+	is.Error(err)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// Schema is the action for generating a chart's values schema.
+//
+// It provides the implementation of 'helm schema' and its respective subcommands.
+type Schema struct {
+	// Check compares the generated schema with the chart's values.schema.json
+	// instead of writing it.
+	Check bool
+	// Force overwrites an existing values.schema.json.
+	Force bool
+}
+
+// NewSchema creates a new Schema object.
+func NewSchema() *Schema {
+	return &Schema{}
+}
+
+// Generate executes 'helm schema generate'.
+func (s *Schema) Generate(chartpath string, out io.Writer) error {
+	values, err := os.ReadFile(filepath.Join(chartpath, chartutil.ValuesfileName))
+	if err != nil {
+		return err
+	}
+	schema, err := chartutil.GenerateSchema(values)
+	if err != nil {
+		return errors.Wrapf(err, "unable to generate schema from %s", chartutil.ValuesfileName)
+	}
+
+	schemaPath := filepath.Join(chartpath, chartutil.SchemafileName)
+	if !s.Check {
+		if _, err := os.Stat(schemaPath); err == nil && !s.Force {
+			return errors.Errorf("%s already exists; use --force to overwrite it", schemaPath)
+		}
+		if err := os.WriteFile(schemaPath, schema, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Generated %s\n", schemaPath)
+		return nil
+	}
+
+	current, err := os.ReadFile(schemaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("%s does not exist", schemaPath)
+		}
+		return err
+	}
+	equal, err := chartutil.SchemaEqual(current, schema)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse %s", schemaPath)
+	}
+	if !equal {
+		return errors.Errorf("%s does not match %s; run 'helm schema generate --force' to update it", schemaPath, chartutil.ValuesfileName)
+	}
+	fmt.Fprintf(out, "%s is up to date\n", schemaPath)
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// RedactRelease returns a copy of rel where the values its chart marks as
+// sensitive are redacted from the user-supplied values and the chart
+// defaults. Occurrences of sensitive strings are also redacted from the
+// description, notes and manifests of the release.
+//
+// The copy shares the rest of its fields with rel.
+func RedactRelease(rel *release.Release) *release.Release {
+	if rel == nil || rel.Chart == nil {
+		return rel
+	}
+	secrets := chartutil.SensitiveStrings(rel.Chart, rel.Config)
+	secrets = append(secrets, chartutil.SensitiveStrings(rel.Chart, rel.Chart.Values)...)
+
+	out := *rel
+	out.Config = chartutil.RedactValues(rel.Chart, rel.Config)
+	out.Manifest = chartutil.RedactString(rel.Manifest, secrets)
+
+	ch := *rel.Chart
+	ch.Values = chartutil.RedactValues(rel.Chart, rel.Chart.Values)
+	out.Chart = &ch
+
+	if rel.Info != nil {
+		info := *rel.Info
+		info.Description = chartutil.RedactString(info.Description, secrets)
+		info.Notes = chartutil.RedactString(info.Notes, secrets)
+		out.Info = &info
+	}
+
+	if len(secrets) > 0 {
+		out.Hooks = make([]*release.Hook, len(rel.Hooks))
+		for i, h := range rel.Hooks {
+			hook := *h
+			hook.Manifest = chartutil.RedactString(h.Manifest, secrets)
+			out.Hooks[i] = &hook
+		}
+	}
+	return &out
+}
+
+// checkHashedValues fails when the values to render for ch hold values of a
+// previous release that were only stored as a hash.
+func checkHashedValues(ch *chart.Chart, vals map[string]interface{}) error {
+	effective, err := chartutil.CoalesceValues(ch, vals)
+	if err != nil {
+		return err
+	}
+	if paths := chartutil.HashedValuePaths(effective); len(paths) > 0 {
+		return errors.Errorf("the previous release only stores a hash of %s; set them again to reuse its values", strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// hashSensitiveValues returns the values to store for the release name of ch,
+// given the user-supplied vals. Dry runs hash with a throwaway key rather than
+// creating the key of the release.
+func (cfg *Configuration) hashSensitiveValues(enabled, dryRun bool, ch *chart.Chart, name string, vals map[string]interface{}) (map[string]interface{}, error) {
+	if !enabled || len(chartutil.SensitivePaths(ch)) == 0 {
+		return vals, nil
+	}
+	var key []byte
+	var err error
+	switch {
+	case dryRun:
+		key, err = newSensitiveValuesKey()
+	case cfg.SensitiveValuesKeys == nil:
+		return nil, errors.New("no store is configured for the keys hashing sensitive values")
+	default:
+		key, err = cfg.SensitiveValuesKeys.Key(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chartutil.HashSensitiveValues(ch, vals, key), nil
+}
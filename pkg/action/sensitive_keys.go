@@ -0,0 +1,159 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// sensitiveValuesKeySize is the size in bytes of the keys hashing sensitive
+// values.
+const sensitiveValuesKeySize = 32
+
+// SensitiveValuesKeys stores the per-release keys used to hash the sensitive
+// values of releases. The keys are kept apart from the release records, so
+// that reading a release is not enough to guess its sensitive values from
+// their digests.
+type SensitiveValuesKeys interface {
+	// Key returns the key of the named release, creating it if needed.
+	Key(name string) ([]byte, error)
+	// Delete deletes the key of the named release, if any.
+	Delete(name string) error
+}
+
+// SecretSensitiveValuesKeys stores the keys of releases in Kubernetes Secrets,
+// one per release, whatever the storage driver of the releases.
+type SecretSensitiveValuesKeys struct {
+	impl corev1.SecretInterface
+}
+
+// NewSecretSensitiveValuesKeys initializes a new SecretSensitiveValuesKeys
+// wrapping an implementation of the kubernetes SecretsInterface.
+func NewSecretSensitiveValuesKeys(impl corev1.SecretInterface) *SecretSensitiveValuesKeys {
+	return &SecretSensitiveValuesKeys{impl: impl}
+}
+
+// Key returns the key of the named release, creating its Secret if needed.
+func (s *SecretSensitiveValuesKeys) Key(name string) ([]byte, error) {
+	secretName := sensitiveValuesKeySecretName(name)
+	secret, err := s.impl.Get(context.Background(), secretName, metav1.GetOptions{})
+	if err == nil {
+		return secretKey(secret)
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "unable to get the sensitive values key of %q", name)
+	}
+
+	key, err := newSensitiveValuesKey()
+	if err != nil {
+		return nil, err
+	}
+	secret = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: secretName,
+			Labels: map[string]string{
+				"owner": "helm",
+				"name":  name,
+			},
+		},
+		Type: "helm.sh/sensitive-values-key",
+		Data: map[string][]byte{"key": key},
+	}
+	if _, err := s.impl.Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, errors.Wrapf(err, "unable to create the sensitive values key of %q", name)
+		}
+		// Another client created the key first.
+		if secret, err = s.impl.Get(context.Background(), secretName, metav1.GetOptions{}); err != nil {
+			return nil, errors.Wrapf(err, "unable to get the sensitive values key of %q", name)
+		}
+		return secretKey(secret)
+	}
+	return key, nil
+}
+
+// Delete deletes the Secret holding the key of the named release, if any.
+func (s *SecretSensitiveValuesKeys) Delete(name string) error {
+	err := s.impl.Delete(context.Background(), sensitiveValuesKeySecretName(name), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete the sensitive values key of %q", name)
+	}
+	return nil
+}
+
+func sensitiveValuesKeySecretName(name string) string {
+	return "sh.helm.sensitive-values.v1." + name
+}
+
+func secretKey(secret *v1.Secret) ([]byte, error) {
+	key := secret.Data["key"]
+	if len(key) == 0 {
+		return nil, errors.Errorf("secret %q holds no key", secret.Name)
+	}
+	return key, nil
+}
+
+// MemorySensitiveValuesKeys stores the keys of releases in memory. It goes
+// with the memory storage driver.
+type MemorySensitiveValuesKeys struct {
+	sync.Mutex
+	keys map[string][]byte
+}
+
+// NewMemorySensitiveValuesKeys initializes a new MemorySensitiveValuesKeys.
+func NewMemorySensitiveValuesKeys() *MemorySensitiveValuesKeys {
+	return &MemorySensitiveValuesKeys{keys: make(map[string][]byte)}
+}
+
+// Key returns the key of the named release, creating it if needed.
+func (m *MemorySensitiveValuesKeys) Key(name string) ([]byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	if key, ok := m.keys[name]; ok {
+		return key, nil
+	}
+	key, err := newSensitiveValuesKey()
+	if err != nil {
+		return nil, err
+	}
+	m.keys[name] = key
+	return key, nil
+}
+
+// Delete deletes the key of the named release, if any.
+func (m *MemorySensitiveValuesKeys) Delete(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.keys, name)
+	return nil
+}
+
+func newSensitiveValuesKey() ([]byte, error) {
+	key := make([]byte, sensitiveValuesKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "unable to generate a sensitive values key")
+	}
+	return key, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretSensitiveValuesKeys(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	secrets := fakeclientset.NewSimpleClientset().CoreV1().Secrets("default")
+	keys := NewSecretSensitiveValuesKeys(secrets)
+
+	key, err := keys.Key("myrelease")
+	req.NoError(err)
+	is.Len(key, sensitiveValuesKeySize)
+
+	secret, err := secrets.Get(context.Background(), "sh.helm.sensitive-values.v1.myrelease", metav1.GetOptions{})
+	req.NoError(err)
+	is.Equal(key, secret.Data["key"])
+	is.Equal("myrelease", secret.Labels["name"])
+
+	again, err := keys.Key("myrelease")
+	req.NoError(err)
+	is.Equal(key, again, "the key of a release must be stable")
+
+	other, err := keys.Key("otherrelease")
+	req.NoError(err)
+	is.NotEqual(key, other, "releases must have their own key")
+
+	req.NoError(keys.Delete("myrelease"))
+	req.NoError(keys.Delete("myrelease"), "deleting a missing key must not fail")
+	_, err = secrets.Get(context.Background(), "sh.helm.sensitive-values.v1.myrelease", metav1.GetOptions{})
+	is.Error(err)
+}
@@ -182,6 +182,9 @@ func (u *Uninstall) purgeReleases(rels ...*release.Release) error {
 			return err
 		}
 	}
+	if len(rels) > 0 && u.cfg.SensitiveValuesKeys != nil {
+		return u.cfg.SensitiveValuesKeys.Delete(rels[0].Name)
+	}
 	return nil
 }
 
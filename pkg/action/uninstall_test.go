@@ -138,3 +138,22 @@ func TestUninstallRelease_Cascade(t *testing.T) {
 	is.Error(err)
 	is.Contains(err.Error(), "failed to delete release: come-fail-away")
 }
+
+func TestUninstallRelease_DeletesSensitiveValuesKey(t *testing.T) {
+	is := assert.New(t)
+
+	unAction := uninstallAction(t)
+	unAction.DisableHooks = true
+
+	rel := releaseStub()
+	rel.Name = "hashed"
+	unAction.cfg.Releases.Create(rel)
+	key, err := unAction.cfg.SensitiveValuesKeys.Key(rel.Name)
+	is.NoError(err)
+
+	_, err = unAction.Run(rel.Name)
+	is.NoError(err)
+	newKey, err := unAction.cfg.SensitiveValuesKeys.Key(rel.Name)
+	is.NoError(err)
+	is.NotEqual(key, newKey, "the key must be deleted with the release")
+}
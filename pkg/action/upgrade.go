@@ -87,6 +87,12 @@ type Upgrade struct {
 	ReuseValues bool
 	// ResetThenReuseValues will reset the values to the chart's built-ins then merge with user's last supplied values.
 	ResetThenReuseValues bool
+	// HashSensitiveValues stores a keyed hash of the values the chart marks as
+	// sensitive instead of the values themselves.
+	HashSensitiveValues bool
+	// MigratedValues lists the changes the values migrations of the chart made
+	// to the values of the previous release. It is set by Run.
+	MigratedValues []chartutil.MigratedValue
 	// Recreate will (if true) recreate pods after a rollback.
 	Recreate bool
 	// MaxHistory limits the maximum number of revisions saved per release
@@ -237,11 +243,20 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		}
 	}
 
+	// migrate the values of the current release to the new chart version
+	migrations, err := u.valuesMigrations(chart, currentRelease)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// determine if values will be reused
-	vals, err = u.reuseValues(chart, currentRelease, vals)
+	vals, err = u.reuseValues(chart, currentRelease, vals, migrations)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := checkHashedValues(chart, vals); err != nil {
+		return nil, nil, err
+	}
 
 	if err := chartutil.ProcessDependenciesWithMerge(chart, vals); err != nil {
 		return nil, nil, err
@@ -251,11 +266,17 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 	// the release object.
 	revision := lastRelease.Version + 1
 
+	previous, err := previousRelease(lastRelease)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	options := chartutil.ReleaseOptions{
 		Name:      name,
 		Namespace: currentRelease.Namespace,
 		Revision:  revision,
 		IsUpgrade: true,
+		Previous:  previous,
 	}
 
 	caps, err := u.cfg.getCapabilities()
@@ -282,12 +303,17 @@ func (u *Upgrade) prepareUpgrade(name string, chart *chart.Chart, vals map[strin
 		return nil, nil, fmt.Errorf("user suplied labels contains system reserved label name. System labels: %+v", driver.GetSystemLabels())
 	}
 
+	config, err := u.cfg.hashSensitiveValues(u.HashSensitiveValues, u.isDryRun(), chart, name, vals)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Store an upgraded release.
 	upgradedRelease := &release.Release{
 		Name:      name,
 		Namespace: currentRelease.Namespace,
 		Chart:     chart,
-		Config:    vals,
+		Config:    config,
 		Info: &release.Info{
 			FirstDeployed: currentRelease.Info.FirstDeployed,
 			LastDeployed:  Timestamper(),
@@ -549,26 +575,45 @@ func (u *Upgrade) failRelease(rel *release.Release, created kube.ResourceList, e
 //
 // This is skipped if the u.ResetValues flag is set, in which case the
 // request values are not altered.
-func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newVals map[string]interface{}) (map[string]interface{}, error) {
+func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newVals map[string]interface{}, migrations []*chartutil.ValuesMigration) (map[string]interface{}, error) {
+	u.MigratedValues = nil
 	if u.ResetValues {
 		// If ResetValues is set, we completely ignore current.Config.
 		u.cfg.Log("resetting values to the chart's original version")
 		return newVals, nil
 	}
 
+	config, changes, err := chartutil.MigrateValues(current.Config, migrations)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to migrate old values")
+	}
+	// The changes are only reported when the old values are used.
+	useMigratedValues := func() {
+		for _, c := range changes {
+			u.cfg.Log("migrated values: %s", c)
+		}
+		u.MigratedValues = changes
+	}
+
 	// If the ReuseValues flag is set, we always copy the old values over the new config's values.
 	if u.ReuseValues {
 		u.cfg.Log("reusing the old release's values")
+		useMigratedValues()
 
 		// We have to regenerate the old coalesced values:
 		oldVals, err := chartutil.CoalesceValues(current.Chart, current.Config)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to rebuild old values")
 		}
+		// The defaults of the old chart need migrating as well.
+		migratedVals, _, err := chartutil.MigrateValues(oldVals, migrations)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to migrate old values")
+		}
 
-		newVals = chartutil.CoalesceTables(newVals, current.Config)
+		newVals = chartutil.ChartMergeKeys(chart).CoalesceTables(newVals, config)
 
-		chart.Values = oldVals
+		chart.Values = migratedVals
 
 		return newVals, nil
 	}
@@ -576,19 +621,31 @@ func (u *Upgrade) reuseValues(chart *chart.Chart, current *release.Release, newV
 	// If the ResetThenReuseValues flag is set, we use the new chart's values, but we copy the old config's values over the new config's values.
 	if u.ResetThenReuseValues {
 		u.cfg.Log("merging values from old release to new values")
+		useMigratedValues()
 
-		newVals = chartutil.CoalesceTables(newVals, current.Config)
+		newVals = chartutil.ChartMergeKeys(chart).CoalesceTables(newVals, config)
 
 		return newVals, nil
 	}
 
-	if len(newVals) == 0 && len(current.Config) > 0 {
+	if len(newVals) == 0 && len(config) > 0 {
 		u.cfg.Log("copying values from %s (v%d) to new release.", current.Name, current.Version)
-		newVals = current.Config
+		useMigratedValues()
+		newVals = config
 	}
 	return newVals, nil
 }
 
+// valuesMigrations returns the values migrations of chart needed to upgrade
+// from the chart of the current release.
+func (u *Upgrade) valuesMigrations(chart *chart.Chart, current *release.Release) ([]*chartutil.ValuesMigration, error) {
+	if current.Chart == nil || current.Chart.Metadata == nil || current.Chart.Name() != chart.Name() {
+		return nil, nil
+	}
+	migrations, err := chartutil.ValuesMigrations(chart, current.Chart.Metadata.Version)
+	return migrations, errors.Wrap(err, "unable to load the values migrations of the chart")
+}
+
 func validateManifest(c kube.Interface, manifest []byte, openAPIValidation bool) error {
 	_, err := c.Build(bytes.NewReader(manifest), openAPIValidation)
 	return err
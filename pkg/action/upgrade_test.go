@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
 
 	"github.com/stretchr/testify/assert"
@@ -69,6 +70,22 @@ func TestUpgradeRelease_Success(t *testing.T) {
 	is.Equal(lastRelease.Info.Status, release.StatusDeployed)
 }
 
+func TestUpgradeRelease_Previous(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	rel := releaseStub()
+	rel.Name = "previous"
+	rel.Version = 2
+	rel.Info.Status = release.StatusDeployed
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	res, err := upAction.Run(rel.Name, buildChart(withPreviousReleaseTemplate()), map[string]interface{}{})
+	req.NoError(err)
+	is.Contains(res.Manifest, `previous: "value-0.1.0-2"`)
+}
+
 func TestUpgradeRelease_Wait(t *testing.T) {
 	is := assert.New(t)
 	req := require.New(t)
@@ -190,6 +207,112 @@ func TestUpgradeRelease_Atomic(t *testing.T) {
 	})
 }
 
+func TestUpgradeRelease_HashedValues(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	upAction := upgradeAction(t)
+	key, err := upAction.cfg.SensitiveValuesKeys.Key("hashed")
+	req.NoError(err)
+
+	rel := releaseStub()
+	rel.Name = "hashed"
+	rel.Info.Status = release.StatusDeployed
+	rel.Chart = buildChart(withSensitiveValues("password"))
+	rel.Config = chartutil.HashSensitiveValues(rel.Chart, map[string]interface{}{"password": "hunter22", "user": "admin"}, key)
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	upAction.ReuseValues = true
+	_, err = upAction.Run(rel.Name, buildChart(withSensitiveValues("password")), map[string]interface{}{})
+	is.Error(err)
+	is.Contains(err.Error(), "only stores a hash of password")
+
+	upAction.HashSensitiveValues = true
+	res, err := upAction.Run(rel.Name, buildChart(withSensitiveValues("password")), map[string]interface{}{"password": "hunter22"})
+	req.NoError(err)
+	is.Equal("admin", res.Config["user"])
+	is.Equal(rel.Config["password"], res.Config["password"], "an unchanged value must keep its digest")
+
+	res, err = upAction.Run(rel.Name, buildChart(withSensitiveValues("password")), map[string]interface{}{"password": "hunter23"})
+	req.NoError(err)
+	is.Equal([]string{"password"}, chartutil.HashedValuePaths(res.Config))
+	is.NotEqual(rel.Config["password"], res.Config["password"], "a changed value must change its digest")
+}
+
+func TestUpgradeRelease_MigratedValues(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	migration := withFile("migrations/2.0.0.yaml", `version: 2.0.0
+operations:
+  - op: move
+    path: image.name
+    to: image.repository
+  - op: delete
+    path: legacy
+`)
+
+	rel := releaseStub()
+	rel.Name = "migrated"
+	rel.Info.Status = release.StatusDeployed
+	rel.Chart = buildChart(withVersion("1.2.0"), withValues(map[string]interface{}{
+		"image": map[string]interface{}{"name": "nginx"},
+	}))
+	rel.Config = map[string]interface{}{
+		"image":    map[string]interface{}{"name": "httpd"},
+		"legacy":   true,
+		"replicas": 2,
+	}
+
+	upAction := upgradeAction(t)
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	upAction.ReuseValues = true
+	res, err := upAction.Run(rel.Name, buildChart(withVersion("2.1.0"), migration), map[string]interface{}{})
+	req.NoError(err)
+	is.Equal(map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "httpd"},
+		"replicas": 2,
+	}, res.Config)
+	is.Equal(map[string]interface{}{"repository": "httpd"}, res.Chart.Values["image"], "the defaults of the old chart must be migrated")
+	is.Equal([]chartutil.MigratedValue{
+		{Version: "2.0.0", Op: chartutil.MigrationMove, Path: "image.name", To: "image.repository"},
+		{Version: "2.0.0", Op: chartutil.MigrationDelete, Path: "legacy"},
+	}, upAction.MigratedValues)
+	is.Equal(map[string]interface{}{"name": "httpd"}, rel.Config["image"], "the previous release must not be changed")
+
+	// The migration is not applied again from 2.1.0.
+	upAction.ReuseValues = false
+	_, err = upAction.Run(rel.Name, buildChart(withVersion("2.2.0"), migration), map[string]interface{}{})
+	req.NoError(err)
+	is.Empty(upAction.MigratedValues)
+}
+
+func TestUpgradeRelease_MigratedValuesWithNewValues(t *testing.T) {
+	is := assert.New(t)
+	req := require.New(t)
+
+	rel := releaseStub()
+	rel.Name = "migrated"
+	rel.Info.Status = release.StatusDeployed
+	rel.Chart = buildChart(withVersion("1.2.0"))
+	rel.Config = map[string]interface{}{"legacy": true, "replicas": 2}
+
+	upAction := upgradeAction(t)
+	req.NoError(upAction.cfg.Releases.Create(rel))
+
+	// The old values are not used, so the migrations are not applied.
+	migration := withFile("migrations/2.0.0.yaml", `version: 2.0.0
+operations:
+  - op: delete
+    path: legacy
+`)
+	res, err := upAction.Run(rel.Name, buildChart(withVersion("2.0.0"), migration), map[string]interface{}{"replicas": 3})
+	req.NoError(err)
+	is.Equal(map[string]interface{}{"replicas": 3}, res.Config)
+	is.Empty(upAction.MigratedValues)
+}
+
 func TestUpgradeRelease_ReuseValues(t *testing.T) {
 	is := assert.New(t)
 
@@ -238,6 +361,39 @@ func TestUpgradeRelease_ReuseValues(t *testing.T) {
 		is.Equal(expectedValues, updatedRes.Config)
 	})
 
+	t.Run("reuse values should merge lists by key", func(t *testing.T) {
+		upAction := upgradeAction(t)
+
+		rel := releaseStub()
+		rel.Name = "nuketown"
+		rel.Info.Status = release.StatusDeployed
+		rel.Config = map[string]interface{}{
+			"env": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+				map[string]interface{}{"name": "REGION", "value": "eu"},
+			},
+		}
+		is.NoError(upAction.cfg.Releases.Create(rel))
+
+		upAction.ReuseValues = true
+		newValues := map[string]interface{}{
+			"env": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+			},
+		}
+		res, err := upAction.Run(rel.Name, buildChart(withMergeKeys("env=name")), newValues)
+		is.NoError(err)
+
+		updatedRes, err := upAction.cfg.Releases.Get(res.Name, 2)
+		is.NoError(err)
+		is.Equal(map[string]interface{}{
+			"env": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+				map[string]interface{}{"name": "REGION", "value": "eu"},
+			},
+		}, updatedRes.Config)
+	})
+
 	t.Run("reuse values should not install disabled charts", func(t *testing.T) {
 		upAction := upgradeAction(t)
 		chartDefaultValues := map[string]interface{}{
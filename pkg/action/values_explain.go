@@ -0,0 +1,54 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// ValuesExplain is the action for explaining where the values of a chart come from.
+//
+// It provides the implementation of 'helm values explain'.
+type ValuesExplain struct {
+	ChartPathOptions
+	Devel bool
+}
+
+// NewValuesExplain creates a new ValuesExplain object with the given configuration.
+func NewValuesExplain(cfg *Configuration) *ValuesExplain {
+	v := &ValuesExplain{}
+	v.ChartPathOptions.registryClient = cfg.RegistryClient
+	return v
+}
+
+// SetRegistryClient sets the registry client to use when pulling a chart from a registry.
+func (v *ValuesExplain) SetRegistryClient(client *registry.Client) {
+	v.ChartPathOptions.registryClient = client
+}
+
+// Run computes the values of chrt given the user-supplied vals, merged from
+// sources, and tells where each of them was set.
+func (v *ValuesExplain) Run(chrt *chart.Chart, vals map[string]interface{}, sources []chartutil.UserValues) ([]chartutil.ExplainedValue, error) {
+	if err := CheckDependencies(chrt, chrt.Metadata.Dependencies); err != nil {
+		return nil, errors.Wrap(err, "An error occurred while checking for chart dependencies. You may need to run `helm dependency build` to fetch missing dependencies")
+	}
+	return chartutil.ExplainValues(chrt, vals, sources)
+}
@@ -18,9 +18,13 @@ package action
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
+	"golang.org/x/crypto/ssh"
+
 	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/provenance"
 )
 
 // Verify is the action for building a given chart's Verify tree.
@@ -44,11 +48,7 @@ func (v *Verify) Run(chartfile string) error {
 		return err
 	}
 
-	for name := range p.SignedBy.Identities {
-		fmt.Fprintf(&out, "Signed by: %v\n", name)
-	}
-	fmt.Fprintf(&out, "Using Key With Fingerprint: %X\n", p.SignedBy.PrimaryKey.Fingerprint)
-	fmt.Fprintf(&out, "Chart Hash Verified: %s\n", p.FileHash)
+	writeVerification(&out, p)
 
 	// TODO(mattfarina): The output is set as a property rather than returned
 	// to maintain the Go API. In Helm v4 this function should return the out
@@ -57,3 +57,19 @@ func (v *Verify) Run(chartfile string) error {
 
 	return nil
 }
+
+// writeVerification writes the signer and the hash of a verified chart to out.
+func writeVerification(out io.Writer, v *provenance.Verification) {
+	if v.SignedBySSH != nil {
+		for _, name := range v.SignedBySSH.Principals {
+			fmt.Fprintf(out, "Signed by: %v\n", name)
+		}
+		fmt.Fprintf(out, "Using Key With Fingerprint: %s\n", ssh.FingerprintSHA256(v.SignedBySSH.Key))
+	} else {
+		for name := range v.SignedBy.Identities {
+			fmt.Fprintf(out, "Signed by: %v\n", name)
+		}
+		fmt.Fprintf(out, "Using Key With Fingerprint: %X\n", v.SignedBy.PrimaryKey.Fingerprint)
+	}
+	fmt.Fprintf(out, "Chart Hash Verified: %s\n", v.FileHash)
+}
@@ -47,6 +47,11 @@ type Dependency struct {
 	ImportValues []interface{} `json:"import-values,omitempty"`
 	// Alias usable alias to be used for the chart
 	Alias string `json:"alias,omitempty"`
+	// Digest is the digest of the chart archive of the dependency.
+	//
+	// It is only recorded in lock files, for the dependencies fetched from
+	// repositories.
+	Digest string `json:"digest,omitempty"`
 }
 
 // Validate checks for common problems with the dependency datastructure in
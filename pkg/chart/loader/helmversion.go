@@ -0,0 +1,86 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+
+	"helm.sh/helm/v3/internal/version"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// HelmVersionError is returned for charts requiring another version of Helm.
+type HelmVersionError struct {
+	// Chart is the name of the chart, or the path of the dependency, requiring
+	// another version of Helm.
+	Chart string
+	// Constraint is the helmVersion of the chart.
+	Constraint string
+	// HelmVersion is the version of Helm checked.
+	HelmVersion string
+}
+
+func (e *HelmVersionError) Error() string {
+	return fmt.Sprintf("chart %s requires Helm %s, which is incompatible with Helm %s", e.Chart, e.Constraint, e.HelmVersion)
+}
+
+// CheckHelmVersion returns a *HelmVersionError if c or one of its dependencies
+// requires another version of Helm than this one.
+func CheckHelmVersion(c *chart.Chart) error {
+	return checkHelmVersion(c, version.GetVersion())
+}
+
+func checkHelmVersion(c *chart.Chart, helmVersion string) error {
+	if c.Metadata != nil && !IsHelmVersionCompatible(c.Metadata.HelmVersion, helmVersion) {
+		return &HelmVersionError{Chart: c.ChartFullPath(), Constraint: c.Metadata.HelmVersion, HelmVersion: helmVersion}
+	}
+	for _, dep := range c.Dependencies() {
+		if err := checkHelmVersion(dep, helmVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsHelmVersionCompatible returns whether helmVersion satisfies the helmVersion
+// constraint of a chart. An empty constraint is satisfied by any version.
+//
+// Pre-releases of Helm are considered to be the version they lead to, so that
+// they satisfy the constraints of charts requiring that version.
+func IsHelmVersionCompatible(constraint, helmVersion string) bool {
+	if constraint == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(helmVersion)
+	if err != nil {
+		return false
+	}
+	if v.Prerelease() != "" {
+		release, err := v.SetPrerelease("")
+		if err != nil {
+			return false
+		}
+		v = &release
+	}
+	return c.Check(v)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestIsHelmVersionCompatible(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		expect              bool
+	}{
+		{"", "v3.16", true},
+		{">=3.16.0", "v3.16", true},
+		{">=3.16.0", "v3.17.0+unreleased", true},
+		{">=3.17.0", "v3.17.0-rc.1", true},
+		{">=3.17.0", "v3.16.2", false},
+		{"^4.0.0", "v3.16", false},
+		{">= three", "v3.16", false},
+		{">=3.16.0", "main", false},
+	}
+	for _, tt := range tests {
+		if got := IsHelmVersionCompatible(tt.constraint, tt.version); got != tt.expect {
+			t.Errorf("IsHelmVersionCompatible(%q, %q) = %t, expected %t", tt.constraint, tt.version, got, tt.expect)
+		}
+	}
+}
+
+func TestCheckHelmVersion(t *testing.T) {
+	dep := &chart.Chart{Metadata: &chart.Metadata{Name: "dep", HelmVersion: ">=4.0.0"}}
+	parent := &chart.Chart{Metadata: &chart.Metadata{Name: "parent", HelmVersion: ">=3.0.0"}}
+	if err := checkHelmVersion(parent, "v3.16"); err != nil {
+		t.Errorf("Expected parent to be compatible, got %s", err)
+	}
+
+	parent.SetDependencies(dep)
+	err := checkHelmVersion(parent, "v3.16")
+	var versionErr *HelmVersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Expected a *HelmVersionError, got %v", err)
+	}
+	expect := "chart parent/charts/dep requires Helm >=4.0.0, which is incompatible with Helm v3.16"
+	if err.Error() != expect {
+		t.Errorf("Expected %q, got %q", expect, err)
+	}
+}
+
+func TestLoadHelmVersion(t *testing.T) {
+	dir := t.TempDir()
+	chartfile := "apiVersion: v2\nname: future\nversion: 1.0.0\nhelmVersion: \">=99.0.0\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var versionErr *HelmVersionError
+	if _, err := Load(dir); !errors.As(err, &versionErr) {
+		t.Errorf("Expected Load to fail with a *HelmVersionError, got %v", err)
+	}
+	if _, err := LoadDir(dir); err != nil {
+		t.Errorf("Expected LoadDir to load the chart, got %s", err)
+	}
+}
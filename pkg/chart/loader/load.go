@@ -54,12 +54,20 @@ func Loader(name string) (ChartLoader, error) {
 //
 // If a .helmignore file is present, the directory loader will skip loading any files
 // matching it. But .helmignore is not evaluated when reading out of an archive.
+//
+// Charts requiring another version of Helm, as declared by the helmVersion of
+// the chart or of one of its dependencies, fail to load with a
+// *HelmVersionError. Use LoadDir or LoadFile to inspect them regardless.
 func Load(name string) (*chart.Chart, error) {
 	l, err := Loader(name)
 	if err != nil {
 		return nil, err
 	}
-	return l.Load()
+	c, err := l.Load()
+	if err != nil {
+		return c, err
+	}
+	return c, CheckHelmVersion(c)
 }
 
 // BufferedFile represents an archive file buffered for later processing.
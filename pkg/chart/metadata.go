@@ -77,6 +77,8 @@ type Metadata struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// KubeVersion is a SemVer constraint specifying the version of Kubernetes required.
 	KubeVersion string `json:"kubeVersion,omitempty"`
+	// HelmVersion is a SemVer constraint specifying the version of Helm required.
+	HelmVersion string `json:"helmVersion,omitempty"`
 	// Dependencies are a list of dependencies for a chart.
 	Dependencies []*Dependency `json:"dependencies,omitempty"`
 	// Specifies the chart type: application or library
@@ -98,6 +100,7 @@ func (md *Metadata) Validate() error {
 	md.Tags = sanitizeString(md.Tags)
 	md.AppVersion = sanitizeString(md.AppVersion)
 	md.KubeVersion = sanitizeString(md.KubeVersion)
+	md.HelmVersion = sanitizeString(md.HelmVersion)
 	for i := range md.Sources {
 		md.Sources[i] = sanitizeString(md.Sources[i])
 	}
@@ -122,6 +125,9 @@ func (md *Metadata) Validate() error {
 	if !isValidSemver(md.Version) {
 		return ValidationErrorf("chart.metadata.version %q is invalid", md.Version)
 	}
+	if md.HelmVersion != "" && !isValidSemverConstraint(md.HelmVersion) {
+		return ValidationErrorf("chart.metadata.helmVersion %q is invalid", md.HelmVersion)
+	}
 	if !isValidChartType(md.Type) {
 		return ValidationError("chart.metadata.type must be application or library")
 	}
@@ -164,6 +170,11 @@ func isValidSemver(v string) bool {
 	return err == nil
 }
 
+func isValidSemverConstraint(c string) bool {
+	_, err := semver.NewConstraint(c)
+	return err == nil
+}
+
 // sanitizeString normalize spaces and removes non-printable characters.
 func sanitizeString(str string) string {
 	return strings.Map(func(r rune) rune {
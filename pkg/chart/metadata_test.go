@@ -177,6 +177,16 @@ func TestValidate(t *testing.T) {
 			&Metadata{APIVersion: "v2", Name: "test", Version: "1.2.3.4"},
 			ValidationError("chart.metadata.version \"1.2.3.4\" is invalid"),
 		},
+		{
+			"helmVersion invalid",
+			&Metadata{APIVersion: "v2", Name: "test", Version: "1.0", HelmVersion: ">= three"},
+			ValidationError("chart.metadata.helmVersion \">= three\" is invalid"),
+		},
+		{
+			"helmVersion valid",
+			&Metadata{APIVersion: "v2", Name: "test", Version: "1.0", HelmVersion: ">=3.16.0"},
+			nil,
+		},
 	}
 
 	for _, tt := range tests {
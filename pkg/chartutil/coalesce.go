@@ -40,6 +40,8 @@ func concatPrefix(a, b string) string {
 //   - Values in a higher level chart always override values in a lower-level
 //     dependency chart
 //   - Scalar values and arrays are replaced, maps are merged
+//   - Arrays with a merge key, declared in values.schema.json or Chart.yaml,
+//     are merged item by item
 //   - A chart has access to all of the variables for it, as well as all of
 //     the values destined for its dependencies.
 func CoalesceValues(chrt *chart.Chart, vals map[string]interface{}) (Values, error) {
@@ -47,7 +49,14 @@ func CoalesceValues(chrt *chart.Chart, vals map[string]interface{}) (Values, err
 	if err != nil {
 		return vals, err
 	}
-	return coalesce(log.Printf, chrt, valsCopy, "", false)
+	coalesced, err := coalesce(log.Printf, chrt, valsCopy, "", false)
+	if err != nil {
+		return coalesced, err
+	}
+	// Markers left in lists with no default items to delete are dropped too.
+	// The merge keys were already reported while coalescing.
+	stripDeleteMarkers(coalesced, "", chartMergeKeys(func(string, ...interface{}) {}, chrt, ""))
+	return coalesced, nil
 }
 
 // MergeValues is used to merge the values in a chart and its subcharts. This
@@ -58,6 +67,8 @@ func CoalesceValues(chrt *chart.Chart, vals map[string]interface{}) (Values, err
 //   - Values in a higher level chart always override values in a lower-level
 //     dependency chart
 //   - Scalar values and arrays are replaced, maps are merged
+//   - Arrays with a merge key, declared in values.schema.json or Chart.yaml,
+//     are merged item by item
 //   - A chart has access to all of the variables for it, as well as all of
 //     the values destined for its dependencies.
 //
@@ -166,7 +177,7 @@ func coalesceGlobals(printf printFn, dest, src map[string]interface{}, prefix st
 					// In this location coalesceTablesFullKey should always have
 					// merge set to true. The output of coalesceGlobals is run
 					// through coalesce where any nils will be removed.
-					coalesceTablesFullKey(printf, vv, destvmap, subPrefix, true)
+					coalesceTablesFullKey(printf, vv, destvmap, subPrefix, true, nil)
 					dg[key] = vv
 				}
 			}
@@ -194,6 +205,7 @@ func copyMap(src map[string]interface{}) map[string]interface{} {
 // Values in v will override the values in the chart.
 func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, prefix string, merge bool) {
 	subPrefix := concatPrefix(prefix, c.Metadata.Name)
+	keys := chartMergeKeys(printf, c, subPrefix)
 
 	// Using c.Values directly when coalescing a table can cause problems where
 	// the original c.Values is altered. Creating a deep copy stops the problem.
@@ -239,8 +251,10 @@ func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, pr
 				} else {
 					// Because v has higher precedence than nv, dest values override src
 					// values.
-					coalesceTablesFullKey(printf, dest, src, concatPrefix(subPrefix, key), merge)
+					coalesceTablesFullKey(printf, dest, src, concatPrefix(subPrefix, key), merge, keys)
 				}
+			} else if dest, src, mergeKey, ok := keys.lists(concatPrefix(subPrefix, key), value, val); ok {
+				v[key] = coalesceKeyedLists(printf, dest, src, mergeKey, concatPrefix(subPrefix, key), keys, merge)
 			}
 		} else {
 			// If the key is not in v, copy it from nv.
@@ -253,17 +267,18 @@ func coalesceValues(printf printFn, c *chart.Chart, v map[string]interface{}, pr
 //
 // dest is considered authoritative.
 func CoalesceTables(dst, src map[string]interface{}) map[string]interface{} {
-	return coalesceTablesFullKey(log.Printf, dst, src, "", false)
+	return coalesceTablesFullKey(log.Printf, dst, src, "", false, nil)
 }
 
 func MergeTables(dst, src map[string]interface{}) map[string]interface{} {
-	return coalesceTablesFullKey(log.Printf, dst, src, "", true)
+	return coalesceTablesFullKey(log.Printf, dst, src, "", true, nil)
 }
 
 // coalesceTablesFullKey merges a source map into a destination map.
 //
-// dest is considered authoritative.
-func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, prefix string, merge bool) map[string]interface{} {
+// dest is considered authoritative. Lists with a key in keys are merged item
+// by item.
+func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, prefix string, merge bool, keys MergeKeys) map[string]interface{} {
 	// When --reuse-values is set but there are no modifications yet, return new values
 	if src == nil {
 		return dst
@@ -281,10 +296,12 @@ func coalesceTablesFullKey(printf printFn, dst, src map[string]interface{}, pref
 			dst[key] = val
 		} else if istable(val) {
 			if istable(dv) {
-				coalesceTablesFullKey(printf, dv.(map[string]interface{}), val.(map[string]interface{}), fullkey, merge)
+				coalesceTablesFullKey(printf, dv.(map[string]interface{}), val.(map[string]interface{}), fullkey, merge, keys)
 			} else {
 				printf("warning: cannot overwrite table with non table for %s (%v)", fullkey, val)
 			}
+		} else if dl, sl, mergeKey, ok := keys.lists(fullkey, dv, val); ok {
+			dst[key] = coalesceKeyedLists(printf, dl, sl, mergeKey, fullkey, keys, merge)
 		} else if istable(dv) && val != nil {
 			printf("warning: destination for %s is a table. Ignoring non-table value (%v)", fullkey, val)
 		}
@@ -0,0 +1,327 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	goYaml "sigs.k8s.io/yaml/goyaml.v3"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// ValueSourceKind identifies where a value was set.
+type ValueSourceKind string
+
+const (
+	// SourceChart is the values.yaml file of the chart or of a dependency.
+	SourceChart ValueSourceKind = "chart"
+	// SourceImport is a value imported from a dependency with import-values.
+	SourceImport ValueSourceKind = "import"
+	// SourceFile is a values file passed with -f/--values.
+	SourceFile ValueSourceKind = "file"
+	// SourceFlag is a value passed with --set or one of its variants.
+	SourceFlag ValueSourceKind = "flag"
+)
+
+// ValueSource describes where a value was set.
+type ValueSource struct {
+	Kind ValueSourceKind `json:"kind"`
+	// Name is the file, relative to the chart for chart defaults, or the flag
+	// that set the value.
+	Name string `json:"name"`
+	// Line is the line of the key in the file, when known.
+	Line int `json:"line,omitempty"`
+	// Global is true when a subchart received the value from the globals of
+	// its parent.
+	Global bool `json:"global,omitempty"`
+}
+
+func (s ValueSource) String() string {
+	var str string
+	switch s.Kind {
+	case SourceImport:
+		str = "import-values in " + s.Name
+	case SourceChart, SourceFile:
+		str = s.Name
+		if s.Line > 0 {
+			str = fmt.Sprintf("%s:%d", str, s.Line)
+		}
+	default:
+		str = s.Name
+	}
+	if s.Global {
+		str += " (global)"
+	}
+	return str
+}
+
+// UserValues is a set of values supplied by the user, such as a values file
+// or a --set flag.
+type UserValues struct {
+	Source ValueSource
+	Values map[string]interface{}
+	// Data is the raw YAML of a values file, used to find line numbers.
+	Data []byte
+}
+
+// ExplainedValue is a leaf of the computed values along with where it was set.
+type ExplainedValue struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+	// Source is where the value was set. It is nil when it cannot be told.
+	Source *ValueSource `json:"source,omitempty"`
+	// Overridden lists the other sources setting the same key, from lowest
+	// to highest precedence.
+	Overridden []ValueSource `json:"overridden,omitempty"`
+	// Unknown is true when the user set a key that matches nothing in the
+	// defaults of the chart and its dependencies.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// ExplainValues computes the values of a chart like an install does, and
+// tells for every leaf where it was set.
+//
+// vals are the merged user-supplied values, and user the individual sources
+// they were merged from, in the order they were applied. Like an install, this
+// processes the dependencies of chrt, which alters it.
+func ExplainValues(chrt *chart.Chart, vals map[string]interface{}, user []UserValues) ([]ExplainedValue, error) {
+	e := &explainer{
+		chart:    chrt,
+		defaults: make(map[*chart.Chart]valuesDocument),
+		imported: make(map[*chart.Chart]map[string]interface{}),
+	}
+	for _, u := range user {
+		e.user = append(e.user, userDocument{source: u.Source, doc: newValuesDocument(u.Values, u.Data)})
+	}
+	if err := ProcessDependenciesWithMerge(chrt, vals); err != nil {
+		return nil, err
+	}
+	e.loadDefaults(chrt)
+
+	final, err := CoalesceValues(chrt, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	var explained []ExplainedValue
+	walkLeaves(final, nil, func(path []string, v interface{}) {
+		ev := e.explain(path, v)
+		// Skip the empty tables coalescing creates for subcharts and globals.
+		if m, ok := v.(map[string]interface{}); ok && len(m) == 0 && ev.Source == nil {
+			return
+		}
+		explained = append(explained, ev)
+	})
+	sort.Slice(explained, func(i, j int) bool { return explained[i].Path < explained[j].Path })
+	return explained, nil
+}
+
+type explainer struct {
+	chart *chart.Chart
+	user  []userDocument
+	// defaults are the values.yaml files of every chart.
+	defaults map[*chart.Chart]valuesDocument
+	// imported are the values of every chart once import-values are processed.
+	imported map[*chart.Chart]map[string]interface{}
+}
+
+type userDocument struct {
+	source ValueSource
+	doc    valuesDocument
+}
+
+// valuesDocument is a set of values and, when known, the YAML it was read from.
+type valuesDocument struct {
+	values map[string]interface{}
+	node   *goYaml.Node
+}
+
+func newValuesDocument(values map[string]interface{}, data []byte) valuesDocument {
+	d := valuesDocument{values: values}
+	if len(data) > 0 {
+		var n goYaml.Node
+		if goYaml.Unmarshal(data, &n) == nil && len(n.Content) > 0 {
+			d.node = n.Content[0]
+		}
+	}
+	return d
+}
+
+// lookup returns whether the document sets path and the line of its key.
+func (d valuesDocument) lookup(path []string) (bool, int) {
+	if _, ok := lookupPath(d.values, path); !ok {
+		return false, 0
+	}
+	n, line := d.node, 0
+	for _, key := range path {
+		if n == nil || n.Kind != goYaml.MappingNode {
+			return true, 0
+		}
+		var next *goYaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				line, next = n.Content[i].Line, n.Content[i+1]
+			}
+		}
+		n = next
+	}
+	return true, line
+}
+
+func lookupPath(values map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = values
+	for _, key := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// loadDefaults records the values.yaml file and the values of c and its
+// dependencies, once import-values are processed. When the chart was loaded
+// from files, its values.yaml tells apart the imported values.
+func (e *explainer) loadDefaults(c *chart.Chart) {
+	defaults := valuesDocument{values: c.Values}
+	for _, f := range c.Raw {
+		if f.Name != ValuesfileName {
+			continue
+		}
+		if values, err := ReadValues(f.Data); err == nil {
+			defaults = newValuesDocument(values, f.Data)
+		}
+	}
+	e.defaults[c] = defaults
+	e.imported[c] = c.Values
+	for _, dep := range c.Dependencies() {
+		e.loadDefaults(dep)
+	}
+}
+
+// explain describes a single leaf of the computed values.
+func (e *explainer) explain(path []string, v interface{}) ExplainedValue {
+	ev := ExplainedValue{Path: strings.Join(path, "."), Value: v}
+	defaults := e.chartSources(e.chart, path)
+	sources := e.sources(path, defaults)
+	if len(sources) > 0 {
+		last := sources[len(sources)-1]
+		ev.Source = &last
+		if len(sources) > 1 {
+			ev.Overridden = sources[:len(sources)-1]
+		}
+	}
+	ev.Unknown = len(defaults) == 0 && !e.isGlobal(path) && len(sources) > 0
+	return ev
+}
+
+// sources returns the sources setting path, from lowest to highest
+// precedence, given the chart defaults setting it.
+func (e *explainer) sources(path []string, defaults []ValueSource) []ValueSource {
+	sources := append([]ValueSource{}, defaults...)
+	for _, u := range e.user {
+		if ok, line := u.doc.lookup(path); ok {
+			s := u.source
+			s.Line = line
+			sources = append(sources, s)
+		}
+	}
+	// The globals of a parent chart override those of its subcharts.
+	if parent := e.parentGlobalPath(path); parent != nil {
+		for _, s := range e.sources(parent, e.chartSources(e.chart, parent)) {
+			s.Global = true
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+// chartSources returns the defaults of c and its dependencies setting path,
+// from lowest to highest precedence.
+func (e *explainer) chartSources(c *chart.Chart, path []string) []ValueSource {
+	var sources []ValueSource
+	dep := dependencyNamed(c, path[0])
+	if dep != nil && len(path) > 1 {
+		sources = e.chartSources(dep, path[1:])
+	}
+
+	name := ValuesfileName
+	if !c.IsRoot() {
+		name = strings.TrimPrefix(c.ChartFullPath(), e.chart.Name()+"/") + "/" + ValuesfileName
+	}
+	if ok, line := e.defaults[c].lookup(path); ok {
+		sources = append(sources, ValueSource{Kind: SourceChart, Name: name, Line: line})
+	} else if _, ok := lookupPath(e.imported[c], path); ok && dep == nil {
+		sources = append(sources, ValueSource{Kind: SourceImport, Name: strings.TrimSuffix(name, ValuesfileName) + "Chart.yaml"})
+	}
+	return sources
+}
+
+// parentGlobalPath returns, for a global value of a subchart, the path of the
+// same global value in its parent chart.
+func (e *explainer) parentGlobalPath(path []string) []string {
+	c := e.chart
+	for i, key := range path {
+		if key == GlobalKey {
+			if i == 0 {
+				return nil
+			}
+			// path[:i] are subcharts; drop the last one.
+			return append(append([]string{}, path[:i-1]...), path[i:]...)
+		}
+		if c = dependencyNamed(c, key); c == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (e *explainer) isGlobal(path []string) bool {
+	for _, key := range path {
+		if key == GlobalKey {
+			return true
+		}
+	}
+	return false
+}
+
+func dependencyNamed(c *chart.Chart, name string) *chart.Chart {
+	for _, dep := range c.Dependencies() {
+		if dep.Name() == name {
+			return dep
+		}
+	}
+	return nil
+}
+
+// walkLeaves calls fn for every value of vals that is not a non-empty table.
+func walkLeaves(vals map[string]interface{}, path []string, fn func([]string, interface{})) {
+	for k, v := range vals {
+		p := append(append([]string{}, path...), k)
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			walkLeaves(m, p, fn)
+			continue
+		}
+		fn(p, v)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func explainTestChart(t *testing.T, name, values string) *chart.Chart {
+	t.Helper()
+	vals, err := ReadValues([]byte(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: "0.1.0"},
+		Values:   vals,
+		Raw:      []*chart.File{{Name: ValuesfileName, Data: []byte(values)}},
+	}
+}
+
+func TestExplainValues(t *testing.T) {
+	parent := explainTestChart(t, "parent", "replicas: 1\nimage:\n  tag: stable\nglobal:\n  region: eu\n")
+	sub := explainTestChart(t, "sub", "port: 80\nglobal:\n  region: us\n")
+	parent.AddDependency(sub)
+
+	userFile := "image:\n  tag: \"1.0\"\nreplica: 3\n"
+	user := []UserValues{
+		{
+			Source: ValueSource{Kind: SourceFile, Name: "prod.yaml"},
+			Values: map[string]interface{}{"image": map[string]interface{}{"tag": "1.0"}, "replica": 3},
+			Data:   []byte(userFile),
+		},
+		{
+			Source: ValueSource{Kind: SourceFlag, Name: "--set sub.port=8080"},
+			Values: map[string]interface{}{"sub": map[string]interface{}{"port": 8080}},
+		},
+	}
+	vals := map[string]interface{}{
+		"image":   map[string]interface{}{"tag": "1.0"},
+		"replica": 3,
+		"sub":     map[string]interface{}{"port": 8080},
+	}
+
+	explained, err := ExplainValues(parent, vals, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []ExplainedValue{
+		{Path: "global.region", Value: "eu", Source: &ValueSource{Kind: SourceChart, Name: "values.yaml", Line: 5}},
+		{
+			Path:       "image.tag",
+			Value:      "1.0",
+			Source:     &ValueSource{Kind: SourceFile, Name: "prod.yaml", Line: 2},
+			Overridden: []ValueSource{{Kind: SourceChart, Name: "values.yaml", Line: 3}},
+		},
+		{Path: "replica", Value: 3, Source: &ValueSource{Kind: SourceFile, Name: "prod.yaml", Line: 3}, Unknown: true},
+		{Path: "replicas", Value: 1.0, Source: &ValueSource{Kind: SourceChart, Name: "values.yaml", Line: 1}},
+		{
+			Path:       "sub.global.region",
+			Value:      "eu",
+			Source:     &ValueSource{Kind: SourceChart, Name: "values.yaml", Line: 5, Global: true},
+			Overridden: []ValueSource{{Kind: SourceChart, Name: "charts/sub/values.yaml", Line: 3}},
+		},
+		{
+			Path:       "sub.port",
+			Value:      8080,
+			Source:     &ValueSource{Kind: SourceFlag, Name: "--set sub.port=8080"},
+			Overridden: []ValueSource{{Kind: SourceChart, Name: "charts/sub/values.yaml", Line: 1}},
+		},
+	}
+	if !reflect.DeepEqual(explained, expect) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expect, explained)
+	}
+}
+
+func TestValueSourceString(t *testing.T) {
+	tests := map[string]ValueSource{
+		"values.yaml:3":                          {Kind: SourceChart, Name: "values.yaml", Line: 3},
+		"prod.yaml":                              {Kind: SourceFile, Name: "prod.yaml"},
+		"--set a=b":                              {Kind: SourceFlag, Name: "--set a=b"},
+		"import-values in charts/sub/Chart.yaml": {Kind: SourceImport, Name: "charts/sub/Chart.yaml"},
+		"values.yaml:1 (global)":                 {Kind: SourceChart, Name: "values.yaml", Line: 1, Global: true},
+	}
+	for expect, s := range tests {
+		if got := s.String(); got != expect {
+			t.Errorf("Expected %q, got %q", expect, got)
+		}
+	}
+}
@@ -32,7 +32,7 @@ import (
 func ValidateAgainstSchema(chrt *chart.Chart, values map[string]interface{}) error {
 	var sb strings.Builder
 	if chrt.Schema != nil {
-		err := ValidateAgainstSingleSchema(values, chrt.Schema)
+		err := ValidateAgainstChartSchema(chrt, values)
 		if err != nil {
 			sb.WriteString(fmt.Sprintf("%s:\n", chrt.Name()))
 			sb.WriteString(err.Error())
@@ -54,8 +54,23 @@ func ValidateAgainstSchema(chrt *chart.Chart, values map[string]interface{}) err
 	return nil
 }
 
+// ValidateAgainstChartSchema checks that values does not violate the structure
+// laid out in the schema of the given chart, without looking at its dependencies.
+//
+// References from the schema to other JSON files are resolved against the
+// files of the chart and of the charts around it, so that 'schemas/common.json'
+// or 'charts/library/schemas/common.json' refer to files shipped with the
+// chart and its dependencies.
+func ValidateAgainstChartSchema(chrt *chart.Chart, values Values) error {
+	return validateAgainstSchema(values, chrt.Schema, schemaURL(chrt, SchemafileName), chartSchemaFiles(chrt.Root()))
+}
+
 // ValidateAgainstSingleSchema checks that values does not violate the structure laid out in this schema
-func ValidateAgainstSingleSchema(values Values, schemaJSON []byte) (reterr error) {
+func ValidateAgainstSingleSchema(values Values, schemaJSON []byte) error {
+	return validateAgainstSchema(values, schemaJSON, schemaURL(nil, SchemafileName), nil)
+}
+
+func validateAgainstSchema(values Values, schemaJSON []byte, base string, files map[string][]byte) (reterr error) {
 	defer func() {
 		if r := recover(); r != nil {
 			reterr = fmt.Errorf("unable to validate schema: %s", r)
@@ -73,10 +88,16 @@ func ValidateAgainstSingleSchema(values Values, schemaJSON []byte) (reterr error
 	if bytes.Equal(valuesJSON, []byte("null")) {
 		valuesJSON = []byte("{}")
 	}
-	schemaLoader := gojsonschema.NewBytesLoader(schemaJSON)
-	valuesLoader := gojsonschema.NewBytesLoader(valuesJSON)
-
-	result, err := gojsonschema.Validate(schemaLoader, valuesLoader)
+	factory := &schemaLoaderFactory{files: files}
+	schemaLoader, err := factory.root(schemaJSON, base)
+	if err != nil {
+		return err
+	}
+	schema, err := gojsonschema.NewSchemaLoader().Compile(schemaLoader)
+	if err != nil {
+		return err
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(valuesJSON))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,245 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	goYaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// SchemaAnnotationPrefix starts a values.yaml comment line that annotates the
+// key below it, or the key on the same line, for schema generation.
+//
+// The rest of the line is a YAML mapping with a single keyword, for example:
+//
+//	# @schema description: Number of pods to run
+//	# @schema minimum: 1
+//	replicaCount: 1
+//	pullPolicy: IfNotPresent # @schema enum: [Always, IfNotPresent, Never]
+const SchemaAnnotationPrefix = "@schema"
+
+// schemaDraft is the JSON Schema draft of generated schemas.
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaKeywords are the keywords allowed in @schema annotations, in addition
+// to 'required'.
+var schemaKeywords = map[string]bool{
+	"description":      true,
+	"enum":             true,
+	"type":             true,
+	"format":           true,
+	"pattern":          true,
+	"minimum":          true,
+	"maximum":          true,
+	"exclusiveMinimum": true,
+	"exclusiveMaximum": true,
+	"minLength":        true,
+	"maxLength":        true,
+	"minItems":         true,
+	"maxItems":         true,
+	SchemaMergeKey:     true,
+	SchemaSensitive:    true,
+}
+
+// GenerateSchema infers a JSON Schema from the contents of a values.yaml file.
+//
+// The type of every key is taken from its value. Comment lines starting with
+// SchemaAnnotationPrefix add keywords to the schema of the annotated key, and
+// '@schema required: true' lists the key as required by its parent.
+func GenerateSchema(data []byte) ([]byte, error) {
+	var doc goYaml.Node
+	if err := goYaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "unable to parse values")
+	}
+
+	schema := map[string]interface{}{"type": "object"}
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind != goYaml.MappingNode {
+			if root.Tag != "!!null" {
+				return nil, errors.New("values must be a YAML mapping")
+			}
+		} else {
+			s, err := nodeSchema(root)
+			if err != nil {
+				return nil, err
+			}
+			schema = s
+		}
+	}
+	schema["$schema"] = schemaDraft
+
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// SchemaEqual reports whether two JSON Schema documents are semantically
+// identical, ignoring formatting.
+func SchemaEqual(a, b []byte) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb), nil
+}
+
+// nodeSchema infers the schema of a single YAML node.
+func nodeSchema(n *goYaml.Node) (map[string]interface{}, error) {
+	switch n.Kind {
+	case goYaml.AliasNode:
+		return nodeSchema(n.Alias)
+	case goYaml.MappingNode:
+		props := make(map[string]interface{})
+		merged := make(map[string]interface{})
+		var required []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			// Merge keys ('<<: *anchor' or '<<: [*a, *b]') pull in the keys of
+			// the anchored mappings.
+			if key.Tag == "!!merge" {
+				if err := mergedProperties(val, merged); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			s, err := nodeSchema(val)
+			if err != nil {
+				return nil, err
+			}
+			isRequired, err := applyAnnotations(s, key.Value, key.HeadComment, key.LineComment, val.LineComment)
+			if err != nil {
+				return nil, err
+			}
+			if isRequired {
+				required = append(required, key.Value)
+			}
+			props[key.Value] = s
+		}
+		// The keys of the mapping take precedence over the merged ones.
+		for k, v := range merged {
+			if _, ok := props[k]; !ok {
+				props[k] = v
+			}
+		}
+		s := map[string]interface{}{"type": "object"}
+		if len(props) > 0 {
+			s["properties"] = props
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			s["required"] = required
+		}
+		return s, nil
+	case goYaml.SequenceNode:
+		s := map[string]interface{}{"type": "array"}
+		if len(n.Content) > 0 {
+			items, err := nodeSchema(n.Content[0])
+			if err != nil {
+				return nil, err
+			}
+			s["items"] = items
+		}
+		return s, nil
+	case goYaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!str", "!!binary", "!!timestamp":
+			return map[string]interface{}{"type": "string"}, nil
+		case "!!int":
+			return map[string]interface{}{"type": "integer"}, nil
+		case "!!float":
+			return map[string]interface{}{"type": "number"}, nil
+		case "!!bool":
+			return map[string]interface{}{"type": "boolean"}, nil
+		}
+		// A null value does not constrain the type.
+		return map[string]interface{}{}, nil
+	}
+	return nil, errors.Errorf("unsupported YAML node at line %d", n.Line)
+}
+
+// mergedProperties adds to props the properties of the mappings merged by a
+// merge key whose value is n, a mapping or a sequence of mappings. The
+// mappings earlier in a sequence take precedence.
+func mergedProperties(n *goYaml.Node, props map[string]interface{}) error {
+	if n.Kind == goYaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == goYaml.SequenceNode {
+		for i := len(n.Content) - 1; i >= 0; i-- {
+			if err := mergedProperties(n.Content[i], props); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := nodeSchema(n)
+	if err != nil {
+		return err
+	}
+	if p, ok := s["properties"].(map[string]interface{}); ok {
+		for k, v := range p {
+			props[k] = v
+		}
+	}
+	return nil
+}
+
+// applyAnnotations adds the keywords of the @schema comment lines found in
+// comments to schema. It returns whether the key was marked as required.
+func applyAnnotations(schema map[string]interface{}, key string, comments ...string) (bool, error) {
+	var required bool
+	for _, c := range comments {
+		for _, line := range strings.Split(c, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			if !strings.HasPrefix(line, SchemaAnnotationPrefix+" ") {
+				continue
+			}
+			var kw map[string]interface{}
+			if err := goYaml.Unmarshal([]byte(strings.TrimPrefix(line, SchemaAnnotationPrefix+" ")), &kw); err != nil || len(kw) != 1 {
+				return false, errors.Errorf("invalid schema annotation for %q: %q", key, line)
+			}
+			for k, v := range kw {
+				switch {
+				case k == "required":
+					r, ok := v.(bool)
+					if !ok {
+						return false, errors.Errorf("invalid schema annotation for %q: required must be true or false", key)
+					}
+					required = r
+				case schemaKeywords[k]:
+					schema[k] = v
+				default:
+					return false, errors.Errorf("unsupported schema annotation for %q: %q", key, k)
+				}
+			}
+		}
+	}
+	return required, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	values := []byte(`# @schema description: Number of pods
+# @schema minimum: 1
+replicaCount: 1
+image:
+  # @schema required: true
+  repository: nginx
+  pullPolicy: IfNotPresent # @schema enum: [Always, IfNotPresent]
+ports: [80]
+resources: {}
+nodeSelector:
+ratio: 0.5
+enabled: true
+`)
+	expect := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "enabled": {
+      "type": "boolean"
+    },
+    "image": {
+      "properties": {
+        "pullPolicy": {
+          "enum": [
+            "Always",
+            "IfNotPresent"
+          ],
+          "type": "string"
+        },
+        "repository": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "repository"
+      ],
+      "type": "object"
+    },
+    "nodeSelector": {},
+    "ports": {
+      "items": {
+        "type": "integer"
+      },
+      "type": "array"
+    },
+    "ratio": {
+      "type": "number"
+    },
+    "replicaCount": {
+      "description": "Number of pods",
+      "minimum": 1,
+      "type": "integer"
+    },
+    "resources": {
+      "type": "object"
+    }
+  },
+  "type": "object"
+}
+`
+	schema, err := GenerateSchema(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(schema) != expect {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expect, schema)
+	}
+
+	vals, err := ReadValues(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateAgainstSingleSchema(vals, schema); err != nil {
+		t.Errorf("Expected values to validate against the generated schema, got %s", err)
+	}
+}
+
+func TestGenerateSchemaMergeKeys(t *testing.T) {
+	values := []byte(`base: &base
+  name: app
+  port: 80
+extra: &extra
+  port: "http"
+  debug: true
+single:
+  <<: *base
+  replicas: 1
+multiple:
+  name: 1
+  <<: [*extra, *base]
+`)
+	schema, err := GenerateSchema(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := ReadValues(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := func(key string) map[string]interface{} {
+		p, err := vals.Table("properties." + key + ".properties")
+		if err != nil {
+			t.Fatalf("Expected properties for %s: %s", key, err)
+		}
+		return p
+	}
+	typeOf := func(p map[string]interface{}, key string) interface{} {
+		if s, ok := p[key].(map[string]interface{}); ok {
+			return s["type"]
+		}
+		return nil
+	}
+
+	single := props("single")
+	for key, expect := range map[string]string{"name": "string", "port": "integer", "replicas": "integer"} {
+		if got := typeOf(single, key); got != expect {
+			t.Errorf("Expected single.%s to be a %s, got %v", key, expect, got)
+		}
+	}
+	// Earlier mappings of a merge sequence, and the keys of the mapping itself,
+	// take precedence.
+	multiple := props("multiple")
+	for key, expect := range map[string]string{"name": "integer", "port": "string", "debug": "boolean"} {
+		if got := typeOf(multiple, key); got != expect {
+			t.Errorf("Expected multiple.%s to be a %s, got %v", key, expect, got)
+		}
+	}
+}
+
+func TestGenerateSchemaErrors(t *testing.T) {
+	tests := map[string]string{
+		"- a\n- b\n":                                  "values must be a YAML mapping",
+		"# @schema default: 1\nreplicas: 1":           `unsupported schema annotation for "replicas"`,
+		"# @schema nonsense\nreplicas: 1":             `invalid schema annotation for "replicas"`,
+		"# @schema required: yes please\nreplicas: 1": "required must be true or false",
+	}
+	for values, expect := range tests {
+		_, err := GenerateSchema([]byte(values))
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("Expected error containing %q for %q, got %v", expect, values, err)
+		}
+	}
+}
+
+func TestSchemaEqual(t *testing.T) {
+	equal, err := SchemaEqual([]byte(`{"type": "object", "required": ["a"]}`), []byte(`{"required":["a"],"type":"object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("Expected schemas to be equal")
+	}
+	equal, err = SchemaEqual([]byte(`{"type": "object"}`), []byte(`{"type": "array"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal {
+		t.Error("Expected schemas to differ")
+	}
+}
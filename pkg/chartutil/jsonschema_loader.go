@@ -0,0 +1,294 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/gates"
+)
+
+// AllowRemoteSchemaRefs is the feature gate that lets values schemas reference
+// documents over HTTP(S). Without it, only files shipped with the chart and its
+// dependencies can be referenced.
+const AllowRemoteSchemaRefs = gates.Gate("HELM_SCHEMA_ALLOW_REMOTE_REFS")
+
+const (
+	draft07SchemaURL = "http://json-schema.org/draft-07/schema#"
+	// schemaBaseURL is the base URL of the chart files referenced from a
+	// schema. It is never read from disk.
+	schemaBaseURL = "file:///"
+)
+
+// unsupportedSchemaKeywords are the keywords of JSON Schema 2019-09 and
+// 2020-12 that have no draft-07 equivalent. Ignoring them would silently
+// accept values the schema author meant to reject.
+var unsupportedSchemaKeywords = []string{
+	"$anchor",
+	"$dynamicAnchor",
+	"$dynamicRef",
+	"$recursiveAnchor",
+	"$recursiveRef",
+	"$vocabulary",
+	"maxContains",
+	"minContains",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+}
+
+// schemaURL returns the URL identifying a file of a chart in schema references.
+func schemaURL(c *chart.Chart, name string) string {
+	if c == nil {
+		return schemaBaseURL + name
+	}
+	return schemaBaseURL + path.Join(c.ChartFullPath(), name)
+}
+
+// chartSchemaFiles returns the schema and JSON files of a chart and all its
+// dependencies, keyed by their URL.
+func chartSchemaFiles(c *chart.Chart) map[string][]byte {
+	files := make(map[string][]byte)
+	var walk func(*chart.Chart)
+	walk = func(c *chart.Chart) {
+		if c.Schema != nil {
+			files[schemaURL(c, SchemafileName)] = c.Schema
+		}
+		for _, f := range c.Files {
+			if path.Ext(f.Name) == ".json" {
+				files[schemaURL(c, f.Name)] = f.Data
+			}
+		}
+		for _, dep := range c.Dependencies() {
+			walk(dep)
+		}
+	}
+	walk(c)
+	return files
+}
+
+// schemaLoaderFactory loads the documents referenced from a values schema.
+//
+// It implements gojsonschema.JSONLoaderFactory so that references never
+// reach the filesystem, and only reach the network when AllowRemoteSchemaRefs
+// is enabled.
+type schemaLoaderFactory struct {
+	files map[string][]byte
+}
+
+// root returns the loader of the top-level schema, identified by base unless
+// it declares its own $id.
+func (f *schemaLoaderFactory) root(data []byte, base string) (gojsonschema.JSONLoader, error) {
+	doc, err := loadSchemaDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := doc.(map[string]interface{}); ok {
+		if _, ok := m["$id"]; !ok {
+			if _, ok := m["id"]; !ok {
+				m["$id"] = base
+			}
+		}
+	}
+	return schemaLoader{JSONLoader: gojsonschema.NewGoLoader(doc), factory: f}, nil
+}
+
+// New implements gojsonschema.JSONLoaderFactory.
+func (f *schemaLoaderFactory) New(source string) gojsonschema.JSONLoader {
+	u, err := url.Parse(source)
+	if err != nil {
+		return errorLoader{gojsonschema.NewGoLoader(nil), err}
+	}
+	u.Fragment = ""
+	if data, ok := f.files[u.String()]; ok {
+		doc, err := loadSchemaDocument(data)
+		if err != nil {
+			return errorLoader{gojsonschema.NewGoLoader(nil), errors.Wrapf(err, "unable to load schema %s", strings.TrimPrefix(u.String(), schemaBaseURL))}
+		}
+		return schemaLoader{JSONLoader: gojsonschema.NewGoLoader(doc), factory: f}
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if AllowRemoteSchemaRefs.IsEnabled() {
+			return gojsonschema.NewReferenceLoader(source)
+		}
+		return errorLoader{gojsonschema.NewGoLoader(nil), errors.Errorf("schema reference %q is remote; set %s=1 to allow remote schema references", source, AllowRemoteSchemaRefs)}
+	}
+	return errorLoader{gojsonschema.NewGoLoader(nil), errors.Errorf("schema reference %q does not match any file in the chart or its dependencies", strings.TrimPrefix(source, schemaBaseURL))}
+}
+
+// schemaLoader loads a parsed schema document and resolves its references
+// through factory.
+type schemaLoader struct {
+	gojsonschema.JSONLoader
+	factory *schemaLoaderFactory
+}
+
+// LoaderFactory implements gojsonschema.JSONLoader.
+func (l schemaLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return l.factory
+}
+
+// errorLoader is a gojsonschema.JSONLoader failing with err.
+type errorLoader struct {
+	gojsonschema.JSONLoader
+	err error
+}
+
+// LoadJSON implements gojsonschema.JSONLoader.
+func (l errorLoader) LoadJSON() (interface{}, error) {
+	return nil, l.err
+}
+
+// loadSchemaDocument parses a schema document. Documents written for JSON
+// Schema 2019-09 or 2020-12 are converted to their draft-07 equivalent.
+func loadSchemaDocument(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc, nil
+	}
+	draft, _ := m["$schema"].(string)
+	switch {
+	case strings.Contains(draft, "/draft/2020-12/"):
+		return upgradeSchema(doc, true)
+	case strings.Contains(draft, "/draft/2019-09/"):
+		return upgradeSchema(doc, false)
+	}
+	return doc, nil
+}
+
+// upgradeSchema converts a JSON Schema 2019-09 or 2020-12 (if draft2020 is
+// true) schema to draft-07.
+func upgradeSchema(node interface{}, draft2020 bool) (interface{}, error) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		// Boolean schemas are the same in every draft.
+		return node, nil
+	}
+	for _, k := range unsupportedSchemaKeywords {
+		if _, ok := m[k]; ok {
+			return nil, errors.Errorf("schema keyword %q is not supported", k)
+		}
+	}
+
+	out := make(map[string]interface{}, len(m))
+	deps := make(map[string]interface{})
+	for k, v := range m {
+		var err error
+		switch k {
+		case "$schema":
+			out[k] = draft07SchemaURL
+		case "$defs", "definitions", "properties", "patternProperties":
+			out[k], err = upgradeSchemaMap(v, draft2020)
+		case "dependentSchemas":
+			var schemas map[string]interface{}
+			if schemas, err = upgradeSchemaMap(v, draft2020); err == nil {
+				for name, s := range schemas {
+					deps[name] = s
+				}
+			}
+		case "dependentRequired":
+			if required, ok := v.(map[string]interface{}); ok {
+				for name, r := range required {
+					deps[name] = r
+				}
+			}
+		case "dependencies":
+			if d, ok := v.(map[string]interface{}); ok {
+				for name, dep := range d {
+					if deps[name], err = upgradeSchema(dep, draft2020); err != nil {
+						break
+					}
+				}
+			}
+		case "prefixItems":
+			out["items"], err = upgradeSchemaList(v, draft2020)
+		case "items":
+			if _, ok := m["prefixItems"]; ok && draft2020 {
+				out["additionalItems"], err = upgradeSchema(v, draft2020)
+			} else if _, ok := v.([]interface{}); ok {
+				out[k], err = upgradeSchemaList(v, draft2020)
+			} else {
+				out[k], err = upgradeSchema(v, draft2020)
+			}
+		case "allOf", "anyOf", "oneOf":
+			out[k], err = upgradeSchemaList(v, draft2020)
+		case "additionalItems", "additionalProperties", "contains", "propertyNames", "not", "if", "then", "else":
+			out[k], err = upgradeSchema(v, draft2020)
+		default:
+			out[k] = v
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(deps) > 0 {
+		out["dependencies"] = deps
+	}
+
+	// Since 2019-09, keywords next to $ref apply too. Draft-07 ignores them,
+	// so the reference moves into an allOf.
+	if ref, ok := out["$ref"]; ok && len(out) > 1 {
+		delete(out, "$ref")
+		allOf, _ := out["allOf"].([]interface{})
+		out["allOf"] = append(allOf, map[string]interface{}{"$ref": ref})
+	}
+	return out, nil
+}
+
+func upgradeSchemaMap(v interface{}, draft2020 bool) (map[string]interface{}, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("expected an object of schemas, got %T", v)
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, s := range m {
+		var err error
+		if out[k], err = upgradeSchema(s, draft2020); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func upgradeSchemaList(v interface{}, draft2020 bool) ([]interface{}, error) {
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("expected an array of schemas, got %T", v)
+	}
+	out := make([]interface{}, len(l))
+	for i, s := range l {
+		var err error
+		if out[i], err = upgradeSchema(s, draft2020); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
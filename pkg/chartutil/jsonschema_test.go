@@ -18,6 +18,7 @@ package chartutil
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v3/pkg/chart"
@@ -165,3 +166,131 @@ func TestValidateAgainstSchemaNegative(t *testing.T) {
 		t.Errorf("Error string :\n`%s`\ndoes not match expected\n`%s`", errString, expectedErrString)
 	}
 }
+
+func TestValidateAgainstModernSchema(t *testing.T) {
+	schema := []byte(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$defs": {
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+  },
+  "type": "object",
+  "properties": {
+    "service": {
+      "$ref": "#/$defs/port",
+      "description": "keywords next to $ref apply since 2019-09",
+      "maximum": 1024
+    },
+    "pair": {
+      "type": "array",
+      "prefixItems": [{"type": "string"}, {"type": "integer"}],
+      "items": false
+    }
+  },
+  "dependentRequired": {
+    "tls": ["certificate"]
+  }
+}`)
+
+	valid := Values{
+		"service":     80,
+		"pair":        []interface{}{"http", 80},
+		"tls":         true,
+		"certificate": "cert",
+	}
+	if err := ValidateAgainstSingleSchema(valid, schema); err != nil {
+		t.Errorf("Error validating Values against Schema: %s", err)
+	}
+
+	invalid := Values{
+		"service": 8080,
+		"pair":    []interface{}{"http", 80, "extra"},
+		"tls":     true,
+	}
+	err := ValidateAgainstSingleSchema(invalid, schema)
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+	for _, expect := range []string{
+		"service: Must be less than or equal to 1024",
+		"pair: No additional items allowed on array",
+		"(root): Has a dependency on certificate",
+	} {
+		if !strings.Contains(err.Error(), expect) {
+			t.Errorf("Expected error to contain %q, got:\n%s", expect, err)
+		}
+	}
+
+	unsupported := []byte(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "unevaluatedProperties": false}`)
+	err = ValidateAgainstSingleSchema(Values{}, unsupported)
+	if err == nil || !strings.Contains(err.Error(), `schema keyword "unevaluatedProperties" is not supported`) {
+		t.Errorf("Expected unsupported keyword error, got %v", err)
+	}
+}
+
+func TestValidateAgainstSchemaLocalRefs(t *testing.T) {
+	library := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "library", Type: "library"},
+		Files: []*chart.File{
+			{Name: "schemas/common.json", Data: []byte(`{"$defs": {"image": {"type": "object", "required": ["repository"]}}}`)},
+		},
+	}
+	subchart := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "subchart"},
+		Schema:   []byte(`{"properties": {"image": {"$ref": "../library/schemas/common.json#/$defs/image"}}}`),
+	}
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "chrt"},
+		Schema:   []byte(`{"properties": {"replicas": {"$ref": "schemas/replicas.json"}}}`),
+		Files: []*chart.File{
+			{Name: "schemas/replicas.json", Data: []byte(`{"type": "integer"}`)},
+		},
+	}
+	chrt.AddDependency(library)
+	chrt.AddDependency(subchart)
+
+	vals := map[string]interface{}{
+		"replicas": 1,
+		"library":  map[string]interface{}{},
+		"subchart": map[string]interface{}{
+			"image": map[string]interface{}{"repository": "nginx"},
+		},
+	}
+	if err := ValidateAgainstSchema(chrt, vals); err != nil {
+		t.Errorf("Error validating Values against Schema: %s", err)
+	}
+
+	vals = map[string]interface{}{
+		"replicas": "one",
+		"library":  map[string]interface{}{},
+		"subchart": map[string]interface{}{
+			"image": map[string]interface{}{},
+		},
+	}
+	expectedErrString := `chrt:
+- replicas: Invalid type. Expected: integer, given: string
+subchart:
+- image: repository is required
+`
+	err := ValidateAgainstSchema(chrt, vals)
+	if err == nil || err.Error() != expectedErrString {
+		t.Errorf("Error string :\n`%v`\ndoes not match expected\n`%s`", err, expectedErrString)
+	}
+}
+
+func TestValidateAgainstSchemaRefsNotFound(t *testing.T) {
+	tests := map[string]string{
+		`{"$ref": "schemas/missing.json"}`:            `schema reference "chrt/schemas/missing.json" does not match any file in the chart or its dependencies`,
+		`{"$ref": "../../etc/passwd"}`:                `schema reference "etc/passwd" does not match any file in the chart or its dependencies`,
+		`{"$ref": "https://example.com/schema.json"}`: `schema reference "https://example.com/schema.json" is remote; set HELM_SCHEMA_ALLOW_REMOTE_REFS=1 to allow remote schema references`,
+	}
+	for schema, expect := range tests {
+		chrt := &chart.Chart{
+			Metadata: &chart.Metadata{Name: "chrt"},
+			Schema:   []byte(schema),
+		}
+		err := ValidateAgainstChartSchema(chrt, Values{})
+		if err == nil || err.Error() != expect {
+			t.Errorf("Expected error %q for %s, got %v", expect, schema, err)
+		}
+	}
+}
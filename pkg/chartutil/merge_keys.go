@@ -0,0 +1,257 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const (
+	// MergeKeysAnnotation is the Chart.yaml annotation declaring the lists
+	// whose items are merged by key, as comma-separated path=key pairs:
+	//
+	//	annotations:
+	//	  helm.sh/merge-keys: "env=name, ingress.hosts=host"
+	MergeKeysAnnotation = "helm.sh/merge-keys"
+
+	// SchemaMergeKey is the values.schema.json keyword declaring the key the
+	// items of an array are merged by.
+	SchemaMergeKey = "x-helm-merge-key"
+
+	// PatchKey marks an item of a keyed list. An item with PatchKey set to
+	// PatchDelete removes the item with the same key from the list it is
+	// merged with.
+	PatchKey = "$patch"
+	// PatchDelete is the value of PatchKey deleting an item.
+	PatchDelete = "delete"
+)
+
+// MergeKeys maps the full key of the lists merged by key to the key of their
+// items.
+//
+// Paths only name tables. The lists within the items of a keyed list are
+// named after the keyed list, e.g. "containers.env" for the env list of every
+// item of the containers list.
+type MergeKeys map[string]string
+
+// ChartMergeKeys returns the merge keys declared by c and its dependencies,
+// with paths relative to the values of c.
+//
+// They merge the values given for c from several sources, such as values
+// files or the values of a previous release, as c merges them with its
+// default values.
+func ChartMergeKeys(c *chart.Chart) MergeKeys {
+	return chartMergeKeys(log.Printf, c, "")
+}
+
+// CoalesceTables merges src into dst like CoalesceTables, merging the lists
+// with a merge key item by item.
+func (k MergeKeys) CoalesceTables(dst, src map[string]interface{}) map[string]interface{} {
+	return coalesceTablesFullKey(log.Printf, dst, src, "", false, k)
+}
+
+// MergeLists merges the list src into the list dst item by item, keeping the
+// items marked for deletion, when the list at the full key path has a merge key. dst is
+// considered authoritative. It returns false when the list has no merge key or
+// either value is not a list.
+func (k MergeKeys) MergeLists(path string, dst, src interface{}) ([]interface{}, bool) {
+	dl, sl, key, ok := k.lists(path, dst, src)
+	if !ok {
+		return nil, false
+	}
+	return coalesceKeyedLists(log.Printf, dl, sl, key, path, k, true), true
+}
+
+// chartMergeKeys returns the merge keys declared by c and its dependencies,
+// with paths prefixed by prefix.
+func chartMergeKeys(printf printFn, c *chart.Chart, prefix string) MergeKeys {
+	keys := make(MergeKeys)
+	for _, dep := range c.Dependencies() {
+		for path, key := range chartMergeKeys(printf, dep, concatPrefix(prefix, dep.Name())) {
+			keys[path] = key
+		}
+	}
+
+	if len(c.Schema) > 0 {
+		var schema interface{}
+		if err := json.Unmarshal(c.Schema, &schema); err != nil {
+			printf("warning: unable to read merge keys of %s from %s: %s", c.Name(), SchemafileName, err)
+		} else {
+			schemaMergeKeys(schema, prefix, keys)
+		}
+	}
+
+	// Chart.yaml takes precedence over the schema.
+	if c.Metadata != nil {
+		if annotation, ok := c.Metadata.Annotations[MergeKeysAnnotation]; ok {
+			for _, pair := range strings.Split(annotation, ",") {
+				if strings.TrimSpace(pair) == "" {
+					continue
+				}
+				path, key, ok := strings.Cut(pair, "=")
+				path, key = strings.TrimSpace(path), strings.TrimSpace(key)
+				if !ok || path == "" || key == "" {
+					printf("warning: skipping invalid %s entry %q in %s: expected path=key", MergeKeysAnnotation, pair, c.Name())
+					continue
+				}
+				keys[concatPrefix(prefix, path)] = key
+			}
+		}
+	}
+	return keys
+}
+
+// schemaMergeKeys records the merge keys declared in schema under path.
+func schemaMergeKeys(schema interface{}, path string, keys MergeKeys) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if key, ok := s[SchemaMergeKey].(string); ok && key != "" {
+		keys[path] = key
+	}
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			schemaMergeKeys(prop, concatPrefix(path, name), keys)
+		}
+	}
+	// Items share the path of their list.
+	schemaMergeKeys(s["items"], path, keys)
+}
+
+// lists returns dst and src as lists, and the key their items are merged by,
+// when the list at fullkey has a merge key.
+func (k MergeKeys) lists(fullkey string, dst, src interface{}) ([]interface{}, []interface{}, string, bool) {
+	key, ok := k[fullkey]
+	if !ok {
+		return nil, nil, "", false
+	}
+	dl, ok := dst.([]interface{})
+	if !ok {
+		return nil, nil, "", false
+	}
+	sl, ok := src.([]interface{})
+	if !ok {
+		return nil, nil, "", false
+	}
+	return dl, sl, key, true
+}
+
+// coalesceKeyedLists merges the items of src into the items of dst that have
+// the same value for key.
+//
+// dst is considered authoritative. Items only in src keep their place, and
+// items only in dst are appended. Items of dst marked for deletion remove the
+// matching item; when merge is true the markers are kept, so that a later
+// coalesce can apply them.
+func coalesceKeyedLists(printf printFn, dst, src []interface{}, key, fullkey string, keys MergeKeys, merge bool) []interface{} {
+	dstItems := make(map[string]map[string]interface{})
+	for _, item := range dst {
+		if id, m, ok := keyedItem(item, key); ok {
+			dstItems[id] = m
+		}
+	}
+
+	out := make([]interface{}, 0, len(src)+len(dst))
+	used := make(map[string]bool)
+	for _, item := range src {
+		id, srcItem, ok := keyedItem(item, key)
+		if !ok {
+			out = append(out, item)
+			continue
+		}
+		if isDeleteMarker(srcItem) && !merge {
+			continue
+		}
+		dstItem, ok := dstItems[id]
+		if !ok {
+			out = append(out, item)
+			continue
+		}
+		used[id] = true
+		if isDeleteMarker(dstItem) {
+			if merge {
+				out = append(out, dstItem)
+			}
+			continue
+		}
+		out = append(out, coalesceTablesFullKey(printf, dstItem, srcItem, fullkey, merge, keys))
+	}
+
+	for _, item := range dst {
+		id, m, ok := keyedItem(item, key)
+		if ok && used[id] {
+			continue
+		}
+		if ok && isDeleteMarker(m) && !merge {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// stripDeleteMarkers removes the items marked for deletion from the lists of
+// vals with a merge key, at any depth, prefix being the full key of vals.
+func stripDeleteMarkers(vals map[string]interface{}, prefix string, keys MergeKeys) {
+	for key, val := range vals {
+		fullkey := concatPrefix(prefix, key)
+		switch v := val.(type) {
+		case map[string]interface{}:
+			stripDeleteMarkers(v, fullkey, keys)
+		case []interface{}:
+			mergeKey, ok := keys[fullkey]
+			if !ok {
+				continue
+			}
+			out := v[:0]
+			for _, item := range v {
+				if _, m, ok := keyedItem(item, mergeKey); ok {
+					if isDeleteMarker(m) {
+						continue
+					}
+					// Items share the path of their list.
+					stripDeleteMarkers(m, fullkey, keys)
+				}
+				out = append(out, item)
+			}
+			vals[key] = out
+		}
+	}
+}
+
+// keyedItem returns the value of key in item, when item is a table holding it.
+func keyedItem(item interface{}, key string) (string, map[string]interface{}, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	v, ok := m[key]
+	if !ok || v == nil {
+		return "", nil, false
+	}
+	return fmt.Sprint(v), m, true
+}
+
+func isDeleteMarker(item map[string]interface{}) bool {
+	return item[PatchKey] == PatchDelete
+}
@@ -0,0 +1,199 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func keyedListChart(t *testing.T) *chart.Chart {
+	t.Helper()
+	defaults, err := ReadValues([]byte(`
+containers:
+  - name: app
+    image: app:1
+    env:
+      - name: LOG_LEVEL
+        value: info
+      - name: DEBUG
+        value: "false"
+  - name: sidecar
+    image: sidecar:1
+hosts:
+  - host: a.example.com
+  - host: b.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:        "keyed",
+			Annotations: map[string]string{MergeKeysAnnotation: "hosts=host"},
+		},
+		Values: defaults,
+		Schema: []byte(`{
+  "properties": {
+    "containers": {
+      "type": "array",
+      "x-helm-merge-key": "name",
+      "items": {
+        "properties": {
+          "env": {"type": "array", "x-helm-merge-key": "name"}
+        }
+      }
+    }
+  }
+}`),
+	}
+}
+
+const keyedListOverrides = `
+containers:
+  - name: app
+    image: app:2
+    env:
+      - name: DEBUG
+        $patch: delete
+      - name: EXTRA
+        value: "1"
+  - name: sidecar
+    $patch: delete
+hosts:
+  - host: c.example.com
+`
+
+func TestCoalesceValuesKeyedLists(t *testing.T) {
+	vals, err := ReadValues([]byte(keyedListOverrides))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := CoalesceValues(keyedListChart(t), vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := ReadValues([]byte(`
+containers:
+  - name: app
+    image: app:2
+    env:
+      - name: LOG_LEVEL
+        value: info
+      - name: EXTRA
+        value: "1"
+hosts:
+  - host: a.example.com
+  - host: b.example.com
+  - host: c.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expect.AsMap(), out.AsMap())
+}
+
+func TestCoalesceValuesKeyedListsWithoutDefaults(t *testing.T) {
+	vals, err := ReadValues([]byte(`
+containers:
+  - name: worker
+    env:
+      - name: DEBUG
+        $patch: delete
+      - name: EXTRA
+        value: "1"
+  - name: sidecar
+    $patch: delete
+hosts:
+  - host: a.example.com
+    $patch: delete
+  - host: c.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := keyedListChart(t)
+	c.Values = map[string]interface{}{}
+	out, err := CoalesceValues(c, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := ReadValues([]byte(`
+containers:
+  - name: worker
+    env:
+      - name: EXTRA
+        value: "1"
+hosts:
+  - host: c.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expect.AsMap(), out.AsMap())
+}
+
+func TestMergeValuesKeyedLists(t *testing.T) {
+	vals, err := ReadValues([]byte(keyedListOverrides))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := keyedListChart(t)
+	merged, err := MergeValues(c, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deletion markers are kept when merging so that coalescing applies them.
+	containers := merged["containers"].([]interface{})
+	assert.Len(t, containers, 2)
+	assert.Equal(t, map[string]interface{}{"name": "sidecar", PatchKey: PatchDelete}, containers[1])
+
+	out, err := CoalesceValues(c, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, out["containers"], 1)
+}
+
+func TestChartMergeKeys(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:        "sub",
+			Annotations: map[string]string{MergeKeysAnnotation: "ports=port, bogus"},
+		},
+	}
+	c := withDeps(keyedListChart(t), sub)
+
+	var warnings []string
+	printf := func(format string, v ...interface{}) {
+		warnings = append(warnings, format)
+	}
+	keys := chartMergeKeys(printf, c, "keyed")
+	assert.Equal(t, MergeKeys{
+		"keyed.containers":     "name",
+		"keyed.containers.env": "name",
+		"keyed.hosts":          "host",
+		"keyed.sub.ports":      "port",
+	}, keys)
+	assert.Len(t, warnings, 1)
+}
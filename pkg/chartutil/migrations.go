@@ -0,0 +1,302 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// MigrationsDir is the directory of a chart holding its values migrations.
+const MigrationsDir = "migrations"
+
+// Values migration operations.
+const (
+	// MigrationMove moves the value at Path to the full path To.
+	MigrationMove = "move"
+	// MigrationRename renames the last key of Path to To.
+	MigrationRename = "rename"
+	// MigrationDelete deletes the value at Path.
+	MigrationDelete = "delete"
+	// MigrationSetDefault sets the value at Path to Value if it is not set.
+	MigrationSetDefault = "setDefault"
+)
+
+// ValuesMigration is a set of changes to the user-supplied values of a release,
+// needed when upgrading it from a version of the chart in the From range to
+// Version of the chart or later.
+//
+// Migrations are read from the YAML files of the migrations directory of a
+// chart:
+//
+//	version: 2.0.0
+//	from: ">=1.0.0 <2.0.0"
+//	description: The image is now configured by repository and tag.
+//	operations:
+//	  - op: move
+//	    path: image.name
+//	    to: image.repository
+//	  - op: rename
+//	    path: service.type
+//	    to: kind
+//	  - op: delete
+//	    path: legacyMode
+//	  - op: setDefault
+//	    path: persistence.enabled
+//	    value: false
+type ValuesMigration struct {
+	// Version is the version of the chart introducing the migration.
+	Version string `json:"version"`
+	// From is a semver constraint on the version of the chart upgraded from.
+	// It defaults to the versions lower than Version, and must not include
+	// Version.
+	From string `json:"from,omitempty"`
+	// Description tells why the migration is needed.
+	Description string `json:"description,omitempty"`
+	// Operations are applied in order.
+	Operations []ValuesMigrationOperation `json:"operations"`
+
+	file    string
+	version *semver.Version
+	from    *semver.Constraints
+}
+
+// ValuesMigrationOperation is a single change of a ValuesMigration.
+type ValuesMigrationOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	To    string      `json:"to,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MigratedValue is a change a ValuesMigration made to values.
+type MigratedValue struct {
+	Version string `json:"version"`
+	Op      string `json:"op"`
+	Path    string `json:"path"`
+	To      string `json:"to,omitempty"`
+}
+
+func (m MigratedValue) String() string {
+	switch m.Op {
+	case MigrationMove, MigrationRename:
+		return fmt.Sprintf("%s: moved %s to %s", m.Version, m.Path, m.To)
+	case MigrationDelete:
+		return fmt.Sprintf("%s: deleted %s", m.Version, m.Path)
+	case MigrationSetDefault:
+		return fmt.Sprintf("%s: set %s", m.Version, m.Path)
+	}
+	return fmt.Sprintf("%s: %s %s", m.Version, m.Op, m.Path)
+}
+
+// LoadValuesMigrations returns the values migrations of c, sorted by version.
+func LoadValuesMigrations(c *chart.Chart) ([]*ValuesMigration, error) {
+	var migrations []*ValuesMigration
+	for _, f := range c.Files {
+		if path.Dir(f.Name) != MigrationsDir {
+			continue
+		}
+		if ext := path.Ext(f.Name); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		m := &ValuesMigration{file: f.Name}
+		if err := yaml.UnmarshalStrict(f.Data, m); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s", f.Name)
+		}
+		if err := m.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid values migration %s", f.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.SliceStable(migrations, func(i, j int) bool {
+		if c := migrations[i].version.Compare(migrations[j].version); c != 0 {
+			return c < 0
+		}
+		return migrations[i].file < migrations[j].file
+	})
+	return migrations, nil
+}
+
+func (m *ValuesMigration) validate() error {
+	v, err := semver.StrictNewVersion(m.Version)
+	if err != nil {
+		return errors.Wrapf(err, "version %q is invalid", m.Version)
+	}
+	m.version = v
+	if m.From != "" {
+		from, err := semver.NewConstraint(m.From)
+		if err != nil {
+			return errors.Wrapf(err, "from %q is invalid", m.From)
+		}
+		if from.Check(v) {
+			return errors.Errorf("from %q includes version %s", m.From, m.Version)
+		}
+		m.from = from
+	}
+	for i, op := range m.Operations {
+		if op.Path == "" {
+			return errors.Errorf("operation %d has no path", i+1)
+		}
+		switch op.Op {
+		case MigrationMove:
+			if op.To == "" {
+				return errors.Errorf("operation %d moves %s nowhere", i+1, op.Path)
+			}
+		case MigrationRename:
+			if op.To == "" || strings.Contains(op.To, ".") {
+				return errors.Errorf("operation %d renames %s to %q, which is not a key", i+1, op.Path, op.To)
+			}
+		case MigrationDelete:
+		case MigrationSetDefault:
+			if op.Value == nil {
+				return errors.Errorf("operation %d sets %s to no value", i+1, op.Path)
+			}
+		default:
+			return errors.Errorf("operation %d has unknown op %q", i+1, op.Op)
+		}
+	}
+	return nil
+}
+
+// ValuesMigrations returns the values migrations of c needed to upgrade a
+// release from version from of the chart, which are the ones with a version
+// not greater than the version of c whose From range includes from.
+func ValuesMigrations(c *chart.Chart, from string) ([]*ValuesMigration, error) {
+	migrations, err := LoadValuesMigrations(c)
+	if err != nil || len(migrations) == 0 {
+		return nil, err
+	}
+	fromVersion, err := semver.NewVersion(from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "version %q of the previous chart is invalid", from)
+	}
+	toVersion, err := semver.NewVersion(c.Metadata.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "version %q of the chart is invalid", c.Metadata.Version)
+	}
+
+	var pending []*ValuesMigration
+	for _, m := range migrations {
+		if m.version.GreaterThan(toVersion) {
+			continue
+		}
+		if m.from != nil && m.from.Check(fromVersion) || m.from == nil && m.version.GreaterThan(fromVersion) {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateValues returns a copy of vals with migrations applied in order, along
+// with the changes they made.
+//
+// A value moved or renamed onto a path already set is dropped, so that values
+// set for the new version of the chart take precedence.
+func MigrateValues(vals map[string]interface{}, migrations []*ValuesMigration) (map[string]interface{}, []MigratedValue, error) {
+	if len(migrations) == 0 {
+		return vals, nil, nil
+	}
+	out, err := copyValues(vals)
+	if err != nil {
+		return vals, nil, err
+	}
+
+	var changes []MigratedValue
+	for _, m := range migrations {
+		for _, op := range m.Operations {
+			from := parsePath(op.Path)
+			change := MigratedValue{Version: m.Version, Op: op.Op, Path: op.Path}
+			switch op.Op {
+			case MigrationMove, MigrationRename:
+				to := parsePath(op.To)
+				if op.Op == MigrationRename {
+					to = append(from[:len(from)-1:len(from)-1], op.To)
+				}
+				v, ok := removePath(out, from)
+				if !ok {
+					continue
+				}
+				if _, exists := lookupPath(out, to); exists {
+					change.Op = MigrationDelete
+					break
+				}
+				if err := setPath(out, to, v); err != nil {
+					return vals, nil, errors.Wrapf(err, "values migration %s", m.Version)
+				}
+				change.To = joinPath(to...)
+			case MigrationDelete:
+				if _, ok := removePath(out, from); !ok {
+					continue
+				}
+			case MigrationSetDefault:
+				if _, ok := lookupPath(out, from); ok {
+					continue
+				}
+				if err := setPath(out, from, op.Value); err != nil {
+					return vals, nil, errors.Wrapf(err, "values migration %s", m.Version)
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+	return out, changes, nil
+}
+
+// removePath removes the value at path from values, along with the tables left
+// empty, and returns it.
+func removePath(values map[string]interface{}, path []string) (interface{}, bool) {
+	if len(path) == 1 {
+		v, ok := values[path[0]]
+		delete(values, path[0])
+		return v, ok
+	}
+	table, ok := values[path[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok := removePath(table, path[1:])
+	if ok && len(table) == 0 {
+		delete(values, path[0])
+	}
+	return v, ok
+}
+
+// setPath sets the value at path in values, creating the missing tables.
+func setPath(values map[string]interface{}, path []string, v interface{}) error {
+	for i, key := range path[:len(path)-1] {
+		next, ok := values[key]
+		if !ok || next == nil {
+			next = map[string]interface{}{}
+			values[key] = next
+		}
+		table, ok := next.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%s is not a table", joinPath(path[:i+1]...))
+		}
+		values = table
+	}
+	values[path[len(path)-1]] = v
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func migrationsTestChart(version string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "migrated", Version: version},
+		Files: []*chart.File{
+			{Name: "migrations/3.0.0.yaml", Data: []byte(`version: 3.0.0
+operations:
+  - op: rename
+    path: service.type
+    to: kind
+  - op: setDefault
+    path: persistence.enabled
+    value: false
+`)},
+			{Name: "migrations/2.0.0.yaml", Data: []byte(`version: 2.0.0
+description: The image is now configured by repository and tag.
+operations:
+  - op: move
+    path: image.name
+    to: image.repository
+  - op: delete
+    path: legacy.mode
+`)},
+			{Name: "migrations/README.md", Data: []byte("Not a migration")},
+			{Name: "files/migrations/1.0.0.yaml", Data: []byte("Not a migration either")},
+		},
+	}
+}
+
+func TestValuesMigrations(t *testing.T) {
+	tests := []struct {
+		from, to string
+		versions []string
+	}{
+		{"1.0.0", "3.0.0", []string{"2.0.0", "3.0.0"}},
+		{"1.0.0", "2.5.0", []string{"2.0.0"}},
+		{"2.0.0", "3.1.0", []string{"3.0.0"}},
+		{"3.0.0", "3.1.0", nil},
+		{"3.0.0", "1.0.0", nil},
+	}
+	for _, tt := range tests {
+		migrations, err := ValuesMigrations(migrationsTestChart(tt.to), tt.from)
+		require.NoError(t, err)
+		var versions []string
+		for _, m := range migrations {
+			versions = append(versions, m.Version)
+		}
+		assert.Equal(t, tt.versions, versions, "from %s to %s", tt.from, tt.to)
+	}
+}
+
+func TestValuesMigrationsRanges(t *testing.T) {
+	c := migrationsTestChart("3.0.0")
+	c.Files = append(c.Files,
+		// Only releases of the 1.x charts have the legacy values.
+		&chart.File{Name: "migrations/legacy.yaml", Data: []byte(`version: 2.0.0
+from: ">=1.0.0 <2.0.0"
+operations:
+  - op: delete
+    path: legacy.enabled
+`)},
+		// Releases of 2.1.0 and later already have the fixed values.
+		&chart.File{Name: "migrations/fix.yaml", Data: []byte(`version: 2.5.0
+from: ">=2.0.0 <2.1.0"
+operations:
+  - op: delete
+    path: broken
+`)})
+
+	tests := []struct {
+		from, to string
+		versions []string
+	}{
+		{"0.9.0", "3.0.0", []string{"2.0.0", "3.0.0"}},
+		{"1.2.0", "3.0.0", []string{"2.0.0", "2.0.0", "3.0.0"}},
+		{"2.0.0", "2.5.0", []string{"2.5.0"}},
+		{"2.0.5", "3.0.0", []string{"2.5.0", "3.0.0"}},
+		{"2.2.0", "3.0.0", []string{"3.0.0"}},
+		{"1.2.0", "1.9.0", nil},
+	}
+	for _, tt := range tests {
+		c.Metadata.Version = tt.to
+		migrations, err := ValuesMigrations(c, tt.from)
+		require.NoError(t, err)
+		var versions []string
+		for _, m := range migrations {
+			versions = append(versions, m.Version)
+		}
+		assert.Equal(t, tt.versions, versions, "from %s to %s", tt.from, tt.to)
+	}
+}
+
+func TestMigrateValues(t *testing.T) {
+	migrations, err := ValuesMigrations(migrationsTestChart("3.0.0"), "1.0.0")
+	require.NoError(t, err)
+
+	vals := map[string]interface{}{
+		"image":   map[string]interface{}{"name": "nginx", "tag": "1.0"},
+		"legacy":  map[string]interface{}{"mode": true},
+		"service": map[string]interface{}{"type": "ClusterIP", "kind": "LoadBalancer"},
+	}
+	migrated, changes, err := MigrateValues(vals, migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"image":       map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"service":     map[string]interface{}{"kind": "LoadBalancer"},
+		"persistence": map[string]interface{}{"enabled": false},
+	}, migrated)
+	assert.Equal(t, []MigratedValue{
+		{Version: "2.0.0", Op: MigrationMove, Path: "image.name", To: "image.repository"},
+		{Version: "2.0.0", Op: MigrationDelete, Path: "legacy.mode"},
+		{Version: "3.0.0", Op: MigrationDelete, Path: "service.type"},
+		{Version: "3.0.0", Op: MigrationSetDefault, Path: "persistence.enabled"},
+	}, changes)
+	assert.Equal(t, "2.0.0: moved image.name to image.repository", changes[0].String())
+	assert.Equal(t, "nginx", vals["image"].(map[string]interface{})["name"], "the values must not be changed")
+
+	migrated, changes, err = MigrateValues(map[string]interface{}{"persistence": map[string]interface{}{"enabled": true}}, migrations)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, map[string]interface{}{"persistence": map[string]interface{}{"enabled": true}}, migrated)
+
+	_, _, err = MigrateValues(map[string]interface{}{"image": map[string]interface{}{"name": "nginx"}, "legacy": "yes"}, []*ValuesMigration{{
+		Version:    "2.0.0",
+		Operations: []ValuesMigrationOperation{{Op: MigrationMove, Path: "image.name", To: "legacy.image"}},
+	}})
+	assert.EqualError(t, err, "values migration 2.0.0: legacy is not a table")
+}
+
+func TestLoadValuesMigrationsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"version: two\noperations: []":                                          `version "two" is invalid`,
+		"version: 2.0.0\noperations:\n  - op: move\n    path: a":                "moves a nowhere",
+		"version: 2.0.0\noperations:\n  - op: rename\n    path: a\n    to: b.c": `renames a to "b.c"`,
+		"version: 2.0.0\noperations:\n  - op: setDefault\n    path: a":          "sets a to no value",
+		"version: 2.0.0\noperations:\n  - op: delete":                           "operation 1 has no path",
+		"version: 2.0.0\nsteps: []":                                             "unable to parse migrations/bad.yaml",
+		"version: 2.0.0\nfrom: one\noperations: []":                             `from "one" is invalid`,
+		"version: 2.0.0\nfrom: \">=1.0.0\"\noperations: []":                     `from ">=1.0.0" includes version 2.0.0`,
+	}
+	for data, msg := range tests {
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{Name: "migrated", Version: "2.0.0"},
+			Files:    []*chart.File{{Name: "migrations/bad.yaml", Data: []byte(data)}},
+		}
+		_, err := LoadValuesMigrations(c)
+		if assert.Error(t, err, data) {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -95,6 +96,29 @@ func SaveDir(c *chart.Chart, dest string) error {
 	return nil
 }
 
+// SaveOptions configures the archives written by SaveWithOptions.
+type SaveOptions struct {
+	// Reproducible makes archives depend on the contents of the chart only,
+	// so that saving the same chart twice yields identical archives. Files are
+	// written in order of their names, with ModTime as modification time and
+	// without ownership.
+	Reproducible bool
+	// ModTime is the modification time of the files of reproducible archives.
+	// The zero value stands for the Unix epoch.
+	ModTime time.Time
+}
+
+// modTime returns the modification time of the files of the archive.
+func (o SaveOptions) modTime() time.Time {
+	if !o.Reproducible {
+		return time.Now()
+	}
+	if o.ModTime.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return o.ModTime.UTC().Truncate(time.Second)
+}
+
 // Save creates an archived chart to the given directory.
 //
 // This takes an existing chart and a destination directory.
@@ -104,6 +128,11 @@ func SaveDir(c *chart.Chart, dest string) error {
 //
 // This returns the absolute path to the chart archive file.
 func Save(c *chart.Chart, outDir string) (string, error) {
+	return SaveWithOptions(c, outDir, SaveOptions{})
+}
+
+// SaveWithOptions is like Save, with the archive configured by opts.
+func SaveWithOptions(c *chart.Chart, outDir string, opts SaveOptions) (string, error) {
 	if err := c.Validate(); err != nil {
 		return "", errors.Wrap(err, "chart validation")
 	}
@@ -132,6 +161,8 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 	zipper := gzip.NewWriter(f)
 	zipper.Header.Extra = headerBytes
 	zipper.Header.Comment = "Helm"
+	// The default gzip header is already reproducible: it has no modification
+	// time or file name, and an unknown OS.
 
 	// Wrap in tar writer
 	twriter := tar.NewWriter(zipper)
@@ -145,14 +176,14 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 		}
 	}()
 
-	if err := writeTarContents(twriter, c, ""); err != nil {
+	if err := writeTarContents(twriter, c, "", opts); err != nil {
 		rollback = true
 		return filename, err
 	}
 	return filename, nil
 }
 
-func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
+func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string, opts SaveOptions) error {
 	err := validateName(c.Name())
 	if err != nil {
 		return err
@@ -173,7 +204,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	if err != nil {
 		return err
 	}
-	if err := writeToTar(out, filepath.Join(base, ChartfileName), cdata); err != nil {
+	if err := writeToTar(out, filepath.Join(base, ChartfileName), cdata, opts); err != nil {
 		return err
 	}
 
@@ -185,7 +216,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 			if err != nil {
 				return err
 			}
-			if err := writeToTar(out, filepath.Join(base, "Chart.lock"), ldata); err != nil {
+			if err := writeToTar(out, filepath.Join(base, "Chart.lock"), ldata, opts); err != nil {
 				return err
 			}
 		}
@@ -194,7 +225,7 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 	// Save values.yaml
 	for _, f := range c.Raw {
 		if f.Name == ValuesfileName {
-			if err := writeToTar(out, filepath.Join(base, ValuesfileName), f.Data); err != nil {
+			if err := writeToTar(out, filepath.Join(base, ValuesfileName), f.Data, opts); err != nil {
 				return err
 			}
 		}
@@ -205,44 +236,58 @@ func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
 		if !json.Valid(c.Schema) {
 			return errors.New("Invalid JSON in " + SchemafileName)
 		}
-		if err := writeToTar(out, filepath.Join(base, SchemafileName), c.Schema); err != nil {
+		if err := writeToTar(out, filepath.Join(base, SchemafileName), c.Schema, opts); err != nil {
 			return err
 		}
 	}
 
+	templates, files, dependencies := c.Templates, c.Files, c.Dependencies()
+	if opts.Reproducible {
+		templates, files = sortedFiles(templates), sortedFiles(files)
+		dependencies = append([]*chart.Chart(nil), dependencies...)
+		sort.SliceStable(dependencies, func(i, j int) bool { return dependencies[i].Name() < dependencies[j].Name() })
+	}
+
 	// Save templates
-	for _, f := range c.Templates {
+	for _, f := range templates {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := writeToTar(out, n, f.Data, opts); err != nil {
 			return err
 		}
 	}
 
 	// Save files
-	for _, f := range c.Files {
+	for _, f := range files {
 		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
+		if err := writeToTar(out, n, f.Data, opts); err != nil {
 			return err
 		}
 	}
 
 	// Save dependencies
-	for _, dep := range c.Dependencies() {
-		if err := writeTarContents(out, dep, filepath.Join(base, ChartsDir)); err != nil {
+	for _, dep := range dependencies {
+		if err := writeTarContents(out, dep, filepath.Join(base, ChartsDir), opts); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// sortedFiles returns a copy of files sorted by name.
+func sortedFiles(files []*chart.File) []*chart.File {
+	sorted := append([]*chart.File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
 // writeToTar writes a single file to a tar archive.
-func writeToTar(out *tar.Writer, name string, body []byte) error {
+func writeToTar(out *tar.Writer, name string, body []byte, opts SaveOptions) error {
 	// TODO: Do we need to create dummy parent directory names if none exist?
 	h := &tar.Header{
 		Name:    filepath.ToSlash(name),
 		Mode:    0644,
 		Size:    int64(len(body)),
-		ModTime: time.Now(),
+		ModTime: opts.modTime(),
 	}
 	if err := out.WriteHeader(h); err != nil {
 		return err
@@ -179,6 +179,92 @@ func TestSavePreservesTimestamps(t *testing.T) {
 	}
 }
 
+func TestSaveReproducible(t *testing.T) {
+	newChart := func(reversed bool) *chart.Chart {
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ahab", Version: "1.2.3"},
+			Templates: []*chart.File{
+				{Name: "templates/a.yaml", Data: []byte("a: 1")},
+				{Name: "templates/b.yaml", Data: []byte("b: 2")},
+			},
+			Files: []*chart.File{
+				{Name: "files/a.txt", Data: []byte("a")},
+				{Name: "files/b.txt", Data: []byte("b")},
+			},
+		}
+		deps := []*chart.Chart{
+			{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ishmael", Version: "0.1.0"}},
+			{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "queequeg", Version: "0.1.0"}},
+		}
+		if reversed {
+			c.Templates[0], c.Templates[1] = c.Templates[1], c.Templates[0]
+			c.Files[0], c.Files[1] = c.Files[1], c.Files[0]
+			deps[0], deps[1] = deps[1], deps[0]
+		}
+		c.SetDependencies(deps...)
+		return c
+	}
+
+	opts := SaveOptions{Reproducible: true}
+	first, err := SaveWithOptions(newChart(false), t.TempDir(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	second, err := SaveWithOptions(newChart(true), t.TempDir(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstData, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondData, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(firstData, secondData) {
+		t.Fatal("Expected saving the same chart twice to yield identical archives")
+	}
+
+	headers, err := retrieveAllHeadersFromTar(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, h := range headers {
+		names = append(names, h.Name)
+		if !h.ModTime.Equal(time.Unix(0, 0)) || h.Uid != 0 || h.Gid != 0 || h.Mode != 0644 {
+			t.Errorf("Expected normalized metadata for %s, got %v, %d:%d, %o", h.Name, h.ModTime, h.Uid, h.Gid, h.Mode)
+		}
+	}
+	expect := []string{
+		"ahab/Chart.yaml",
+		"ahab/templates/a.yaml",
+		"ahab/templates/b.yaml",
+		"ahab/files/a.txt",
+		"ahab/files/b.txt",
+		"ahab/charts/ishmael/Chart.yaml",
+		"ahab/charts/queequeg/Chart.yaml",
+	}
+	if strings.Join(names, ",") != strings.Join(expect, ",") {
+		t.Errorf("Expected files in order %v, got %v", expect, names)
+	}
+
+	epoch := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	where, err := SaveWithOptions(newChart(false), t.TempDir(), SaveOptions{Reproducible: true, ModTime: epoch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, err = retrieveAllHeadersFromTar(where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !headers[0].ModTime.Equal(epoch) {
+		t.Errorf("Expected modification time %v, got %v", epoch, headers[0].ModTime)
+	}
+}
+
 // We could refactor `load.go` to use this `retrieveAllHeadersFromTar` function
 // as well, so we are not duplicating components of the code which iterate
 // through the tar.
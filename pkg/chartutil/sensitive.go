@@ -0,0 +1,272 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const (
+	// SensitiveValuesAnnotation is the Chart.yaml annotation listing the
+	// paths of sensitive values, separated by commas. A '*' path element
+	// matches any key:
+	//
+	//	annotations:
+	//	  helm.sh/sensitive-values: "database.password, apiKeys.*"
+	SensitiveValuesAnnotation = "helm.sh/sensitive-values"
+
+	// SchemaSensitive is the values.schema.json keyword marking a value as
+	// sensitive when set to true.
+	SchemaSensitive = "x-helm-sensitive"
+
+	// RedactedValue replaces sensitive values in output.
+	RedactedValue = "[REDACTED]"
+
+	// hashedValuePrefix starts the keyed digests replacing sensitive values in
+	// the stored values of a release.
+	hashedValuePrefix = "[REDACTED hmac-sha256:"
+
+	// minRedactedStringLength is the length under which sensitive strings are
+	// not redacted from free text, as doing so would mangle unrelated words.
+	minRedactedStringLength = 4
+)
+
+// SensitivePaths returns the paths of the values c and its dependencies mark
+// as sensitive. Paths of dependencies are prefixed by the dependency name, and
+// sensitive globals are also listed at the top level.
+func SensitivePaths(c *chart.Chart) []string {
+	set := make(map[string]bool)
+	sensitivePaths(c, "", set)
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func sensitivePaths(c *chart.Chart, prefix string, set map[string]bool) {
+	add := func(path string) {
+		set[concatPrefix(prefix, path)] = true
+		if prefix != "" && strings.HasPrefix(path, GlobalKey+".") {
+			set[path] = true
+		}
+	}
+
+	if len(c.Schema) > 0 {
+		var schema interface{}
+		if err := json.Unmarshal(c.Schema, &schema); err == nil {
+			schemaSensitivePaths(schema, "", add)
+		}
+	}
+	if c.Metadata != nil {
+		for _, p := range strings.Split(c.Metadata.Annotations[SensitiveValuesAnnotation], ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				add(p)
+			}
+		}
+	}
+	for _, dep := range c.Dependencies() {
+		sensitivePaths(dep, concatPrefix(prefix, dep.Name()), set)
+	}
+}
+
+// schemaSensitivePaths calls add with the path of every sensitive value
+// declared in schema under path.
+func schemaSensitivePaths(schema interface{}, path string, add func(string)) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if sensitive, _ := s[SchemaSensitive].(bool); sensitive && path != "" {
+		add(path)
+		return
+	}
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			schemaSensitivePaths(prop, concatPrefix(path, name), add)
+		}
+	}
+	if path != "" {
+		schemaSensitivePaths(s["additionalProperties"], path+".*", add)
+	}
+	// Items share the path of their list.
+	schemaSensitivePaths(s["items"], path, add)
+}
+
+// RedactValues returns a copy of vals where the values c marks as sensitive
+// are replaced by RedactedValue. Hashed values are kept as they are.
+func RedactValues(c *chart.Chart, vals map[string]interface{}) map[string]interface{} {
+	return replaceSensitive(vals, SensitivePaths(c), func(v interface{}) interface{} {
+		if isHashedValue(v) {
+			return v
+		}
+		return RedactedValue
+	})
+}
+
+// HashSensitiveValues returns a copy of vals where the values c marks as
+// sensitive are replaced by their HMAC-SHA256 under key, for storing the values
+// of a release without them. Without the key, the digests cannot be used to
+// guess the values, but a value changing still changes its digest.
+func HashSensitiveValues(c *chart.Chart, vals map[string]interface{}, key []byte) map[string]interface{} {
+	return replaceSensitive(vals, SensitivePaths(c), func(v interface{}) interface{} {
+		if isHashedValue(v) {
+			return v
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return RedactedValue
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		return hashedValuePrefix + hex.EncodeToString(mac.Sum(nil)) + "]"
+	})
+}
+
+// HashedValuePaths returns the paths of the values of vals that were replaced
+// by HashSensitiveValues.
+func HashedValuePaths(vals map[string]interface{}) []string {
+	var paths []string
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				walk(item, concatPrefix(path, k))
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item, path)
+			}
+		default:
+			if isHashedValue(v) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	walk(vals, "")
+	sort.Strings(paths)
+	return paths
+}
+
+// SensitiveStrings returns the strings of vals that c marks as sensitive, for
+// redacting them from free text with RedactString.
+func SensitiveStrings(c *chart.Chart, vals map[string]interface{}) []string {
+	var secrets []string
+	replaceSensitive(vals, SensitivePaths(c), func(v interface{}) interface{} {
+		collectStrings(v, &secrets)
+		return v
+	})
+	return secrets
+}
+
+func collectStrings(v interface{}, out *[]string) {
+	switch v := v.(type) {
+	case string:
+		if len(v) >= minRedactedStringLength && !isHashedValue(v) {
+			*out = append(*out, v)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			collectStrings(item, out)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectStrings(item, out)
+		}
+	}
+}
+
+// RedactString replaces every occurrence of secrets in s by RedactedValue.
+func RedactString(s string, secrets []string) string {
+	if len(secrets) == 0 {
+		return s
+	}
+	// Longer secrets first, so that a secret containing another one is
+	// redacted as a whole.
+	sorted := append([]string{}, secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		s = strings.ReplaceAll(s, secret, RedactedValue)
+	}
+	return s
+}
+
+func isHashedValue(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, hashedValuePrefix) && strings.HasSuffix(s, "]")
+}
+
+// replaceSensitive returns a copy of vals where the values at paths are
+// replaced by the result of replace.
+func replaceSensitive(vals map[string]interface{}, paths []string, replace func(interface{}) interface{}) map[string]interface{} {
+	if vals == nil || len(paths) == 0 {
+		return vals
+	}
+	split := make([][]string, len(paths))
+	for i, p := range paths {
+		split[i] = strings.Split(p, ".")
+	}
+	out, _ := replaceSensitiveValue(vals, nil, split, replace).(map[string]interface{})
+	return out
+}
+
+func replaceSensitiveValue(v interface{}, path []string, paths [][]string, replace func(interface{}) interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	for _, p := range paths {
+		if matchPath(p, path) {
+			return replace(v)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = replaceSensitiveValue(item, append(path[:len(path):len(path)], k), paths, replace)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = replaceSensitiveValue(item, path, paths, replace)
+		}
+		return out
+	}
+	return v
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
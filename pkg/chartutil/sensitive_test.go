@@ -0,0 +1,131 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func sensitiveTestChart() *chart.Chart {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:        "parent",
+			Annotations: map[string]string{SensitiveValuesAnnotation: "database.password, apiKeys.*"},
+		},
+		Schema: []byte(`{
+  "properties": {
+    "auth": {
+      "properties": {
+        "token": {"type": "string", "x-helm-sensitive": true},
+        "user": {"type": "string"}
+      }
+    },
+    "users": {
+      "items": {
+        "properties": {
+          "password": {"x-helm-sensitive": true}
+        }
+      }
+    },
+    "env": {
+      "additionalProperties": {"x-helm-sensitive": true}
+    }
+  }
+}`),
+	}
+	c.AddDependency(&chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:        "sub",
+			Annotations: map[string]string{SensitiveValuesAnnotation: "secret, global.registryPassword"},
+		},
+	})
+	return c
+}
+
+func TestSensitivePaths(t *testing.T) {
+	assert.Equal(t, []string{
+		"apiKeys.*",
+		"auth.token",
+		"database.password",
+		"env.*",
+		"global.registryPassword",
+		"sub.global.registryPassword",
+		"sub.secret",
+		"users.password",
+	}, SensitivePaths(sensitiveTestChart()))
+}
+
+func TestRedactValues(t *testing.T) {
+	vals := map[string]interface{}{
+		"auth":     map[string]interface{}{"token": "s3cr3t-token", "user": "admin"},
+		"database": map[string]interface{}{"password": map[string]interface{}{"nested": "whole table"}},
+		"apiKeys":  map[string]interface{}{"a": "key-a", "b": "key-b"},
+		"users":    []interface{}{map[string]interface{}{"name": "bob", "password": "hunter22"}},
+		"sub":      map[string]interface{}{"secret": "sub-secret", "other": "visible"},
+		"global":   map[string]interface{}{"registryPassword": "registry"},
+	}
+	c := sensitiveTestChart()
+	redacted := RedactValues(c, vals)
+
+	assert.Equal(t, map[string]interface{}{
+		"auth":     map[string]interface{}{"token": RedactedValue, "user": "admin"},
+		"database": map[string]interface{}{"password": RedactedValue},
+		"apiKeys":  map[string]interface{}{"a": RedactedValue, "b": RedactedValue},
+		"users":    []interface{}{map[string]interface{}{"name": "bob", "password": RedactedValue}},
+		"sub":      map[string]interface{}{"secret": RedactedValue, "other": "visible"},
+		"global":   map[string]interface{}{"registryPassword": RedactedValue},
+	}, redacted)
+	assert.Equal(t, "s3cr3t-token", vals["auth"].(map[string]interface{})["token"], "RedactValues must not alter its input")
+
+	secrets := SensitiveStrings(c, vals)
+	assert.Contains(t, secrets, "s3cr3t-token")
+	assert.Contains(t, secrets, "whole table")
+	assert.NotContains(t, secrets, "admin")
+	assert.Equal(t, "login failed with [REDACTED] for admin", RedactString("login failed with s3cr3t-token for admin", secrets))
+}
+
+func TestHashSensitiveValues(t *testing.T) {
+	c := sensitiveTestChart()
+	vals := map[string]interface{}{
+		"auth": map[string]interface{}{"token": "s3cr3t-token", "user": "admin"},
+	}
+	key := []byte("0123456789abcdef0123456789abcdef")
+	token := func(vals map[string]interface{}) string {
+		return vals["auth"].(map[string]interface{})["token"].(string)
+	}
+
+	hashed := HashSensitiveValues(c, vals, key)
+	assert.Regexp(t, `^\[REDACTED hmac-sha256:[0-9a-f]{64}\]$`, token(hashed))
+	assert.Equal(t, "admin", hashed["auth"].(map[string]interface{})["user"])
+	assert.Equal(t, "s3cr3t-token", token(vals), "the values must not be altered")
+	assert.Equal(t, hashed, RedactValues(c, hashed), "hashed values must be kept when redacting")
+	assert.Equal(t, hashed, HashSensitiveValues(c, hashed, key), "hashed values must not be hashed again")
+
+	assert.Equal(t, token(hashed), token(HashSensitiveValues(c, vals, key)), "the same value and key must give the same digest")
+	changed := map[string]interface{}{
+		"auth": map[string]interface{}{"token": "n3w-t0ken", "user": "admin"},
+	}
+	assert.NotEqual(t, token(hashed), token(HashSensitiveValues(c, changed, key)), "a changed value must change its digest")
+	assert.NotEqual(t, token(hashed), token(HashSensitiveValues(c, vals, []byte("another key"))), "the digest must depend on the key")
+
+	assert.Equal(t, []string{"auth.token"}, HashedValuePaths(hashed))
+	assert.Empty(t, HashedValuePaths(vals))
+}
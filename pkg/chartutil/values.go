@@ -129,6 +129,19 @@ type ReleaseOptions struct {
 	Revision  int
 	IsUpgrade bool
 	IsInstall bool
+	// Previous describes the last stored revision of the release, if any.
+	Previous *PreviousRelease
+}
+
+// PreviousRelease holds the details of the last stored revision of a release
+// that are exposed to templates as .Release.Previous.
+type PreviousRelease struct {
+	// Values are the computed values the previous revision was rendered with.
+	Values Values
+	// ChartVersion is the version of the chart used by the previous revision.
+	ChartVersion string
+	// Revision is the revision number of the previous revision.
+	Revision int
 }
 
 // ToRenderValues composes the struct from the data coming from the Releases, Charts and Values files
@@ -155,6 +168,7 @@ func ToRenderValuesWithSchemaValidation(chrt *chart.Chart, chrtVals map[string]i
 			"IsInstall": options.IsInstall,
 			"Revision":  options.Revision,
 			"Service":   "Helm",
+			"Previous":  previousToRenderValues(options.Previous),
 		},
 	}
 
@@ -174,6 +188,23 @@ func ToRenderValuesWithSchemaValidation(chrt *chart.Chart, chrtVals map[string]i
 	return top, nil
 }
 
+// previousToRenderValues builds the .Release.Previous object. It is never nil so
+// that templates can safely reference its fields on install.
+func previousToRenderValues(prev *PreviousRelease) map[string]interface{} {
+	if prev == nil {
+		prev = &PreviousRelease{}
+	}
+	vals := prev.Values
+	if vals == nil {
+		vals = Values{}
+	}
+	return map[string]interface{}{
+		"Values":       vals,
+		"ChartVersion": prev.ChartVersion,
+		"Revision":     prev.Revision,
+	}
+}
+
 // istable is a special-purpose function to see if the present thing matches the definition of a YAML table.
 func istable(v interface{}) bool {
 	_, ok := v.(map[string]interface{})
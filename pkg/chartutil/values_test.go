@@ -152,6 +152,46 @@ func TestToRenderValues(t *testing.T) {
 	}
 }
 
+func TestToRenderValuesPrevious(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "test", Version: "0.2.0"},
+	}
+
+	res, err := ToRenderValues(c, nil, ReleaseOptions{Name: "test", IsInstall: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := res["Release"].(map[string]interface{})["Previous"].(map[string]interface{})
+	if len(prev["Values"].(Values)) != 0 || prev["ChartVersion"] != "" || prev["Revision"] != 0 {
+		t.Errorf("Expected empty previous release on install, got %v", prev)
+	}
+
+	o := ReleaseOptions{
+		Name:      "test",
+		Revision:  3,
+		IsUpgrade: true,
+		Previous: &PreviousRelease{
+			Values:       Values{"password": "s3cr3t"},
+			ChartVersion: "0.1.0",
+			Revision:     2,
+		},
+	}
+	res, err = ToRenderValues(c, nil, o, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev = res["Release"].(map[string]interface{})["Previous"].(map[string]interface{})
+	if pw := prev["Values"].(Values)["password"]; pw != "s3cr3t" {
+		t.Errorf("Expected previous password 's3cr3t', got %v", pw)
+	}
+	if prev["ChartVersion"] != "0.1.0" {
+		t.Errorf("Expected previous chart version '0.1.0', got %v", prev["ChartVersion"])
+	}
+	if prev["Revision"] != 2 {
+		t.Errorf("Expected previous revision 2, got %v", prev["Revision"])
+	}
+}
+
 func TestReadValuesFile(t *testing.T) {
 	data, err := ReadValuesFile("./testdata/coleridge.yaml")
 	if err != nil {
@@ -80,6 +80,15 @@ type EnvSettings struct {
 	RepositoryConfig string
 	// RepositoryCache is the path to the repository cache directory.
 	RepositoryCache string
+	// TrustPolicy is the path to the trust policy file, mapping repositories
+	// and registries to the verification of their charts.
+	TrustPolicy string
+	// ChartCache is the path to the cache of chart archives shared by the
+	// commands fetching charts.
+	ChartCache string
+	// Offline restricts the charts fetched to those of the chart cache,
+	// resolved from the cached indexes of chart repositories.
+	Offline bool
 	// PluginsDirectory is the path to the plugins directory.
 	PluginsDirectory string
 	// MaxHistory is the max release history maintained.
@@ -106,6 +115,9 @@ func New() *EnvSettings {
 		RegistryConfig:            envOr("HELM_REGISTRY_CONFIG", helmpath.ConfigPath("registry/config.json")),
 		RepositoryConfig:          envOr("HELM_REPOSITORY_CONFIG", helmpath.ConfigPath("repositories.yaml")),
 		RepositoryCache:           envOr("HELM_REPOSITORY_CACHE", helmpath.CachePath("repository")),
+		TrustPolicy:               envOr("HELM_TRUST_POLICY", helmpath.ConfigPath("trust-policy.yaml")),
+		ChartCache:                envOr("HELM_CHART_CACHE", helmpath.CachePath("charts")),
+		Offline:                   envBoolOr("HELM_OFFLINE", false),
 		BurstLimit:                envIntOr("HELM_BURST_LIMIT", defaultBurstLimit),
 		QPS:                       envFloat32Or("HELM_QPS", defaultQPS),
 	}
@@ -157,6 +169,9 @@ func (s *EnvSettings) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.RegistryConfig, "registry-config", s.RegistryConfig, "path to the registry config file")
 	fs.StringVar(&s.RepositoryConfig, "repository-config", s.RepositoryConfig, "path to the file containing repository names and URLs")
 	fs.StringVar(&s.RepositoryCache, "repository-cache", s.RepositoryCache, "path to the directory containing cached repository indexes")
+	fs.StringVar(&s.TrustPolicy, "trust-policy", s.TrustPolicy, "path to the file containing the verification required for charts of repositories and registries")
+	fs.StringVar(&s.ChartCache, "chart-cache", s.ChartCache, "path to the directory containing cached chart archives")
+	fs.BoolVar(&s.Offline, "offline", s.Offline, "fetch charts only from the chart cache, without reaching chart repositories or registries")
 	fs.IntVar(&s.BurstLimit, "burst-limit", s.BurstLimit, "client-side default throttling limit")
 	fs.Float32Var(&s.QPS, "qps", s.QPS, "queries per second used when communicating with the Kubernetes API, not including bursting")
 }
@@ -223,6 +238,9 @@ func (s *EnvSettings) EnvVars() map[string]string {
 		"HELM_REGISTRY_CONFIG":   s.RegistryConfig,
 		"HELM_REPOSITORY_CACHE":  s.RepositoryCache,
 		"HELM_REPOSITORY_CONFIG": s.RepositoryConfig,
+		"HELM_TRUST_POLICY":      s.TrustPolicy,
+		"HELM_CHART_CACHE":       s.ChartCache,
+		"HELM_OFFLINE":           fmt.Sprint(s.Offline),
 		"HELM_NAMESPACE":         s.Namespace(),
 		"HELM_MAX_HISTORY":       strconv.Itoa(s.MaxHistory),
 		"HELM_BURST_LIMIT":       strconv.Itoa(s.BurstLimit),
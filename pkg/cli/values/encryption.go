@@ -0,0 +1,225 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+const (
+	// EncryptedHeader starts the first line of encrypted values files. The ID
+	// of the key the file was encrypted with follows it.
+	EncryptedHeader = "$HELM_ENCRYPTED_VALUES;1;XCHACHA20-POLY1305;"
+
+	// EncryptedExtension is the extension of values files that must be
+	// encrypted.
+	EncryptedExtension = ".enc.yaml"
+
+	// KeyEnvVar is the environment variable holding a key, encoded in base64,
+	// to decrypt values files with.
+	KeyEnvVar = "HELM_VALUES_KEY"
+
+	// KeyringEnvVar is the environment variable holding the path of the
+	// keyring, overriding the default one.
+	KeyringEnvVar = "HELM_VALUES_KEYRING"
+
+	// encryptedLineLength is the length of the lines of base64 in encrypted
+	// values files.
+	encryptedLineLength = 76
+)
+
+// DefaultKeyring returns the path of the keyring holding the keys of
+// encrypted values files.
+func DefaultKeyring() string {
+	if keyring := os.Getenv(KeyringEnvVar); keyring != "" {
+		return keyring
+	}
+	return helmpath.ConfigPath("values.keyring")
+}
+
+// GenerateKey returns a new random key, encoded in base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// AddKey appends key, encoded in base64, to the keyring at path, creating it
+// if needed.
+func AddKey(path, key string) error {
+	if _, err := decodeKey(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadKeys returns the key from KeyEnvVar, if set, followed by the keys of the
+// keyring at path. A missing keyring holds no keys.
+//
+// Keyrings hold one key, encoded in base64, per line. Empty lines and lines
+// starting with '#' are ignored.
+func LoadKeys(path string) ([][]byte, error) {
+	var keys [][]byte
+	if env := os.Getenv(KeyEnvVar); env != "" {
+		key, err := decodeKey(env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key in %s", KeyEnvVar)
+		}
+		keys = append(keys, key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := decodeKey(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key on line %d of %s", n, path)
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, errors.Errorf("keys must be %d bytes long, got %d", chacha20poly1305.KeySize, len(key))
+	}
+	return key, nil
+}
+
+// keyID identifies a key in the header of encrypted values files without
+// revealing it.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// IsEncrypted returns whether data is an encrypted values file.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(EncryptedHeader))
+}
+
+// Encrypt encrypts the values file data with key, using XChaCha20-Poly1305.
+func Encrypt(data, key []byte) ([]byte, error) {
+	if IsEncrypted(data) {
+		return nil, errors.New("values are already encrypted")
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	header := EncryptedHeader + keyID(key)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, data, []byte(header))
+
+	var out bytes.Buffer
+	out.WriteString(header + "\n")
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	for len(encoded) > encryptedLineLength {
+		out.WriteString(encoded[:encryptedLineLength] + "\n")
+		encoded = encoded[encryptedLineLength:]
+	}
+	out.WriteString(encoded + "\n")
+	return out.Bytes(), nil
+}
+
+// FindKey returns the key among keys the encrypted values file data was
+// encrypted with.
+func FindKey(data []byte, keys [][]byte) ([]byte, error) {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	id := strings.TrimPrefix(strings.TrimRight(string(header), "\r"), EncryptedHeader)
+	for _, k := range keys {
+		if keyID(k) == id {
+			return k, nil
+		}
+	}
+	return nil, errors.Errorf("no key with ID %s found; set %s or add the key to the keyring", id, KeyEnvVar)
+}
+
+// Decrypt decrypts the encrypted values file data with the key among keys it
+// was encrypted with.
+func Decrypt(data []byte, keys [][]byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("values are not encrypted")
+	}
+	header, body, _ := bytes.Cut(data, []byte("\n"))
+	header = bytes.TrimRight(header, "\r")
+	key, err := FindKey(data, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed encrypted values")
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted values")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("unable to decrypt values: the file was altered")
+	}
+	return plain, nil
+}
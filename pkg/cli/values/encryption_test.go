@@ -0,0 +1,145 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+func testKeyring(t *testing.T) (string, []byte) {
+	t.Helper()
+	t.Setenv(KeyEnvVar, "")
+	keyring := filepath.Join(t.TempDir(), "values.keyring")
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddKey(keyring, key); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadKeys(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("Expected 1 key in the keyring, got %d", len(keys))
+	}
+	return keyring, keys[0]
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	_, key := testKeyring(t)
+	plain := []byte("password: hunter22\n")
+
+	encrypted, err := Encrypt(plain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) {
+		t.Fatal("Expected the encrypted values to start with the header")
+	}
+	if bytes.Contains(encrypted, []byte("hunter22")) {
+		t.Fatal("Expected the encrypted values not to hold the plain text")
+	}
+	if _, err := Encrypt(encrypted, key); err == nil {
+		t.Error("Expected encrypting encrypted values to fail")
+	}
+
+	decrypted, err := Decrypt(encrypted, [][]byte{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, decrypted) {
+		t.Errorf("Expected %q, got %q", plain, decrypted)
+	}
+
+	otherKey := bytes.Repeat([]byte{1}, len(key))
+	if _, err := Decrypt(encrypted, [][]byte{otherKey}); err == nil || !strings.Contains(err.Error(), "no key with ID") {
+		t.Errorf("Expected a missing key error, got %v", err)
+	}
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(EncryptedHeader)+20] ^= 1
+	if _, err := Decrypt(tampered, [][]byte{key}); err == nil {
+		t.Error("Expected decrypting altered values to fail")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	keyring, key := testKeyring(t)
+	envKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KeyEnvVar, envKey)
+	keys, err := LoadKeys(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || !bytes.Equal(keys[1], key) {
+		t.Errorf("Expected the key from %s followed by the keyring, got %d keys", KeyEnvVar, len(keys))
+	}
+
+	if err := os.WriteFile(keyring, []byte("# comment\n\nnot-a-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeys(keyring); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an invalid key error on line 3, got %v", err)
+	}
+
+	keys, err = LoadKeys(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected a missing keyring to hold no keys, got %d keys and %v", len(keys), err)
+	}
+}
+
+func TestMergeValuesEncrypted(t *testing.T) {
+	keyring, key := testKeyring(t)
+	dir := t.TempDir()
+
+	encrypted, err := Encrypt([]byte("password: hunter22\n"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := filepath.Join(dir, "secrets.enc.yaml")
+	if err := os.WriteFile(secrets, encrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Options{ValueFiles: []string{secrets}, Keyring: keyring}
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vals, map[string]interface{}{"password": "hunter22"}) {
+		t.Errorf("Expected the decrypted values, got %v", vals)
+	}
+
+	if err := os.WriteFile(secrets, []byte("password: hunter22\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opts.MergeValues(getter.Providers{}); err == nil || !strings.Contains(err.Error(), "is not encrypted") {
+		t.Errorf("Expected an error for a plain .enc.yaml file, got %v", err)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"sigs.k8s.io/yaml"
 
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/strvals"
 )
@@ -37,11 +38,35 @@ type Options struct {
 	FileValues    []string // --set-file
 	JSONValues    []string // --set-json
 	LiteralValues []string // --set-literal
+	// Keyring is the path of the keyring holding the keys of encrypted values
+	// files. DefaultKeyring is used when it is empty.
+	Keyring string
+	// MergeKeys are the merge keys of the chart the values are for. The lists
+	// with a merge key are merged item by item across values files instead
+	// of being replaced.
+	MergeKeys chartutil.MergeKeys
 }
 
 // MergeValues merges values from files specified via -f/--values and directly
 // via --set-json, --set, --set-string, or --set-file, marshaling them to YAML
 func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, error) {
+	return opts.mergeValues(p, nil)
+}
+
+// MergeValuesWithSources merges values like MergeValues, and also returns
+// every file and flag they were merged from, in the order they were applied.
+func (opts *Options) MergeValuesWithSources(p getter.Providers) (map[string]interface{}, []chartutil.UserValues, error) {
+	var sources []chartutil.UserValues
+	base, err := opts.mergeValues(p, func(u chartutil.UserValues) {
+		sources = append(sources, u)
+	})
+	return base, sources, err
+}
+
+// parser parses a single flag value into a map.
+type parser func(value string, dest map[string]interface{}) error
+
+func (opts *Options) mergeValues(p getter.Providers, record func(chartutil.UserValues)) (map[string]interface{}, error) {
 	base := map[string]interface{}{}
 
 	// User specified a values files via -f/--values
@@ -52,52 +77,89 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 		if err != nil {
 			return nil, err
 		}
+		if bytes, err = opts.decrypt(filePath, bytes); err != nil {
+			return nil, err
+		}
 
 		if err := yaml.Unmarshal(bytes, &currentMap); err != nil {
 			return nil, errors.Wrapf(err, "failed to parse %s", filePath)
 		}
+		if record != nil {
+			record(chartutil.UserValues{
+				Source: chartutil.ValueSource{Kind: chartutil.SourceFile, Name: filePath},
+				Values: currentMap,
+				Data:   bytes,
+			})
+		}
 		// Merge with the previous map
-		base = mergeMaps(base, currentMap)
+		base = mergeMaps(base, currentMap, opts.MergeKeys, "")
+	}
+
+	// parseFlag parses value into base and, when sources are recorded, on its own.
+	parseFlag := func(flag, value string, parse parser) error {
+		if err := parse(value, base); err != nil {
+			return err
+		}
+		if record != nil {
+			own := map[string]interface{}{}
+			if err := parse(value, own); err != nil {
+				return err
+			}
+			record(chartutil.UserValues{
+				Source: chartutil.ValueSource{Kind: chartutil.SourceFlag, Name: flag + " " + value},
+				Values: own,
+			})
+		}
+		return nil
 	}
 
 	// User specified a value via --set-json
 	for _, value := range opts.JSONValues {
-		if err := strvals.ParseJSON(value, base); err != nil {
+		if err := parseFlag("--set-json", value, strvals.ParseJSON); err != nil {
 			return nil, errors.Errorf("failed parsing --set-json data %s", value)
 		}
 	}
 
 	// User specified a value via --set
 	for _, value := range opts.Values {
-		if err := strvals.ParseInto(value, base); err != nil {
+		if err := parseFlag("--set", value, strvals.ParseInto); err != nil {
 			return nil, errors.Wrap(err, "failed parsing --set data")
 		}
 	}
 
 	// User specified a value via --set-string
 	for _, value := range opts.StringValues {
-		if err := strvals.ParseIntoString(value, base); err != nil {
+		if err := parseFlag("--set-string", value, strvals.ParseIntoString); err != nil {
 			return nil, errors.Wrap(err, "failed parsing --set-string data")
 		}
 	}
 
 	// User specified a value via --set-file
 	for _, value := range opts.FileValues {
+		// Files are read once even when the flag is parsed twice.
+		contents := map[string]interface{}{}
 		reader := func(rs []rune) (interface{}, error) {
+			if content, ok := contents[string(rs)]; ok {
+				return content, nil
+			}
 			bytes, err := readFile(string(rs), p)
 			if err != nil {
 				return nil, err
 			}
+			contents[string(rs)] = string(bytes)
 			return string(bytes), err
 		}
-		if err := strvals.ParseIntoFile(value, base, reader); err != nil {
+		parseFile := func(value string, dest map[string]interface{}) error {
+			return strvals.ParseIntoFile(value, dest, reader)
+		}
+		if err := parseFlag("--set-file", value, parseFile); err != nil {
 			return nil, errors.Wrap(err, "failed parsing --set-file data")
 		}
 	}
 
 	// User specified a value via --set-literal
 	for _, value := range opts.LiteralValues {
-		if err := strvals.ParseLiteralInto(value, base); err != nil {
+		if err := parseFlag("--set-literal", value, strvals.ParseLiteralInto); err != nil {
 			return nil, errors.Wrap(err, "failed parsing --set-literal data")
 		}
 	}
@@ -105,20 +167,54 @@ func (opts *Options) MergeValues(p getter.Providers) (map[string]interface{}, er
 	return base, nil
 }
 
-func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+// decrypt returns the decrypted contents of the values file at filePath when
+// it is encrypted, and data as is otherwise.
+func (opts *Options) decrypt(filePath string, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		if strings.HasSuffix(filePath, EncryptedExtension) {
+			return nil, errors.Errorf("%s is not encrypted", filePath)
+		}
+		return data, nil
+	}
+	keyring := opts.Keyring
+	if keyring == "" {
+		keyring = DefaultKeyring()
+	}
+	keys, err := LoadKeys(keyring)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Decrypt(data, keys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", filePath)
+	}
+	return plain, nil
+}
+
+// mergeMaps merges b into a, b taking precedence. The lists at prefix with a
+// key in keys are merged item by item.
+func mergeMaps(a, b map[string]interface{}, keys chartutil.MergeKeys, prefix string) map[string]interface{} {
 	out := make(map[string]interface{}, len(a))
 	for k, v := range a {
 		out[k] = v
 	}
 	for k, v := range b {
+		fullkey := k
+		if prefix != "" {
+			fullkey = prefix + "." + k
+		}
 		if v, ok := v.(map[string]interface{}); ok {
 			if bv, ok := out[k]; ok {
 				if bv, ok := bv.(map[string]interface{}); ok {
-					out[k] = mergeMaps(bv, v)
+					out[k] = mergeMaps(bv, v, keys, fullkey)
 					continue
 				}
 			}
 		}
+		if list, ok := keys.MergeLists(fullkey, v, out[k]); ok {
+			out[k] = list
+			continue
+		}
 		out[k] = v
 	}
 	return out
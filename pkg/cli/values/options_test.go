@@ -17,9 +17,12 @@ limitations under the License.
 package values
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/getter"
 )
 
@@ -45,25 +48,25 @@ func TestMergeValues(t *testing.T) {
 		"testing": "fun",
 	}
 
-	testMap := mergeMaps(flatMap, nestedMap)
+	testMap := mergeMaps(flatMap, nestedMap, nil, "")
 	equal := reflect.DeepEqual(testMap, nestedMap)
 	if !equal {
 		t.Errorf("Expected a nested map to overwrite a flat value. Expected: %v, got %v", nestedMap, testMap)
 	}
 
-	testMap = mergeMaps(nestedMap, flatMap)
+	testMap = mergeMaps(nestedMap, flatMap, nil, "")
 	equal = reflect.DeepEqual(testMap, flatMap)
 	if !equal {
 		t.Errorf("Expected a flat value to overwrite a map. Expected: %v, got %v", flatMap, testMap)
 	}
 
-	testMap = mergeMaps(nestedMap, anotherNestedMap)
+	testMap = mergeMaps(nestedMap, anotherNestedMap, nil, "")
 	equal = reflect.DeepEqual(testMap, anotherNestedMap)
 	if !equal {
 		t.Errorf("Expected a nested map to overwrite another nested map. Expected: %v, got %v", anotherNestedMap, testMap)
 	}
 
-	testMap = mergeMaps(anotherFlatMap, anotherNestedMap)
+	testMap = mergeMaps(anotherFlatMap, anotherNestedMap, nil, "")
 	expectedMap := map[string]interface{}{
 		"testing": "fun",
 		"foo":     "bar",
@@ -78,6 +81,56 @@ func TestMergeValues(t *testing.T) {
 	}
 }
 
+func TestMergeValuesWithMergeKeys(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `
+env:
+  - name: LOG_LEVEL
+    value: info
+  - name: REGION
+    value: eu
+args: [--verbose]
+`,
+		"prod.yaml": `
+env:
+  - name: LOG_LEVEL
+    value: warn
+  - name: REGION
+    $patch: delete
+  - name: REPLICAS
+    value: "3"
+args: [--quiet]
+`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &Options{
+		ValueFiles: []string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "prod.yaml")},
+		MergeKeys:  chartutil.MergeKeys{"env": "name"},
+	}
+	vals, err := opts.MergeValues(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The deletion marker is kept to apply to the chart's default values.
+	expect := map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": "LOG_LEVEL", "value": "warn"},
+			map[string]interface{}{"name": "REGION", "$patch": "delete"},
+			map[string]interface{}{"name": "REPLICAS", "value": "3"},
+		},
+		"args": []interface{}{"--quiet"},
+	}
+	if !reflect.DeepEqual(vals, expect) {
+		t.Errorf("Expected values %v, got %v", expect, vals)
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	var p getter.Providers
 	filePath := "%a.txt"
@@ -86,3 +139,27 @@ func TestReadFile(t *testing.T) {
 		t.Errorf("Expected error when has special strings")
 	}
 }
+
+func TestMergeValuesWithSources(t *testing.T) {
+	opts := &Options{
+		Values:       []string{"a=1,b.c=x"},
+		StringValues: []string{"b.d=2"},
+	}
+	vals, sources, err := opts.MergeValuesWithSources(getter.Providers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{"a": int64(1), "b": map[string]interface{}{"c": "x", "d": "2"}}
+	if !reflect.DeepEqual(vals, expect) {
+		t.Errorf("Expected values %v, got %v", expect, vals)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("Expected 2 sources, got %d", len(sources))
+	}
+	if sources[0].Source.Name != "--set a=1,b.c=x" || sources[1].Source.Name != "--set-string b.d=2" {
+		t.Errorf("Unexpected sources %v, %v", sources[0].Source, sources[1].Source)
+	}
+	if !reflect.DeepEqual(sources[1].Values, map[string]interface{}{"b": map[string]interface{}{"d": "2"}}) {
+		t.Errorf("Expected --set-string source to hold only its own values, got %v", sources[1].Values)
+	}
+}
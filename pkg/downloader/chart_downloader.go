@@ -16,6 +16,7 @@ limitations under the License.
 package downloader
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/url"
@@ -26,6 +27,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/internal/chartcache"
 	"helm.sh/helm/v3/internal/fileutil"
 	"helm.sh/helm/v3/internal/urlutil"
 	"helm.sh/helm/v3/pkg/getter"
@@ -66,6 +68,9 @@ type ChartDownloader struct {
 	Verify VerificationStrategy
 	// Keyring is the keyring file used for verification.
 	Keyring string
+	// TrustPolicy is the path to the trust policy file. The policy of the
+	// source of a chart may raise Verify, and replace Keyring.
+	TrustPolicy string
 	// Getter collection for the operation
 	Getters getter.Providers
 	// Options provide parameters to be passed along to the Getter being initialized.
@@ -73,6 +78,27 @@ type ChartDownloader struct {
 	RegistryClient   *registry.Client
 	RepositoryConfig string
 	RepositoryCache  string
+	// ChartCache is the path to the cache of chart archives, consulted before
+	// downloading charts and filled with the downloaded ones. Empty disables
+	// the cache.
+	ChartCache string
+	// Offline restricts the charts to those of the chart cache, resolved from
+	// the cached indexes of chart repositories or by reference.
+	Offline bool
+	// Debug enables the debug messages, such as the URLs charts are fetched
+	// from, written to Out.
+	Debug bool
+
+	// digest is the digest of the chart resolved by ResolveChartVersion from
+	// the index of a chart repository, if any.
+	digest string
+	// lockDigest is the digest of the chart in the lock file of the chart
+	// depending on it, if any, which takes precedence over digest.
+	lockDigest string
+	// repoEntry is the chart repository of the chart resolved by
+	// ResolveChartVersion, if any, whose mirrors are used when it is
+	// unavailable.
+	repoEntry *repo.Entry
 }
 
 // DownloadTo retrieves a chart. Depending on the settings, it may also download a provenance file.
@@ -82,6 +108,11 @@ type ChartDownloader struct {
 // If Verify is set to VerifyAlways, this will return a verification or an error if the verification fails.
 // If Verify is set to VerifyLater, this will download the prov file (if it exists), but not verify it.
 //
+// The trust policy of the source of the chart, if any, takes precedence over
+// weaker strategies: charts from sources at the enforce level are verified as
+// with VerifyAlways, and charts from sources at the warn level are verified with
+// failures reported as warnings.
+//
 // For VerifyNever and VerifyIfPossible, the Verification may be empty.
 //
 // Returns a string path to the location where the file was downloaded and a verification
@@ -96,14 +127,23 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 	if err != nil {
 		return "", nil, err
 	}
+	if c.repoEntry != nil && len(c.repoEntry.Mirrors) > 0 {
+		g = &mirroredGetter{Getter: g, entry: c.repoEntry, getters: c.Getters, logf: c.debugf}
+	}
 
-	data, err := g.Get(u.String(), c.Options...)
+	verify, keyring, warnOnly, err := c.verification(u)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fetch := c.cachedGetter(g, u)
+	data, err := fetch(u.String())
 	if err != nil {
 		return "", nil, err
 	}
 
 	name := filepath.Base(u.Path)
-	if u.Scheme == registry.OCIScheme {
+	if u.Scheme == registry.OCIScheme || u.Scheme == registry.OCILayoutScheme {
 		idx := strings.LastIndexByte(name, ':')
 		name = fmt.Sprintf("%s-%s.tgz", name[:idx], name[idx+1:])
 	}
@@ -115,10 +155,10 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 
 	// If provenance is requested, verify it.
 	ver := &provenance.Verification{}
-	if c.Verify > VerifyNever {
-		body, err := g.Get(u.String() + ".prov")
+	if verify > VerifyNever {
+		body, err := fetch(u.String() + ".prov")
 		if err != nil {
-			if c.Verify == VerifyAlways {
+			if verify == VerifyAlways {
 				return destfile, ver, errors.Errorf("failed to fetch provenance %q", u.String()+".prov")
 			}
 			fmt.Fprintf(c.Out, "WARNING: Verification not found for %s: %s\n", ref, err)
@@ -129,9 +169,13 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 			return destfile, nil, err
 		}
 
-		if c.Verify != VerifyLater {
-			ver, err = VerifyChart(destfile, c.Keyring)
+		if verify != VerifyLater {
+			ver, err = VerifyChart(destfile, keyring)
 			if err != nil {
+				if warnOnly {
+					fmt.Fprintf(c.Out, "WARNING: Verification failed for %s: %s\n", ref, err)
+					return destfile, &provenance.Verification{}, nil
+				}
 				// Fail always in this case, since it means the verification step
 				// failed.
 				return destfile, ver, err
@@ -141,6 +185,105 @@ func (c *ChartDownloader) DownloadTo(ref, version, dest string) (string, *proven
 	return destfile, ver, nil
 }
 
+// cachedGetter returns the function getting the chart at u and its provenance
+// file with g, through the chart cache.
+//
+// Charts are looked up in the cache by the digest of the index of their chart
+// repository, or, offline, by URL. Registries are left to their getter, which
+// looks up the digests of their manifests.
+func (c *ChartDownloader) cachedGetter(g getter.Getter, u *url.URL) func(string) (*bytes.Buffer, error) {
+	if c.ChartCache == "" || u.Scheme == registry.OCILayoutScheme {
+		return func(href string) (*bytes.Buffer, error) {
+			return g.Get(href, c.Options...)
+		}
+	}
+	if u.Scheme == registry.OCIScheme {
+		options := append([]getter.Option{getter.WithChartCache(c.ChartCache), getter.WithOffline(c.Offline)}, c.Options...)
+		return func(href string) (*bytes.Buffer, error) {
+			return g.Get(href, options...)
+		}
+	}
+
+	cache := chartcache.New(c.ChartCache)
+	ref := u.String()
+	digest := c.lockDigest
+	if digest == "" {
+		digest = c.digest
+	}
+	if digest == "" && c.Offline {
+		digest, _ = cache.Resolve(ref)
+	}
+	return func(href string) (*bytes.Buffer, error) {
+		isProv := href != ref
+		if digest != "" {
+			var data []byte
+			var err error
+			if isProv {
+				data, err = cache.GetProvenance(digest)
+			} else {
+				data, err = cache.Get(digest)
+			}
+			if err == nil {
+				return bytes.NewBuffer(data), nil
+			}
+		}
+		if c.Offline {
+			return nil, errors.Wrapf(chartcache.ErrNotFound, "unable to get %s offline", href)
+		}
+
+		data, err := g.Get(href, c.Options...)
+		if err != nil {
+			return nil, err
+		}
+		// The cache only saves downloads, failing to write to it is not an error.
+		if isProv {
+			if digest != "" {
+				cache.PutProvenance(digest, data.Bytes())
+			}
+		} else if d, err := cache.Put(data.Bytes()); err == nil {
+			digest = d
+			cache.Link(ref, d)
+		}
+		return data, nil
+	}
+}
+
+func (c *ChartDownloader) debugf(format string, v ...interface{}) {
+	if c.Debug {
+		fmt.Fprintf(c.Out, format+"\n", v...)
+	}
+}
+
+// verification returns the verification strategy and the keyring for the chart
+// at u, after applying the trust policy. Verification failures are only to be
+// warned about when warnOnly is true.
+func (c *ChartDownloader) verification(u *url.URL) (verify VerificationStrategy, keyring string, warnOnly bool, err error) {
+	policy, err := LoadTrustPolicy(c.TrustPolicy)
+	if err != nil {
+		return c.Verify, c.Keyring, false, err
+	}
+	var repoName string
+	if c.repoEntry != nil {
+		repoName = c.repoEntry.Name
+	}
+	source := policy.SourceFor(u.String(), repoName, c.RepositoryConfig)
+	if source == nil {
+		return c.Verify, c.Keyring, false, nil
+	}
+
+	keyring = c.Keyring
+	if source.Keyring != "" {
+		keyring = source.Keyring
+	}
+	switch {
+	case source.Level == TrustLevelEnforce, source.Level == TrustLevelWarn && c.Verify == VerifyAlways:
+		return VerifyAlways, keyring, false, nil
+	case source.Level == TrustLevelWarn:
+		return VerifyIfPossible, keyring, true, nil
+	}
+	return c.Verify, keyring, false, nil
+}
+
 func (c *ChartDownloader) getOciURI(ref, version string, u *url.URL) (*url.URL, error) {
 	var tag string
 	var err error
@@ -149,6 +292,8 @@ func (c *ChartDownloader) getOciURI(ref, version string, u *url.URL) (*url.URL,
 	_, errSemVer := semver.NewVersion(version)
 	if errSemVer == nil {
 		tag = version
+	} else if c.Offline {
+		return nil, errors.Errorf("unable to look up the versions of %s offline, an exact version is required", ref)
 	} else {
 		// Retrieve list of repository tags
 		tags, err := c.RegistryClient.Tags(strings.TrimPrefix(ref, fmt.Sprintf("%s://", registry.OCIScheme)))
@@ -174,13 +319,43 @@ func (c *ChartDownloader) getOciURI(ref, version string, u *url.URL) (*url.URL,
 	return u, err
 }
 
+// getOCILayoutURI returns the URL of the chart at ref, in an OCI image layout
+// directory, with the version of the chart matching the given version or
+// constraint. The version of the reference, if any, takes precedence.
+func (c *ChartDownloader) getOCILayoutURI(ref, version string, u *url.URL) (*url.URL, error) {
+	dir, name, tag, err := registry.ParseLayoutReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		return u, nil
+	}
+
+	layout, err := registry.LoadLayoutDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	tags := layout.Tags(name)
+	if len(tags) == 0 {
+		return nil, errors.Errorf("Unable to locate any versions of chart %s in OCI layout %s", name, dir)
+	}
+	tag, err = registry.GetTagMatchingVersionOrConstraint(tags, version)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = fmt.Sprintf("%s:%s", u.Path, tag)
+	return u, nil
+}
+
 // ResolveChartVersion resolves a chart reference to a URL.
 //
 // It returns the URL and sets the ChartDownloader's Options that can fetch
 // the URL using the appropriate Getter.
 //
-// A reference may be an HTTP URL, an oci reference URL, a 'reponame/chartname'
-// reference, or a local path.
+// A reference may be an HTTP URL, an oci reference URL, an oci-layout URL of a
+// chart in an OCI image layout directory, a 'reponame/chartname' reference, or
+// a local path.
 //
 // A version is a SemVer string (1.2.3-beta.1+f334a6789).
 //
@@ -190,6 +365,8 @@ func (c *ChartDownloader) getOciURI(ref, version string, u *url.URL) (*url.URL,
 //   - If version is empty, this will return the URL for the latest version
 //   - If no version can be found, an error is returned
 func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, error) {
+	c.digest = ""
+	c.repoEntry = nil
 	u, err := url.Parse(ref)
 	if err != nil {
 		return nil, errors.Errorf("invalid chart URL format: %s", ref)
@@ -198,6 +375,9 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 	if registry.IsOCI(u.String()) {
 		return c.getOciURI(ref, version, u)
 	}
+	if registry.IsOCILayout(u.String()) {
+		return c.getOCILayoutURI(ref, version, u)
+	}
 
 	rf, err := loadRepoConfig(c.RepositoryConfig)
 	if err != nil {
@@ -225,6 +405,7 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 
 		// If we get here, we don't need to go through the next phase of looking
 		// up the URL. We have it already. So we just set the parameters and return.
+		c.repoEntry = rc
 		c.Options = append(
 			c.Options,
 			getter.WithURL(rc.URL),
@@ -292,6 +473,8 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 	if len(cv.URLs) == 0 {
 		return u, errors.Errorf("chart %q has no downloadable URLs", ref)
 	}
+	c.digest = cv.Digest
+	c.repoEntry = rc
 
 	// TODO: Seems that picking first URL is not fully correct
 	resolvedURL, err := repo.ResolveReferenceURL(rc.URL, cv.URLs[0])
@@ -307,6 +490,10 @@ func (c *ChartDownloader) ResolveChartVersion(ref, version string) (*url.URL, er
 //
 // It assumes that a chart archive file is accompanied by a provenance file whose
 // name is the archive file name plus the ".prov" extension.
+//
+// If the provenance file holds an SSH signature, keyring is the path of an
+// allowed signers file, in the format of 'ssh-keygen -Y verify', rather than
+// a PGP keyring.
 func VerifyChart(path, keyring string) (*provenance.Verification, error) {
 	// For now, error out if it's not a tar file.
 	switch fi, err := os.Stat(path); {
@@ -323,6 +510,18 @@ func VerifyChart(path, keyring string) (*provenance.Verification, error) {
 		return nil, errors.Wrapf(err, "could not load provenance file %s", provfile)
 	}
 
+	data, err := os.ReadFile(provfile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load provenance file %s", provfile)
+	}
+	if provenance.IsSSHSigned(data) {
+		sig, err := provenance.NewSSHFromAllowedSigners(keyring)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load allowed signers")
+		}
+		return sig.Verify(path, provfile)
+	}
+
 	sig, err := provenance.NewFromKeyring(keyring, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load keyring")
@@ -387,6 +586,7 @@ func (c *ChartDownloader) scanReposForURL(u string, rf *repo.File) (*repo.Entry,
 			for _, ver := range entry {
 				for _, dl := range ver.URLs {
 					if urlutil.Equal(u, dl) {
+						c.digest = ver.Digest
 						return rc, nil
 					}
 				}
@@ -16,13 +16,20 @@ limitations under the License.
 package downloader
 
 import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"helm.sh/helm/v3/internal/test/ensure"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/pusher"
 	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/repo/repotest"
 )
@@ -216,6 +223,193 @@ func TestDownloadTo(t *testing.T) {
 	}
 }
 
+func TestDownloadTo_ChartCache(t *testing.T) {
+	srv := repotest.NewTempServerWithCleanupAndBasicAuth(t, "testdata/*.tgz*")
+	defer srv.Stop()
+	if err := srv.CreateIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := ChartDownloader{
+		Out:              os.Stderr,
+		Verify:           VerifyAlways,
+		Keyring:          "testdata/helm-test-key.pub",
+		RepositoryConfig: repoConfig,
+		RepositoryCache:  repoCache,
+		ChartCache:       t.TempDir(),
+		Getters: getter.All(&cli.EnvSettings{
+			RepositoryConfig: repoConfig,
+			RepositoryCache:  repoCache,
+		}),
+		Options: []getter.Option{
+			getter.WithBasicAuth("username", "password"),
+		},
+	}
+	u := srv.URL() + "/signtest-0.1.0.tgz"
+	if _, _, err := c.DownloadTo(u, "", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chart and its provenance file are fetched from the cache.
+	srv.Stop()
+	c.Offline = true
+	dest := t.TempDir()
+	where, v, err := c.DownloadTo(u, "", dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := filepath.Join(dest, "signtest-0.1.0.tgz"); where != expect {
+		t.Errorf("Expected download to %s, got %s", expect, where)
+	}
+	if v.FileHash == "" {
+		t.Error("File hash was empty, but verification is required.")
+	}
+
+	if _, _, err := c.DownloadTo(srv.URL()+"/local-subchart-0.1.0.tgz", "", t.TempDir()); err == nil {
+		t.Error("Expected an error downloading an uncached chart offline")
+	}
+}
+
+func TestDownloadTo_Mirrors(t *testing.T) {
+	srv := repotest.NewTempServerWithCleanupAndBasicAuth(t, "testdata/*.tgz*")
+	defer srv.Stop()
+
+	// The repository is down, and its first mirror cannot be reached.
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	dir := t.TempDir()
+	rf := repo.NewFile()
+	entry := &repo.Entry{
+		Name:               "test",
+		URL:                down.URL + "/charts",
+		Username:           "username",
+		Password:           "password",
+		PassCredentialsAll: true,
+		Mirrors:            []string{unreachable.URL, srv.URL()},
+	}
+	rf.Add(entry)
+	if err := rf.WriteFile(filepath.Join(dir, "repositories.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index, err := repo.IndexDirectory(srv.Root(), down.URL+"/charts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(filepath.Join(dir, helmpath.CacheIndexFile("test")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := ChartDownloader{
+		Out:              &out,
+		Verify:           VerifyAlways,
+		Keyring:          "testdata/helm-test-key.pub",
+		RepositoryConfig: filepath.Join(dir, "repositories.yaml"),
+		RepositoryCache:  dir,
+		Debug:            true,
+		Getters: getter.All(&cli.EnvSettings{
+			RepositoryConfig: filepath.Join(dir, "repositories.yaml"),
+			RepositoryCache:  dir,
+		}),
+	}
+	dest := t.TempDir()
+	where, v, err := c.DownloadTo("test/signtest", "0.1.0", dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := filepath.Join(dest, "signtest-0.1.0.tgz"); where != expect {
+		t.Errorf("Expected download to %s, got %s", expect, where)
+	}
+	if v.FileHash == "" {
+		t.Error("File hash was empty, but verification is required.")
+	}
+
+	// The unavailable endpoints are only tried for the chart.
+	for _, expect := range []string{
+		fmt.Sprintf("Unable to fetch %s/charts/signtest-0.1.0.tgz from %s/charts", down.URL, down.URL),
+		fmt.Sprintf("Unable to fetch %s/charts/signtest-0.1.0.tgz from %s", down.URL, unreachable.URL),
+		fmt.Sprintf("Fetched %s/charts/signtest-0.1.0.tgz from %s\n", down.URL, srv.URL()),
+		fmt.Sprintf("Fetched %s/charts/signtest-0.1.0.tgz.prov from %s\n", down.URL, srv.URL()),
+	} {
+		if !strings.Contains(out.String(), expect) {
+			t.Errorf("Expected %q in the output, got %q", expect, out.String())
+		}
+	}
+	if n := strings.Count(out.String(), "Unable to fetch"); n != 2 {
+		t.Errorf("Expected 2 failed attempts, got %d: %q", n, out.String())
+	}
+
+	// The credentials are not sent to the mirrors without PassCredentialsAll.
+	entry.PassCredentialsAll = false
+	if err := rf.WriteFile(filepath.Join(dir, "repositories.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c.Getters = getter.All(&cli.EnvSettings{
+		RepositoryConfig: filepath.Join(dir, "repositories.yaml"),
+		RepositoryCache:  dir,
+	})
+	var authorized bool
+	srv.WithMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _, ok := r.BasicAuth()
+		authorized = authorized || ok
+	}))
+	if _, _, err := c.DownloadTo("test/signtest", "0.1.0", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if authorized {
+		t.Error("Expected no credentials sent to the mirror")
+	}
+}
+
+func TestDownloadTo_OCILayout(t *testing.T) {
+	layoutDir := filepath.Join(t.TempDir(), "layout")
+	p, err := pusher.NewOCILayoutPusher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Push("testdata/signtest-0.1.0.tgz", "oci-layout://"+layoutDir); err != nil {
+		t.Fatal(err)
+	}
+
+	c := ChartDownloader{
+		Out:              os.Stderr,
+		Verify:           VerifyAlways,
+		Keyring:          "testdata/helm-test-key.pub",
+		RepositoryConfig: repoConfig,
+		RepositoryCache:  repoCache,
+		Getters: getter.All(&cli.EnvSettings{
+			RepositoryConfig: repoConfig,
+			RepositoryCache:  repoCache,
+		}),
+	}
+	ref := "oci-layout://" + layoutDir + "/signtest"
+	for _, version := range []string{"", "0.1.0", "^0.1"} {
+		dest := t.TempDir()
+		where, v, err := c.DownloadTo(ref, version, dest)
+		if err != nil {
+			t.Fatalf("version %q: %s", version, err)
+		}
+		if expect := filepath.Join(dest, "signtest-0.1.0.tgz"); where != expect {
+			t.Errorf("Expected download to %s, got %s", expect, where)
+		}
+		if v.FileHash == "" {
+			t.Error("File hash was empty, but verification is required.")
+		}
+	}
+
+	if _, _, err := c.DownloadTo(ref, "0.2.0", t.TempDir()); err == nil {
+		t.Error("Expected an error for a missing version")
+	}
+	if _, _, err := c.DownloadTo("oci-layout://"+layoutDir+"/missing", "", t.TempDir()); err == nil {
+		t.Error("Expected an error for a missing chart")
+	}
+}
+
 func TestDownloadTo_TLS(t *testing.T) {
 	// Set up mock server w/ tls enabled
 	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
@@ -16,6 +16,7 @@ limitations under the License.
 package downloader
 
 import (
+	"bytes"
 	"crypto"
 	"encoding/hex"
 	"fmt"
@@ -45,6 +46,10 @@ import (
 	"helm.sh/helm/v3/pkg/repo"
 )
 
+// DefaultMaxConcurrentDownloads is the default maximum number of dependencies
+// downloaded at the same time.
+const DefaultMaxConcurrentDownloads = 8
+
 // ErrRepoNotFound indicates that chart repositories can't be found in local repo cache.
 // The value of Repos is missing repos.
 type ErrRepoNotFound struct {
@@ -68,8 +73,23 @@ type Manager struct {
 	Debug bool
 	// Keyring is the key ring file.
 	Keyring string
+	// TrustPolicy is the path to the trust policy file, which may raise the
+	// verification of dependencies from some sources.
+	TrustPolicy string
 	// SkipUpdate indicates that the repository should not be updated first.
 	SkipUpdate bool
+	// KeepProvenance keeps the provenance files fetched along with the
+	// dependencies next to their archives in the charts directory.
+	KeepProvenance bool
+	// ChartCache is the path to the cache of chart archives, consulted before
+	// downloading dependencies and filled with the downloaded ones.
+	ChartCache string
+	// Offline restricts the dependencies to those of the chart cache and of the
+	// cached indexes of chart repositories, which are not updated.
+	Offline bool
+	// MaxConcurrentDownloads is the maximum number of dependencies downloaded
+	// at the same time. Values below 1 stand for DefaultMaxConcurrentDownloads.
+	MaxConcurrentDownloads int
 	// Getter collection for the operation
 	Getters          []getter.Provider
 	RegistryClient   *registry.Client
@@ -133,7 +153,7 @@ func (m *Manager) Build() error {
 		return err
 	}
 
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && !m.Offline {
 		// For each repo in the file, update the cached copy of that repo
 		if err := m.UpdateRepositories(); err != nil {
 			return err
@@ -154,6 +174,11 @@ func (m *Manager) Update() error {
 	if err != nil {
 		return err
 	}
+	// The dependencies in charts/ are about to be replaced, so only the chart
+	// itself must be compatible with this version of Helm.
+	if err := loader.CheckHelmVersion(&chart.Chart{Metadata: c.Metadata}); err != nil {
+		return err
+	}
 
 	// If no dependencies are found, we consider this a successful
 	// completion.
@@ -183,7 +208,7 @@ func (m *Manager) Update() error {
 
 	// For each of the repositories Helm is configured to know about, update
 	// the index information locally.
-	if !m.SkipUpdate {
+	if !m.SkipUpdate && !m.Offline {
 		if err := m.UpdateRepositories(); err != nil {
 			return err
 		}
@@ -232,11 +257,17 @@ func (m *Manager) loadChartDir() (*chart.Chart, error) {
 // This returns a lock file, which has all of the dependencies normalized to a specific version.
 func (m *Manager) resolve(req []*chart.Dependency, repoNames map[string]string) (*chart.Lock, error) {
 	res := resolver.New(m.ChartPath, m.RepositoryCache, m.RegistryClient)
+	res.Offline = m.Offline
 	return res.Resolve(req, repoNames)
 }
 
 // downloadAll takes a list of dependencies and downloads them into charts/
 //
+// Up to MaxConcurrentDownloads dependencies are fetched at the same time. The
+// messages about each dependency are written in the order of the list once all
+// of them are fetched, and the charts directory is only changed if all of them
+// were saved.
+//
 // It will delete versions of the chart that exist on disk and might cause
 // a conflict.
 func (m *Manager) downloadAll(deps []*chart.Dependency) error {
@@ -268,107 +299,215 @@ func (m *Manager) downloadAll(deps []*chart.Dependency) error {
 	defer os.RemoveAll(tmpPath)
 
 	fmt.Fprintf(m.Out, "Saving %d charts\n", len(deps))
-	var saveError error
-	churls := make(map[string]struct{})
-	for _, dep := range deps {
-		// No repository means the chart is in charts directory
-		if dep.Repository == "" {
-			fmt.Fprintf(m.Out, "Dependency %s did not declare a repository. Assuming it exists in the charts directory\n", dep.Name)
-			// NOTE: we are only validating the local dependency conforms to the constraints. No copying to tmpPath is necessary.
-			chartPath := filepath.Join(destPath, dep.Name)
-			ch, err := loader.LoadDir(chartPath)
-			if err != nil {
-				return fmt.Errorf("unable to load chart '%s': %v", chartPath, err)
-			}
 
-			constraint, err := semver.NewConstraint(dep.Version)
-			if err != nil {
-				return fmt.Errorf("dependency %s has an invalid version/constraint format: %s", dep.Name, err)
-			}
+	// Each dependency has its own output and error, so that they are reported
+	// in order whatever the order of the downloads.
+	outs := make([]bytes.Buffer, len(deps))
+	errs := make([]error, len(deps))
+	saved := make([]string, len(deps))
+	// Dependencies with the same chart URL are downloaded once, for the first
+	// of them.
+	firsts := make(map[string]int)
+	duplicates := make(map[int]int)
+	// Charts of the same git repository are checked out one at a time.
+	gitLocks := make(map[string]*sync.Mutex)
+
+	limit := make(chan struct{}, m.maxConcurrentDownloads())
+	var wg sync.WaitGroup
+	run := func(i int, download func(out io.Writer) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			errs[i] = download(&outs[i])
+		}()
+	}
 
-			v, err := semver.NewVersion(ch.Metadata.Version)
-			if err != nil {
-				return fmt.Errorf("invalid version %s for dependency %s: %s", dep.Version, dep.Name, err)
-			}
+	for i, dep := range deps {
+		out := &outs[i]
 
-			if !constraint.Check(v) {
-				saveError = fmt.Errorf("dependency %s at version %s does not satisfy the constraint %s", dep.Name, ch.Metadata.Version, dep.Version)
-				break
-			}
+		// No repository means the chart is in charts directory
+		if dep.Repository == "" {
+			fmt.Fprintf(out, "Dependency %s did not declare a repository. Assuming it exists in the charts directory\n", dep.Name)
+			errs[i] = checkLocalDependency(dep, destPath)
 			continue
 		}
 		if strings.HasPrefix(dep.Repository, "file://") {
 			if m.Debug {
-				fmt.Fprintf(m.Out, "Archiving %s from repo %s\n", dep.Name, dep.Repository)
+				fmt.Fprintf(out, "Archiving %s from repo %s\n", dep.Name, dep.Repository)
 			}
 			ver, err := tarFromLocalDir(m.ChartPath, dep.Name, dep.Repository, dep.Version, tmpPath)
 			if err != nil {
-				saveError = err
-				break
+				errs[i] = err
+				continue
 			}
 			dep.Version = ver
 			continue
 		}
+		if resolver.IsGitRepository(dep.Repository) {
+			src, err := resolver.ParseGitSource(dep.Repository)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			mu, ok := gitLocks[src.Remote]
+			if !ok {
+				mu = &sync.Mutex{}
+				gitLocks[src.Remote] = mu
+			}
+			run(i, func(out io.Writer) error {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintf(out, "Archiving %s from git repo %s\n", dep.Name, dep.Repository)
+				archive, ver, err := tarFromGit(m.RepositoryCache, dep.Name, dep.Repository, dep.Version, tmpPath)
+				if err != nil {
+					return err
+				}
+				dep.Version = ver
+				return lockDigest(dep, archive)
+			})
+			continue
+		}
 
 		// Any failure to resolve/download a chart should fail:
 		// https://github.com/helm/helm/issues/1439
 		churl, username, password, insecureskiptlsverify, passcredentialsall, caFile, certFile, keyFile, err := m.findChartURL(dep.Name, dep.Version, dep.Repository, repos)
 		if err != nil {
-			saveError = errors.Wrapf(err, "could not find %s", churl)
-			break
+			errs[i] = errors.Wrapf(err, "could not find %s", churl)
+			continue
 		}
 
-		if _, ok := churls[churl]; ok {
-			fmt.Fprintf(m.Out, "Already downloaded %s from repo %s\n", dep.Name, dep.Repository)
+		if first, ok := firsts[churl]; ok {
+			duplicates[i] = first
 			continue
 		}
+		firsts[churl] = i
+
+		run(i, func(out io.Writer) error {
+			fmt.Fprintf(out, "Downloading %s from repo %s\n", dep.Name, dep.Repository)
+
+			dl := ChartDownloader{
+				Out:              out,
+				Verify:           m.Verify,
+				Keyring:          m.Keyring,
+				TrustPolicy:      m.TrustPolicy,
+				RepositoryConfig: m.RepositoryConfig,
+				RepositoryCache:  m.RepositoryCache,
+				ChartCache:       m.ChartCache,
+				Offline:          m.Offline,
+				Debug:            m.Debug,
+				RegistryClient:   m.RegistryClient,
+				Getters:          m.Getters,
+				lockDigest:       dep.Digest,
+				Options: []getter.Option{
+					getter.WithBasicAuth(username, password),
+					getter.WithPassCredentialsAll(passcredentialsall),
+					getter.WithInsecureSkipVerifyTLS(insecureskiptlsverify),
+					getter.WithTLSClientConfig(certFile, keyFile, caFile),
+				},
+			}
 
-		fmt.Fprintf(m.Out, "Downloading %s from repo %s\n", dep.Name, dep.Repository)
-
-		dl := ChartDownloader{
-			Out:              m.Out,
-			Verify:           m.Verify,
-			Keyring:          m.Keyring,
-			RepositoryConfig: m.RepositoryConfig,
-			RepositoryCache:  m.RepositoryCache,
-			RegistryClient:   m.RegistryClient,
-			Getters:          m.Getters,
-			Options: []getter.Option{
-				getter.WithBasicAuth(username, password),
-				getter.WithPassCredentialsAll(passcredentialsall),
-				getter.WithInsecureSkipVerifyTLS(insecureskiptlsverify),
-				getter.WithTLSClientConfig(certFile, keyFile, caFile),
-			},
-		}
+			ref, version := churl, ""
+			if registry.IsOCI(churl) {
+				var err error
+				ref, version, err = parseOCIRef(churl)
+				if err != nil {
+					return errors.Wrapf(err, "could not parse OCI reference")
+				}
+				dl.Options = append(dl.Options,
+					getter.WithRegistryClient(m.RegistryClient),
+					getter.WithTagName(version))
+			}
 
-		version := ""
-		if registry.IsOCI(churl) {
-			churl, version, err = parseOCIRef(churl)
+			archive, _, err := dl.DownloadTo(ref, version, tmpPath)
 			if err != nil {
-				return errors.Wrapf(err, "could not parse OCI reference")
+				return errors.Wrapf(err, "could not download %s", ref)
 			}
-			dl.Options = append(dl.Options,
-				getter.WithRegistryClient(m.RegistryClient),
-				getter.WithTagName(version))
+			saved[i] = archive
+			return lockDigest(dep, archive)
+		})
+	}
+	wg.Wait()
+
+	for i, dep := range deps {
+		first, ok := duplicates[i]
+		if !ok || errs[first] != nil {
+			continue
 		}
+		fmt.Fprintf(&outs[i], "Already downloaded %s from repo %s\n", dep.Name, dep.Repository)
+		errs[i] = lockDigest(dep, saved[first])
+	}
 
-		if _, _, err = dl.DownloadTo(churl, version, tmpPath); err != nil {
-			saveError = errors.Wrapf(err, "could not download %s", churl)
-			break
+	var saveErrors []error
+	var messages []string
+	for i, dep := range deps {
+		m.Out.Write(outs[i].Bytes())
+		if errs[i] != nil {
+			fmt.Fprintln(m.Out, "Save error occurred: ", errs[i])
+			saveErrors = append(saveErrors, errs[i])
+			messages = append(messages, fmt.Sprintf("- %s: %s", dep.Name, errs[i]))
 		}
+	}
+	switch len(saveErrors) {
+	case 0:
+		// now we can move all downloaded charts to destPath and delete outdated dependencies
+		return m.safeMoveDeps(deps, tmpPath, destPath)
+	case 1:
+		return saveErrors[0]
+	}
+	return errors.Errorf("%d dependencies could not be saved:\n%s", len(saveErrors), strings.Join(messages, "\n"))
+}
 
-		churls[churl] = struct{}{}
+// maxConcurrentDownloads returns the number of dependencies downloaded at the
+// same time.
+func (m *Manager) maxConcurrentDownloads() int {
+	if m.MaxConcurrentDownloads < 1 {
+		return DefaultMaxConcurrentDownloads
 	}
+	return m.MaxConcurrentDownloads
+}
 
-	// TODO: this should probably be refactored to be a []error, so we can capture and provide more information rather than "last error wins".
-	if saveError == nil {
-		// now we can move all downloaded charts to destPath and delete outdated dependencies
-		if err := m.safeMoveDeps(deps, tmpPath, destPath); err != nil {
-			return err
-		}
-	} else {
-		fmt.Fprintln(m.Out, "Save error occurred: ", saveError)
-		return saveError
+// checkLocalDependency checks that the chart of dep in the charts directory
+// destPath conforms to its constraint.
+func checkLocalDependency(dep *chart.Dependency, destPath string) error {
+	// NOTE: we are only validating the local dependency conforms to the constraints. No copying to tmpPath is necessary.
+	chartPath := filepath.Join(destPath, dep.Name)
+	ch, err := loader.LoadDir(chartPath)
+	if err != nil {
+		return fmt.Errorf("unable to load chart '%s': %v", chartPath, err)
+	}
+
+	constraint, err := semver.NewConstraint(dep.Version)
+	if err != nil {
+		return fmt.Errorf("dependency %s has an invalid version/constraint format: %s", dep.Name, err)
+	}
+
+	v, err := semver.NewVersion(ch.Metadata.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version %s for dependency %s: %s", dep.Version, dep.Name, err)
+	}
+
+	if !constraint.Check(v) {
+		return fmt.Errorf("dependency %s at version %s does not satisfy the constraint %s", dep.Name, ch.Metadata.Version, dep.Version)
+	}
+	return nil
+}
+
+// lockDigest records the digest of the downloaded archive of dep in dep, to be
+// written to the lock file, or checks it against the digest read from the lock
+// file.
+func lockDigest(dep *chart.Dependency, archive string) error {
+	digest, err := resolver.HashArchive(archive)
+	if err != nil {
+		return err
+	}
+	if dep.Digest == "" {
+		dep.Digest = digest
+		return nil
+	}
+	if dep.Digest != digest {
+		return errors.Errorf("the archive of %s %s does not match the digest in the lock file: expected %s, got %s", dep.Name, dep.Version, dep.Digest, digest)
 	}
 	return nil
 }
@@ -415,23 +554,43 @@ func (m *Manager) safeMoveDeps(deps []*chart.Dependency, source, dest string) er
 		}
 	}
 
+	// Verify every chart before moving any, so that the charts directory is
+	// left untouched when one of them cannot be used.
+	var verified []string
 	for _, file := range sourceFiles {
 		if file.IsDir() {
 			continue
 		}
 		filename := file.Name()
 		sourcefile := filepath.Join(source, filename)
-		destfile := filepath.Join(dest, filename)
 		existsInSourceDirectory[filename] = true
-		if _, err := loader.LoadFile(sourcefile); err != nil {
+		if filepath.Ext(filename) == ".prov" {
+			continue
+		}
+		ch, err := loader.LoadFile(sourcefile)
+		if err != nil {
 			fmt.Fprintf(m.Out, "Could not verify %s for moving: %s (Skipping)", sourcefile, err)
 			continue
 		}
+		if err := loader.CheckHelmVersion(ch); err != nil {
+			return errors.Wrap(err, "unable to use dependency")
+		}
+		verified = append(verified, filename)
+	}
+
+	for _, filename := range verified {
+		sourcefile := filepath.Join(source, filename)
+		destfile := filepath.Join(dest, filename)
 		// NOTE: no need to delete the dest; os.Rename replaces it.
 		if err := fs.RenameWithFallback(sourcefile, destfile); err != nil {
 			fmt.Fprintf(m.Out, "Unable to move %s to charts dir %s (Skipping)", sourcefile, err)
 			continue
 		}
+		if m.KeepProvenance && existsInSourceDirectory[filename+".prov"] {
+			if err := fs.RenameWithFallback(sourcefile+".prov", destfile+".prov"); err != nil {
+				fmt.Fprintf(m.Out, "Unable to move %s to charts dir %s (Skipping)", sourcefile+".prov", err)
+			}
+		}
 	}
 
 	fmt.Fprintln(m.Out, "Deleting outdated charts")
@@ -439,6 +598,13 @@ func (m *Manager) safeMoveDeps(deps []*chart.Dependency, source, dest string) er
 	for _, file := range destFiles {
 		if !file.IsDir() && !existsInSourceDirectory[file.Name()] {
 			fname := filepath.Join(dest, file.Name())
+			// provenance file - deleted along with its chart
+			if archive := strings.TrimSuffix(fname, ".prov"); archive != fname {
+				if _, err := os.Stat(archive); os.IsNotExist(err) {
+					os.Remove(fname)
+				}
+				continue
+			}
 			ch, err := loader.LoadFile(fname)
 			if err != nil {
 				fmt.Fprintf(m.Out, "Could not verify %s for deletion: %s (Skipping)\n", fname, err)
@@ -471,8 +637,8 @@ func (m *Manager) hasAllRepos(deps []*chart.Dependency) error {
 	missing := []string{}
 Loop:
 	for _, dd := range deps {
-		// If repo is from local path or OCI, continue
-		if strings.HasPrefix(dd.Repository, "file://") || registry.IsOCI(dd.Repository) {
+		// If repo is from local path, OCI or git, continue
+		if strings.HasPrefix(dd.Repository, "file://") || registry.IsOCI(dd.Repository) || resolver.IsGitRepository(dd.Repository) {
 			continue
 		}
 
@@ -544,7 +710,7 @@ func (m *Manager) ensureMissingRepos(repoNames map[string]string, deps []*chart.
 	// repositories configured by the user. Here we update repos found in
 	// the dependencies that are not known to the user if update skipping
 	// is not configured.
-	if !m.SkipUpdate && len(ru) > 0 {
+	if !m.SkipUpdate && !m.Offline && len(ru) > 0 {
 		fmt.Fprintln(m.Out, "Getting updates for unmanaged Helm repositories...")
 		if err := m.parallelRepoUpdate(ru); err != nil {
 			return repoNames, err
@@ -589,7 +755,7 @@ func (m *Manager) resolveRepoNames(deps []*chart.Dependency) (map[string]string,
 			continue
 		}
 
-		if registry.IsOCI(dd.Repository) {
+		if registry.IsOCI(dd.Repository) || resolver.IsGitRepository(dd.Repository) {
 			reposMap[dd.Name] = dd.Repository
 			continue
 		}
@@ -668,6 +834,11 @@ func (m *Manager) parallelRepoUpdate(repos []*repo.Entry) error {
 			return err
 		}
 		r.CachePath = m.RepositoryCache
+		if m.Debug {
+			r.Log = func(format string, v ...interface{}) {
+				fmt.Fprintf(m.Out, format+"\n", v...)
+			}
+		}
 		wg.Add(1)
 		go func(r *repo.ChartRepository) {
 			if _, err := r.DownloadIndexFile(); err != nil {
@@ -889,6 +1060,41 @@ func tarFromLocalDir(chartpath, name, repo, version, destPath string) (string, e
 	return "", errors.Errorf("can't get a valid version for dependency %s", name)
 }
 
+// tarFromGit archives a dep chart from the git repository repo, whose clone
+// is cached in cachepath, into destPath. The archive is reproducible, so that
+// it matches the digest of the dependency in the lock file.
+func tarFromGit(cachepath, name, repo, version, destPath string) (string, string, error) {
+	src, err := resolver.ParseGitSource(repo)
+	if err != nil {
+		return "", "", err
+	}
+	origPath, _, err := src.Checkout(cachepath)
+	if err != nil {
+		return "", "", err
+	}
+
+	ch, err := loader.LoadDir(origPath)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "unable to load chart %s from %s", name, repo)
+	}
+
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "dependency %s has an invalid version/constraint format", name)
+	}
+
+	v, err := semver.NewVersion(ch.Metadata.Version)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !constraint.Check(v) {
+		return "", "", errors.Errorf("can't get a valid version for dependency %s", name)
+	}
+	saved, err := chartutil.SaveWithOptions(ch, destPath, chartutil.SaveOptions{Reproducible: true})
+	return saved, ch.Metadata.Version, err
+}
+
 // The prefix to use for cache keys created by the manager for repo names
 const managerKeyPrefix = "helm-manager-"
 
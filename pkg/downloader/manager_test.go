@@ -17,14 +17,19 @@ package downloader
 
 import (
 	"bytes"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
+	"helm.sh/helm/v3/internal/resolver"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo/repotest"
 )
@@ -358,6 +363,238 @@ func TestUpdateBeforeBuild(t *testing.T) {
 	}
 }
 
+func TestDownloadAllConcurrently(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	chartPath := t.TempDir()
+	var out bytes.Buffer
+	m := &Manager{
+		Out:                    &out,
+		ChartPath:              chartPath,
+		Getters:                getter.All(&cli.EnvSettings{}),
+		RepositoryConfig:       filepath.Join(srv.Root(), "repositories.yaml"),
+		RepositoryCache:        srv.Root(),
+		MaxConcurrentDownloads: 2,
+	}
+
+	// The messages are in the order of the dependencies, and the charts
+	// depended on twice are downloaded once.
+	deps := []*chart.Dependency{
+		{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+		{Name: "local-subchart", Version: "0.1.0", Repository: srv.URL()},
+		{Name: "signtest", Version: "0.1.0", Repository: srv.URL(), Alias: "again"},
+	}
+	if err := m.downloadAll(deps); err != nil {
+		t.Fatal(err)
+	}
+	expect := "Saving 3 charts\n" +
+		"Downloading signtest from repo " + srv.URL() + "\n" +
+		"Downloading local-subchart from repo " + srv.URL() + "\n" +
+		"Already downloaded signtest from repo " + srv.URL() + "\n"
+	if !strings.HasPrefix(out.String(), expect) {
+		t.Errorf("expected output to start with:\n%s\ngot:\n%s", expect, out.String())
+	}
+	if deps[0].Digest == "" || deps[2].Digest != deps[0].Digest {
+		t.Errorf("expected the digests of the archives to be locked, got %q and %q", deps[0].Digest, deps[2].Digest)
+	}
+
+	// The charts directory is left untouched when any dependency fails, and
+	// all failures are reported.
+	err = m.downloadAll([]*chart.Dependency{
+		{Name: "missing", Version: "0.1.0", Repository: srv.URL()},
+		{Name: "signtest", Version: "0.1.0", Repository: srv.URL()},
+		{Name: "local-subchart", Version: "9.9.9", Repository: srv.URL()},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "2 dependencies could not be saved:") ||
+		!strings.Contains(err.Error(), "missing") || !strings.Contains(err.Error(), "local-subchart") {
+		t.Errorf("expected an error for both missing charts, got %v", err)
+	}
+	for _, name := range []string{"signtest-0.1.0.tgz", "local-subchart-0.1.0.tgz"} {
+		if _, err := os.Stat(filepath.Join(chartPath, "charts", name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestUpdateLocksDigests(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "with-dependency",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "signtest",
+				Version:    "0.1.0",
+				Repository: srv.URL(),
+			}},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath:        dir(c.Metadata.Name),
+		Out:              io.Discard,
+		Getters:          getter.All(&cli.EnvSettings{}),
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := resolver.HashArchive("testdata/signtest-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := loader.LoadDir(m.ChartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest := ch.Lock.Dependencies[0].Digest; digest != expect {
+		t.Fatalf("expected the digest %s in the lock file, got %q", expect, digest)
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archives that do not match the lock file are not saved.
+	archive := dir(c.Metadata.Name, "charts", "signtest-0.1.0.tgz")
+	if err := os.Remove(archive); err != nil {
+		t.Fatal(err)
+	}
+	ch.Lock.Dependencies[0].Digest = "sha256:0000"
+	ch.Lock.Digest, err = resolver.HashReq(ch.Metadata.Dependencies, ch.Lock.Dependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeLock(m.ChartPath, ch.Lock, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err == nil || !strings.Contains(err.Error(), "does not match the digest in the lock file") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be saved", archive)
+	}
+}
+
+func TestUpdateGitDependency(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-c", "user.name=Helm", "-c", "user.email=helm@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// A git repository with a library chart in a subdirectory.
+	remote := t.TempDir()
+	lib := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "lib", Version: "1.0.0", APIVersion: "v2", Type: "library"},
+	}
+	if err := chartutil.SaveDir(lib, filepath.Join(remote, "charts")); err != nil {
+		t.Fatal(err)
+	}
+	git(remote, "init", "--quiet", "--initial-branch=main")
+	git(remote, "add", "--all")
+	git(remote, "commit", "--quiet", "--message", "lib 1.0.0")
+	git(remote, "tag", "v1.0.0")
+	commit := git(remote, "rev-parse", "HEAD")
+
+	dir := t.TempDir()
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "with-dependency",
+			Version:    "0.1.0",
+			APIVersion: "v2",
+			Dependencies: []*chart.Dependency{{
+				Name:       "lib",
+				Version:    "^1.0.0",
+				Repository: "git+file://" + remote + "//charts/lib?ref=v1.0.0",
+			}},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath:        filepath.Join(dir, c.Metadata.Name),
+		Out:              io.Discard,
+		Getters:          getter.All(&cli.EnvSettings{}),
+		RepositoryConfig: filepath.Join(dir, "repositories.yaml"),
+		RepositoryCache:  filepath.Join(dir, "cache"),
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock file pins the commit of the tag.
+	ch, err := loader.LoadDir(m.ChartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := ch.Lock.Dependencies[0]
+	if expect := "git+file://" + remote + "//charts/lib?ref=" + commit; locked.Repository != expect {
+		t.Errorf("expected the repository %s in the lock file, got %s", expect, locked.Repository)
+	}
+	if locked.Version != "1.0.0" || locked.Digest == "" {
+		t.Errorf("expected version 1.0.0 with a digest, got %s %q", locked.Version, locked.Digest)
+	}
+	if statuses, err := resolver.VerifyDigests(m.ChartPath, ch.Lock); err != nil || statuses[0] != resolver.DigestOK {
+		t.Errorf("expected the archive to match the lock file, got %v: %v", statuses, err)
+	}
+
+	// Moving the tag does not change the charts built from the lock file.
+	if err := os.WriteFile(filepath.Join(remote, "charts", "lib", "values.yaml"), []byte("moved: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(remote, "add", "--all")
+	git(remote, "commit", "--quiet", "--message", "moved")
+	git(remote, "tag", "--force", "v1.0.0")
+	if err := os.RemoveAll(filepath.Join(m.ChartPath, "charts")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	dep, err := loader.Load(filepath.Join(m.ChartPath, "charts", "lib-1.0.0.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dep.Values) != 0 {
+		t.Errorf("expected the chart of the locked commit, got values %v", dep.Values)
+	}
+}
+
 // TestUpdateWithNoRepo is for the case of a dependency that has no repo listed.
 // This happens when the dependency is in the charts directory and does not need
 // to be fetched.
@@ -598,3 +835,41 @@ func TestKey(t *testing.T) {
 		}
 	}
 }
+
+func TestSafeMoveDepsKeepProvenance(t *testing.T) {
+	for _, keep := range []bool{true, false} {
+		source, dest := t.TempDir(), t.TempDir()
+		for _, name := range []string{"signtest-0.1.0.tgz", "signtest-0.1.0.tgz.prov"} {
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(source, name), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The provenance file of a chart that is no longer a dependency.
+		if err := os.WriteFile(filepath.Join(dest, "outdated-0.1.0.tgz.prov"), []byte("prov"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		m := &Manager{Out: new(bytes.Buffer), KeepProvenance: keep}
+		deps := []*chart.Dependency{{Name: "signtest", Version: "0.1.0", Repository: "https://example.com/charts"}}
+		if err := m.safeMoveDeps(deps, source, dest); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dest, "signtest-0.1.0.tgz")); err != nil {
+			t.Error(err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "signtest-0.1.0.tgz.prov")); (err == nil) != keep {
+			t.Errorf("expected the provenance file to be kept: %t, got: %v", keep, err)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "outdated-0.1.0.tgz.prov")); !os.IsNotExist(err) {
+			t.Errorf("expected the outdated provenance file to be deleted, got: %v", err)
+		}
+		if out := m.Out.(*bytes.Buffer).String(); strings.Contains(out, "Could not verify") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	}
+}
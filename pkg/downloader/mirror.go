@@ -0,0 +1,99 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"bytes"
+	"net/url"
+
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// mirroredGetter gets the files of a chart repository from the mirrors of the
+// repository, in order, when the repository cannot be reached or fails to
+// serve them.
+type mirroredGetter struct {
+	getter.Getter
+	entry   *repo.Entry
+	getters getter.Providers
+	logf    func(format string, v ...interface{})
+
+	// unavailable are the endpoints found unavailable, which are not tried
+	// again for the following files.
+	unavailable map[string]bool
+}
+
+func (g *mirroredGetter) Get(href string, options ...getter.Option) (*bytes.Buffer, error) {
+	var err error
+	for _, endpoint := range g.entry.URLs() {
+		if g.unavailable[endpoint] {
+			continue
+		}
+		u := href
+		if endpoint != g.entry.URL {
+			var ok bool
+			if u, ok = g.entry.MirrorURL(endpoint, href); !ok {
+				// The file is not in the repository, it is not mirrored.
+				break
+			}
+		}
+
+		var data *bytes.Buffer
+		data, err = g.get(endpoint, u, options)
+		if err == nil {
+			g.logf("Fetched %s from %s", href, endpoint)
+			return data, nil
+		}
+		if !getter.IsUnavailable(err) {
+			return nil, err
+		}
+		g.logf("Unable to fetch %s from %s: %s", href, endpoint, err)
+		if g.unavailable == nil {
+			g.unavailable = map[string]bool{}
+		}
+		g.unavailable[endpoint] = true
+	}
+	if err == nil {
+		// The endpoints were all found unavailable for previous files.
+		return g.Getter.Get(href, options...)
+	}
+	return nil, err
+}
+
+// get gets the file at u from the repository or one of its mirrors at
+// endpoint.
+func (g *mirroredGetter) get(endpoint, u string, options []getter.Option) (*bytes.Buffer, error) {
+	if endpoint == g.entry.URL {
+		return g.Getter.Get(u, options...)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	mg, err := g.getters.ByScheme(parsed.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options[:len(options):len(options)], getter.WithURL(endpoint))
+	if !g.entry.PassCredentialsAll {
+		// Mirrors are other services: the credentials of the repository are
+		// only sent to them when they are passed to all domains.
+		options = append(options, getter.WithBasicAuth("", ""))
+	}
+	return mg.Get(u, options...)
+}
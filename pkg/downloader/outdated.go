@@ -0,0 +1,258 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/resolver"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// OutdatedDependency reports the versions of a dependency of a chart.
+type OutdatedDependency struct {
+	// Name is the name of the dependency.
+	Name string `json:"name"`
+	// Alias is the alias of the dependency, if any.
+	Alias string `json:"alias,omitempty"`
+	// Repository is the repository of the dependency.
+	Repository string `json:"repository"`
+	// Constraint is the version constraint of the dependency in Chart.yaml.
+	Constraint string `json:"constraint"`
+	// Locked is the version of the dependency in the lock file, if any.
+	Locked string `json:"locked,omitempty"`
+	// Wanted is the newest version of the dependency matching its constraint.
+	Wanted string `json:"wanted,omitempty"`
+	// Latest is the newest version of the dependency. Pre-releases are only
+	// taken into account when the locked version is a pre-release.
+	Latest string `json:"latest,omitempty"`
+}
+
+// HasUpdate returns whether a version of the dependency newer than its locked
+// version is available, or whether the dependency is not locked.
+func (d *OutdatedDependency) HasUpdate() bool {
+	if d.Locked == "" {
+		return d.Wanted != ""
+	}
+	locked, err := semver.NewVersion(d.Locked)
+	if err != nil {
+		return false
+	}
+	for _, version := range []string{d.Wanted, d.Latest} {
+		if v, err := semver.NewVersion(version); err == nil && v.GreaterThan(locked) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedVersions returns the locked version of each dependency of req.
+//
+// The lock file lists the dependencies of Chart.yaml in order, by chart name
+// and repository only, so that aliases of a chart are told apart by their
+// position. Entries are matched in order first, then by chart name and
+// repository for a lock file that is stale or in another order.
+func lockedVersions(req, locked []*chart.Dependency) []string {
+	versions := make([]string, len(req))
+	matched := make([]bool, len(req))
+	used := make([]bool, len(locked))
+	same := func(dep, l *chart.Dependency) bool {
+		return l.Name == dep.Name && l.Repository == dep.Repository
+	}
+	for i, dep := range req {
+		if i < len(locked) && same(dep, locked[i]) {
+			versions[i] = locked[i].Version
+			matched[i], used[i] = true, true
+		}
+	}
+	for i, dep := range req {
+		if matched[i] {
+			continue
+		}
+		for j, l := range locked {
+			if !used[j] && same(dep, l) {
+				versions[i] = l.Version
+				used[j] = true
+				break
+			}
+		}
+	}
+	return versions
+}
+
+// Outdated reports, for each dependency of the chart fetched from a
+// repository or a registry, or from a local path, its locked version, the
+// newest version matching its constraint and the newest version overall.
+//
+// The versions are read from the cached indexes of chart repositories, which
+// are refreshed first unless SkipUpdate is true, and from the tags of
+// registries.
+func (m *Manager) Outdated() ([]*OutdatedDependency, error) {
+	c, err := m.loadChartDir()
+	if err != nil {
+		return nil, err
+	}
+	req := c.Metadata.Dependencies
+	if req == nil {
+		return nil, nil
+	}
+
+	repoNames, err := m.resolveRepoNames(req)
+	if err != nil {
+		return nil, err
+	}
+	repoNames, err = m.ensureMissingRepos(repoNames, req)
+	if err != nil {
+		return nil, err
+	}
+	if !m.SkipUpdate && !m.Offline {
+		if err := m.UpdateRepositories(); err != nil {
+			return nil, err
+		}
+	}
+
+	var locked []string
+	if c.Lock != nil {
+		locked = lockedVersions(req, c.Lock.Dependencies)
+	} else {
+		locked = make([]string, len(req))
+	}
+
+	var result []*OutdatedDependency
+	for i, dep := range req {
+		// Charts vendored in the charts directory have no other versions.
+		if dep.Repository == "" {
+			continue
+		}
+
+		od := &OutdatedDependency{
+			Name:       dep.Name,
+			Alias:      dep.Alias,
+			Repository: dep.Repository,
+			Constraint: dep.Version,
+			Locked:     locked[i],
+		}
+
+		constraint, err := semver.NewConstraint(dep.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dependency %q has an invalid version/constraint format", dep.Name)
+		}
+		versions, err := m.availableVersions(dep, repoNames[dep.Name])
+		if err != nil {
+			return nil, err
+		}
+		prerelease := false
+		if v, err := semver.NewVersion(od.Locked); err == nil {
+			prerelease = v.Prerelease() != ""
+		}
+
+		var wanted, latest *semver.Version
+		for _, v := range versions {
+			if constraint.Check(v) && (wanted == nil || v.GreaterThan(wanted)) {
+				wanted = v
+			}
+			if (prerelease || v.Prerelease() == "") && (latest == nil || v.GreaterThan(latest)) {
+				latest = v
+			}
+		}
+		if wanted != nil {
+			od.Wanted = wanted.Original()
+		}
+		if latest != nil {
+			od.Latest = latest.Original()
+		}
+		result = append(result, od)
+	}
+	return result, nil
+}
+
+// availableVersions returns the versions of the chart of dep available in its
+// repository, whose cached index is the one of the repository repoName, in its
+// registry, or at its local path or ref of its git repository.
+func (m *Manager) availableVersions(dep *chart.Dependency, repoName string) ([]*semver.Version, error) {
+	var versions []string
+	switch {
+	case strings.HasPrefix(dep.Repository, "file://"):
+		chartpath, err := resolver.GetLocalPath(dep.Repository, m.ChartPath)
+		if err != nil {
+			return nil, err
+		}
+		ch, err := loader.LoadDir(chartpath)
+		if err != nil {
+			return nil, err
+		}
+		versions = []string{ch.Metadata.Version}
+	case resolver.IsGitRepository(dep.Repository):
+		src, err := resolver.ParseGitSource(dep.Repository)
+		if err != nil {
+			return nil, err
+		}
+		chartpath, _, err := src.Checkout(m.RepositoryCache)
+		if err != nil {
+			return nil, err
+		}
+		ch, err := loader.LoadDir(chartpath)
+		if err != nil {
+			return nil, err
+		}
+		versions = []string{ch.Metadata.Version}
+	case registry.IsOCI(dep.Repository):
+		if m.RegistryClient == nil {
+			return nil, errors.Errorf("unable to list the versions of %s, missing registry client", dep.Name)
+		}
+		if m.Offline {
+			return nil, errors.Errorf("unable to list the versions of %s offline", dep.Name)
+		}
+		ref := fmt.Sprintf("%s/%s", strings.TrimPrefix(dep.Repository, fmt.Sprintf("%s://", registry.OCIScheme)), dep.Name)
+		tags, err := m.RegistryClient.Tags(ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retrieve list of tags for repository %s", dep.Repository)
+		}
+		versions = tags
+	default:
+		repoIndex, err := repo.LoadIndexFile(filepath.Join(m.RepositoryCache, helmpath.CacheIndexFile(repoName)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "no cached repository for %s found. (try 'helm repo update')", repoName)
+		}
+		entries, ok := repoIndex.Entries[dep.Name]
+		if !ok {
+			return nil, errors.Errorf("%s chart not found in repo %s", dep.Name, dep.Repository)
+		}
+		for _, entry := range entries {
+			if len(entry.URLs) > 0 {
+				versions = append(versions, entry.Version)
+			}
+		}
+	}
+
+	var result []*semver.Version
+	for _, version := range versions {
+		if v, err := semver.NewVersion(version); err == nil {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,220 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo/repotest"
+)
+
+func TestOutdated(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "../../cmd/helm/testdata/testcharts/compressedchart-0.*.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	local := &chart.Chart{Metadata: &chart.Metadata{Name: "local", Version: "1.2.3", APIVersion: chart.APIVersionV2}}
+	if err := chartutil.SaveDir(local, dir()); err != nil {
+		t.Fatal(err)
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "with-dependencies",
+			Version:    "0.1.0",
+			APIVersion: chart.APIVersionV2,
+			Dependencies: []*chart.Dependency{
+				{Name: "compressedchart", Version: "~0.1.0", Repository: srv.URL()},
+				{Name: "local", Version: "^1.0.0", Repository: "file://../local"},
+			},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath:        dir(c.Metadata.Name),
+		Out:              io.Discard,
+		Getters:          getter.All(&cli.EnvSettings{}),
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+	}
+
+	// Dependencies are not locked before the first update.
+	deps, err := m.Outdated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deps[0].Locked != "" || !deps[0].HasUpdate() {
+		t.Errorf("expected an unlocked dependency with updates, got %+v", deps[0])
+	}
+
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+	deps, err = m.Outdated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []*OutdatedDependency{
+		{Name: "compressedchart", Repository: srv.URL(), Constraint: "~0.1.0", Locked: "0.1.0", Wanted: "0.1.0", Latest: "0.3.0"},
+		{Name: "local", Repository: "file://../local", Constraint: "^1.0.0", Locked: "1.2.3", Wanted: "1.2.3", Latest: "1.2.3"},
+	}
+	if !reflect.DeepEqual(expect, deps) {
+		t.Errorf("expected %+v, got %+v", expect, deps)
+	}
+	if !deps[0].HasUpdate() {
+		t.Error("expected compressedchart to have updates")
+	}
+	if deps[1].HasUpdate() {
+		t.Error("expected local to have no updates")
+	}
+
+	// Lock entries are found by name after Chart.yaml is reordered.
+	c.Metadata.Dependencies = []*chart.Dependency{c.Metadata.Dependencies[1], c.Metadata.Dependencies[0]}
+	if err := chartutil.SaveChartfile(dir(c.Metadata.Name, chartutil.ChartfileName), c.Metadata); err != nil {
+		t.Fatal(err)
+	}
+	deps, err = m.Outdated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect[0], expect[1] = expect[1], expect[0]
+	if !reflect.DeepEqual(expect, deps) {
+		t.Errorf("expected %+v, got %+v", expect, deps)
+	}
+}
+
+func TestOutdatedDependencyHasUpdate(t *testing.T) {
+	tests := []struct {
+		dep    OutdatedDependency
+		expect bool
+	}{
+		{OutdatedDependency{Locked: "1.0.0", Wanted: "1.0.0", Latest: "1.0.0"}, false},
+		{OutdatedDependency{Locked: "1.0.0", Wanted: "1.0.1", Latest: "1.0.1"}, true},
+		{OutdatedDependency{Locked: "1.0.0", Wanted: "1.0.0", Latest: "2.0.0"}, true},
+		{OutdatedDependency{Locked: "1.0.0"}, false},
+		{OutdatedDependency{Wanted: "1.0.0", Latest: "1.0.0"}, true},
+		{OutdatedDependency{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.dep.HasUpdate(); got != tt.expect {
+			t.Errorf("%+v: expected %t, got %t", tt.dep, tt.expect, got)
+		}
+	}
+}
+
+func TestOutdatedAliases(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "../../cmd/helm/testdata/testcharts/compressedchart-0.*.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(p ...string) string {
+		return filepath.Join(append([]string{srv.Root()}, p...)...)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "with-aliases",
+			Version:    "0.1.0",
+			APIVersion: chart.APIVersionV2,
+			Dependencies: []*chart.Dependency{
+				{Name: "compressedchart", Alias: "old", Version: "~0.1.0", Repository: srv.URL()},
+				{Name: "compressedchart", Alias: "new", Version: "~0.2.0", Repository: srv.URL()},
+			},
+		},
+	}
+	if err := chartutil.SaveDir(c, dir()); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{
+		ChartPath:        dir(c.Metadata.Name),
+		Out:              io.Discard,
+		Getters:          getter.All(&cli.EnvSettings{}),
+		RepositoryConfig: dir("repositories.yaml"),
+		RepositoryCache:  dir(),
+	}
+	if err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+	deps, err := m.Outdated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []*OutdatedDependency{
+		{Name: "compressedchart", Alias: "old", Repository: srv.URL(), Constraint: "~0.1.0", Locked: "0.1.0", Wanted: "0.1.0", Latest: "0.3.0"},
+		{Name: "compressedchart", Alias: "new", Repository: srv.URL(), Constraint: "~0.2.0", Locked: "0.2.0", Wanted: "0.2.0", Latest: "0.3.0"},
+	}
+	if !reflect.DeepEqual(expect, deps) {
+		t.Errorf("expected %+v, got %+v", expect, deps)
+	}
+}
+
+func TestLockedVersions(t *testing.T) {
+	req := []*chart.Dependency{
+		{Name: "common", Alias: "a", Repository: "https://example.com"},
+		{Name: "common", Alias: "b", Repository: "https://example.com"},
+		{Name: "other", Repository: "https://example.com"},
+	}
+	tests := []struct {
+		name   string
+		locked []*chart.Dependency
+		expect []string
+	}{
+		{"in order", []*chart.Dependency{
+			{Name: "common", Version: "1.0.0", Repository: "https://example.com"},
+			{Name: "common", Version: "2.0.0", Repository: "https://example.com"},
+			{Name: "other", Version: "3.0.0", Repository: "https://example.com"},
+		}, []string{"1.0.0", "2.0.0", "3.0.0"}},
+		{"reordered", []*chart.Dependency{
+			{Name: "common", Version: "1.0.0", Repository: "https://example.com"},
+			{Name: "other", Version: "3.0.0", Repository: "https://example.com"},
+			{Name: "common", Version: "2.0.0", Repository: "https://example.com"},
+		}, []string{"1.0.0", "2.0.0", "3.0.0"}},
+		{"missing", []*chart.Dependency{
+			{Name: "other", Version: "3.0.0", Repository: "https://example.com"},
+		}, []string{"", "", "3.0.0"}},
+		{"other repository", []*chart.Dependency{
+			{Name: "common", Version: "1.0.0", Repository: "https://example.org"},
+		}, []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		if got := lockedVersions(req, tt.locked); !reflect.DeepEqual(tt.expect, got) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expect, got)
+		}
+	}
+}
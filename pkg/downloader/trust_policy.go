@@ -0,0 +1,167 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Verification levels of trust policies.
+const (
+	// TrustLevelNone leaves verification to the caller.
+	TrustLevelNone = "none"
+	// TrustLevelWarn verifies charts, and warns about missing or invalid
+	// provenance files without failing.
+	TrustLevelWarn = "warn"
+	// TrustLevelEnforce verifies charts, and fails on missing or invalid
+	// provenance files.
+	TrustLevelEnforce = "enforce"
+)
+
+// TrustPolicy maps the sources of charts to the verification they require.
+//
+// Trust policies are read from YAML files:
+//
+//	sources:
+//	  - repository: internal
+//	    level: enforce
+//	    keyring: internal.gpg
+//	  - url: oci://registry.example.com/charts
+//	    level: warn
+//	    keyring: allowed_signers
+//
+// Relative keyrings are relative to the directory of the file.
+type TrustPolicy struct {
+	Sources []*TrustSource `json:"sources"`
+}
+
+// TrustSource is the trust policy of charts from a repository or a registry.
+type TrustSource struct {
+	// Repository is the name of a repository of the repositories file.
+	Repository string `json:"repository,omitempty"`
+	// URL is the URL of a repository, or an OCI registry or a prefix of its
+	// repositories. It applies to charts whose URL it prefixes.
+	URL string `json:"url,omitempty"`
+	// Level is the minimum verification level of charts: none, warn or enforce.
+	Level string `json:"level"`
+	// Keyring is the keyring, or the allowed signers file, charts are verified
+	// against. The keyring of the caller is used when empty.
+	Keyring string `json:"keyring,omitempty"`
+}
+
+// LoadTrustPolicy loads the trust policy file at path. A missing file is an
+// empty policy.
+func LoadTrustPolicy(path string) (*TrustPolicy, error) {
+	p := &TrustPolicy{}
+	if path == "" {
+		return p, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, errors.Wrapf(err, "couldn't load trust policy file (%s)", path)
+	}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse trust policy file (%s)", path)
+	}
+	for i, s := range p.Sources {
+		if err := s.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid source %d of trust policy file (%s)", i+1, path)
+		}
+		if s.Keyring != "" && !filepath.IsAbs(s.Keyring) {
+			s.Keyring = filepath.Join(filepath.Dir(path), s.Keyring)
+		}
+	}
+	return p, nil
+}
+
+func (s *TrustSource) validate() error {
+	if (s.Repository == "") == (s.URL == "") {
+		return errors.New("exactly one of repository and url must be set")
+	}
+	switch s.Level {
+	case TrustLevelNone, TrustLevelWarn, TrustLevelEnforce:
+	default:
+		return errors.Errorf("unknown level %q, expected none, warn or enforce", s.Level)
+	}
+	return nil
+}
+
+// SourceFor returns the source of the trust policy applying to the chart at
+// chartURL, resolved from the repository named repoName if not empty, or nil.
+//
+// The source naming the repository the chart was resolved from applies
+// whatever the URL of the chart, as the index of a repository can point to
+// charts on other hosts. Otherwise, the source applying is the one with the
+// longest URL prefixing chartURL, the URLs of repositories being looked up by
+// name in the repositories file at repoConfig.
+func (p *TrustPolicy) SourceFor(chartURL, repoName, repoConfig string) *TrustSource {
+	if p == nil {
+		return nil
+	}
+	if repoName != "" {
+		for _, s := range p.Sources {
+			if s.Repository == repoName {
+				return s
+			}
+		}
+	}
+	var repos *repo.File
+	var match *TrustSource
+	var matchLen int
+	for _, s := range p.Sources {
+		prefix := s.URL
+		if s.Repository != "" {
+			if repos == nil {
+				var err error
+				if repos, err = repo.LoadFile(repoConfig); err != nil {
+					repos = repo.NewFile()
+				}
+			}
+			entry := repos.Get(s.Repository)
+			if entry == nil {
+				continue
+			}
+			prefix = entry.URL
+		}
+		prefix = strings.TrimSuffix(prefix, "/")
+		if prefix == "" || len(prefix) <= matchLen || !hasURLPrefix(chartURL, prefix) {
+			continue
+		}
+		match, matchLen = s, len(prefix)
+	}
+	return match
+}
+
+// hasURLPrefix returns whether prefix is a prefix of u ending at a path
+// segment, or at the tag or the digest of an OCI reference.
+func hasURLPrefix(u, prefix string) bool {
+	if !strings.HasPrefix(u, prefix) {
+		return false
+	}
+	rest := u[len(prefix):]
+	return rest == "" || strings.ContainsAny(rest[:1], "/:@?")
+}
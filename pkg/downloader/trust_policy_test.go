@@ -0,0 +1,233 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo/repotest"
+)
+
+func writeTrustPolicy(t *testing.T, policy string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trust-policy.yaml")
+	if err := os.WriteFile(path, []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTrustPolicy(t *testing.T) {
+	p, err := LoadTrustPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sources) != 0 {
+		t.Errorf("expected a missing policy to be empty, got %v", p.Sources)
+	}
+
+	path := writeTrustPolicy(t, `sources:
+  - repository: testing
+    level: enforce
+    keyring: pubring.gpg
+  - url: oci://registry.example.com/charts
+    level: warn
+    keyring: /etc/helm/allowed_signers
+`)
+	p, err = LoadTrustPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := filepath.Join(filepath.Dir(path), "pubring.gpg"); p.Sources[0].Keyring != expect {
+		t.Errorf("expected keyring %s, got %s", expect, p.Sources[0].Keyring)
+	}
+	if p.Sources[1].Keyring != "/etc/helm/allowed_signers" {
+		t.Errorf("expected absolute keyring to be kept, got %s", p.Sources[1].Keyring)
+	}
+
+	for _, tt := range []struct {
+		policy string
+		expect string
+	}{
+		{"sources:\n  - url: https://example.com\n    level: always\n", `unknown level "always"`},
+		{"sources:\n  - level: warn\n", "exactly one of repository and url must be set"},
+		{"sources:\n  - url: https://example.com\n    repository: testing\n    level: warn\n", "exactly one of repository and url must be set"},
+		{"sources:\n  - url: https://example.com\n    level: warn\n    keys: []\n", `unknown field "keys"`},
+	} {
+		_, err := LoadTrustPolicy(writeTrustPolicy(t, tt.policy))
+		if err == nil || !strings.Contains(err.Error(), tt.expect) {
+			t.Errorf("expected error containing %q, got %v", tt.expect, err)
+		}
+	}
+}
+
+func TestTrustPolicySourceFor(t *testing.T) {
+	p := &TrustPolicy{Sources: []*TrustSource{
+		{Repository: "testing", Level: TrustLevelWarn},
+		{Repository: "kubernetes-charts", Level: TrustLevelEnforce},
+		{Repository: "unknown", Level: TrustLevelEnforce},
+		{URL: "oci://registry.example.com/charts/", Level: TrustLevelEnforce},
+	}}
+
+	for _, tt := range []struct {
+		url    string
+		repo   string
+		expect *TrustSource
+	}{
+		{"http://example.com/alpine-0.1.0.tgz", "", p.Sources[0]},
+		{"http://example.com/charts/alpine-0.1.0.tgz", "", p.Sources[1]},
+		{"http://example.com.evil/alpine-0.1.0.tgz", "", nil},
+		{"https://example.com/alpine-0.1.0.tgz", "", nil},
+		{"oci://registry.example.com/charts/alpine:0.1.0", "", p.Sources[3]},
+		{"oci://registry.example.com/charts:0.1.0", "", p.Sources[3]},
+		{"oci://registry.example.com/chartsmuseum/alpine:0.1.0", "", nil},
+		// The repository the chart was resolved from applies whatever the
+		// host of the chart.
+		{"https://cdn.example.org/alpine-0.1.0.tgz", "testing", p.Sources[0]},
+		{"http://example.com/charts/alpine-0.1.0.tgz", "testing", p.Sources[0]},
+		{"https://cdn.example.org/alpine-0.1.0.tgz", "testing-relative", nil},
+	} {
+		if got := p.SourceFor(tt.url, tt.repo, repoConfig); got != tt.expect {
+			t.Errorf("%s from %q: expected source %v, got %v", tt.url, tt.repo, tt.expect, got)
+		}
+	}
+
+	var none *TrustPolicy
+	if none.SourceFor("http://example.com/alpine-0.1.0.tgz", "testing", repoConfig) != nil {
+		t.Error("expected no source for a nil policy")
+	}
+}
+
+func TestDownloadTo_TrustPolicy(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	if err := srv.LinkIndices(); err != nil {
+		t.Fatal(err)
+	}
+
+	download := func(policy, chart string) (string, error) {
+		var out bytes.Buffer
+		c := ChartDownloader{
+			Out:              &out,
+			Verify:           VerifyNever,
+			TrustPolicy:      writeTrustPolicy(t, policy),
+			RepositoryConfig: repoConfig,
+			RepositoryCache:  repoCache,
+			Getters: getter.All(&cli.EnvSettings{
+				RepositoryConfig: repoConfig,
+				RepositoryCache:  repoCache,
+			}),
+		}
+		_, v, err := c.DownloadTo(srv.URL()+"/"+chart, "", t.TempDir())
+		if err == nil && v.FileHash != "" {
+			fmt.Fprintf(&out, "verified %s", v.FileName)
+		}
+		return out.String(), err
+	}
+
+	keyring, err := filepath.Abs("testdata/helm-test-key.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforce := fmt.Sprintf("sources:\n  - url: %s\n    level: enforce\n    keyring: %s\n", srv.URL(), keyring)
+	warn := fmt.Sprintf("sources:\n  - url: %s\n    level: warn\n    keyring: %s\n", srv.URL(), keyring)
+	other := fmt.Sprintf("sources:\n  - url: https://charts.example.com\n    level: enforce\n    keyring: %s\n", keyring)
+
+	if out, err := download(enforce, "signtest-0.1.0.tgz"); err != nil || out != "verified signtest-0.1.0.tgz" {
+		t.Errorf("expected signed chart to be verified, got %q, %v", out, err)
+	}
+	if _, err := download(enforce, "local-subchart-0.1.0.tgz"); err == nil || !strings.Contains(err.Error(), "failed to fetch provenance") {
+		t.Errorf("expected unsigned chart to fail, got %v", err)
+	}
+	if out, err := download(warn, "local-subchart-0.1.0.tgz"); err != nil || !strings.Contains(out, "WARNING: Verification not found") {
+		t.Errorf("expected unsigned chart to be warned about, got %q, %v", out, err)
+	}
+	badKeyring := strings.Replace(warn, keyring, filepath.Join(filepath.Dir(keyring), "repositories.yaml"), 1)
+	if out, err := download(badKeyring, "signtest-0.1.0.tgz"); err != nil || !strings.Contains(out, "WARNING: Verification failed") {
+		t.Errorf("expected failed verification to be warned about, got %q, %v", out, err)
+	}
+	if out, err := download(other, "local-subchart-0.1.0.tgz"); err != nil || out != "" {
+		t.Errorf("expected charts of other sources not to be verified, got %q, %v", out, err)
+	}
+	if _, err := download("sources: {}", "signtest-0.1.0.tgz"); err == nil {
+		t.Error("expected an invalid policy to fail")
+	}
+}
+
+func TestDownloadTo_TrustPolicyRepositoryOnOtherHost(t *testing.T) {
+	srv, err := repotest.NewTempServerWithCleanup(t, "testdata/*.tgz*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	// The index of the repository points to charts hosted elsewhere, as
+	// on a CDN.
+	dir := t.TempDir()
+	repos := filepath.Join(dir, "repositories.yaml")
+	if err := os.WriteFile(repos, []byte("apiVersion: v1\nrepositories:\n  - name: internal\n    url: https://charts.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index := fmt.Sprintf(`apiVersion: v1
+entries:
+  signtest:
+    - apiVersion: v1
+      name: signtest
+      version: 0.1.0
+      urls: [%[1]s/signtest-0.1.0.tgz]
+  local-subchart:
+    - apiVersion: v2
+      name: local-subchart
+      version: 0.1.0
+      urls: [%[1]s/local-subchart-0.1.0.tgz]
+`, srv.URL())
+	if err := os.WriteFile(filepath.Join(dir, "internal-index.yaml"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := filepath.Abs("testdata/helm-test-key.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ChartDownloader{
+		Out:              &bytes.Buffer{},
+		Verify:           VerifyNever,
+		TrustPolicy:      writeTrustPolicy(t, fmt.Sprintf("sources:\n  - repository: internal\n    level: enforce\n    keyring: %s\n", keyring)),
+		RepositoryConfig: repos,
+		RepositoryCache:  dir,
+		Getters: getter.All(&cli.EnvSettings{
+			RepositoryConfig: repos,
+			RepositoryCache:  dir,
+		}),
+	}
+	if _, v, err := c.DownloadTo("internal/signtest", "", t.TempDir()); err != nil || v.FileHash == "" {
+		t.Errorf("expected signed chart to be verified, got %v, %v", v, err)
+	}
+	if _, _, err := c.DownloadTo("internal/local-subchart", "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "failed to fetch provenance") {
+		t.Errorf("expected unsigned chart to fail, got %v", err)
+	}
+}
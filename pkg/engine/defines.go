@@ -0,0 +1,237 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// ScopedDefinesAnnotation is the Chart.yaml annotation that opts a chart into
+// scoped named templates.
+//
+// When set to "true", references to named templates from the chart's own
+// templates resolve to definitions found in the chart and its dependencies
+// before falling back to the definitions shared by the whole release.
+const ScopedDefinesAnnotation = "helm.sh/scoped-defines"
+
+// DefineCollision describes a named template that is defined with different
+// content in more than one template file.
+type DefineCollision struct {
+	// Name is the name of the template.
+	Name string
+	// Files lists every template file defining Name, in parse order. The
+	// definition from the last file is the one used when rendering.
+	Files []string
+}
+
+// FindDefineCollisions returns the named templates of a chart and its
+// dependencies that are defined with different content in several files.
+//
+// Identical definitions, such as the same library chart vendored by two
+// subcharts, are not reported, and neither are definitions that are all in
+// charts using scoped named templates, or in their dependencies, as each of
+// those charts resolves its own.
+func FindDefineCollisions(c *chart.Chart) ([]DefineCollision, error) {
+	tpls := allTemplates(c, chartutil.Values{})
+	keys := sortTemplates(tpls)
+
+	var scopes []string
+	for _, filename := range keys {
+		if s := tpls[filename].defineScope; s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	inScope := func(filename string) bool {
+		for _, s := range scopes {
+			if strings.HasPrefix(filename, s+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	files := make(map[string][]string)
+	bodies := make(map[string]map[string]struct{})
+	scoped := make(map[string]bool)
+	for _, filename := range keys {
+		defs, err := parseDefines(filename, tpls[filename].tpl)
+		if err != nil {
+			return nil, cleanupParseError(filename, err)
+		}
+		for name, tree := range defs {
+			files[name] = append(files[name], filename)
+			if bodies[name] == nil {
+				bodies[name] = make(map[string]struct{})
+				scoped[name] = true
+			}
+			scoped[name] = scoped[name] && inScope(filename)
+			bodies[name][tree.Root.String()] = struct{}{}
+		}
+	}
+
+	var collisions []DefineCollision
+	for name, b := range bodies {
+		if len(b) > 1 && !scoped[name] {
+			collisions = append(collisions, DefineCollision{Name: name, Files: files[name]})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].Name < collisions[j].Name })
+	return collisions, nil
+}
+
+// hasScopedDefines returns true if the chart opted into scoped named templates.
+func hasScopedDefines(c *chart.Chart) bool {
+	return c.Metadata != nil && c.Metadata.Annotations[ScopedDefinesAnnotation] == "true"
+}
+
+// parseDefines parses a template file on its own and returns the non-empty
+// named templates it defines.
+func parseDefines(filename, text string) (map[string]*parse.Tree, error) {
+	t, err := template.New(filename).Funcs(funcMap()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]*parse.Tree)
+	for _, d := range t.Templates() {
+		if d.Name() == filename || d.Tree == nil || parse.IsEmptyTree(d.Tree.Root) {
+			continue
+		}
+		defs[d.Name()] = d.Tree
+	}
+	return defs, nil
+}
+
+// scopedDefineName returns the name under which a named template is
+// registered for a chart using scoped named templates.
+func scopedDefineName(scope, name string) string {
+	return scope + "#" + name
+}
+
+// addScopedDefines registers, for every chart that opted into scoped named
+// templates, a private copy of the named templates found in the chart and its
+// dependencies, and points the references made by the chart's own templates
+// at those copies.
+//
+// The original definitions stay in place so that other charts keep resolving
+// them as before.
+func addScopedDefines(t *template.Template, tpls map[string]renderable, keys []string) error {
+	scopes := make(map[string]struct{})
+	for _, filename := range keys {
+		if s := tpls[filename].defineScope; s != "" {
+			scopes[s] = struct{}{}
+		}
+	}
+
+	for scope := range scopes {
+		// keys are in parse order, so later definitions win just as they
+		// do in the shared template set.
+		defs := make(map[string]*parse.Tree)
+		for _, filename := range keys {
+			if !strings.HasPrefix(filename, scope+"/") {
+				continue
+			}
+			fileDefs, err := parseDefines(filename, tpls[filename].tpl)
+			if err != nil {
+				return cleanupParseError(filename, err)
+			}
+			for name, tree := range fileDefs {
+				defs[name] = tree
+			}
+		}
+
+		for name, tree := range defs {
+			cp := tree.Copy()
+			renameTemplateRefs(cp.Root, scope, defs)
+			if _, err := t.AddParseTree(scopedDefineName(scope, name), cp); err != nil {
+				return err
+			}
+		}
+
+		for _, filename := range keys {
+			if tpls[filename].defineScope != scope {
+				continue
+			}
+			if ft := t.Lookup(filename); ft != nil && ft.Tree != nil {
+				renameTemplateRefs(ft.Tree.Root, scope, defs)
+			}
+		}
+	}
+	return nil
+}
+
+// renameTemplateRefs rewrites the 'template' actions and the 'include' calls
+// with a literal name found in defs to their scoped names.
+func renameTemplateRefs(node parse.Node, scope string, defs map[string]*parse.Tree) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			renameTemplateRefs(c, scope, defs)
+		}
+	case *parse.ActionNode:
+		renameTemplateRefs(n.Pipe, scope, defs)
+	case *parse.IfNode:
+		renameBranchRefs(&n.BranchNode, scope, defs)
+	case *parse.RangeNode:
+		renameBranchRefs(&n.BranchNode, scope, defs)
+	case *parse.WithNode:
+		renameBranchRefs(&n.BranchNode, scope, defs)
+	case *parse.TemplateNode:
+		if _, ok := defs[n.Name]; ok {
+			n.Name = scopedDefineName(scope, n.Name)
+		}
+		renameTemplateRefs(n.Pipe, scope, defs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			renameTemplateRefs(c, scope, defs)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 1 {
+			if id, ok := n.Args[0].(*parse.IdentifierNode); ok && id.Ident == "include" {
+				if s, ok := n.Args[1].(*parse.StringNode); ok {
+					if _, ok := defs[s.Text]; ok {
+						s.Text = scopedDefineName(scope, s.Text)
+						s.Quoted = strconv.Quote(s.Text)
+					}
+				}
+			}
+		}
+		for _, a := range n.Args {
+			renameTemplateRefs(a, scope, defs)
+		}
+	case *parse.ChainNode:
+		renameTemplateRefs(n.Node, scope, defs)
+	}
+}
+
+func renameBranchRefs(n *parse.BranchNode, scope string, defs map[string]*parse.Tree) {
+	renameTemplateRefs(n.Pipe, scope, defs)
+	renameTemplateRefs(n.List, scope, defs)
+	renameTemplateRefs(n.ElseList, scope, defs)
+}
@@ -112,6 +112,8 @@ type renderable struct {
 	vals chartutil.Values
 	// namespace prefix to the templates of the current chart
 	basePath string
+	// defineScope is the full path of the chart when it uses scoped named templates
+	defineScope string
 }
 
 const warnStartDelim = "HELM_ERR_START"
@@ -283,6 +285,10 @@ func (e Engine) render(tpls map[string]renderable) (rendered map[string]string,
 		}
 	}
 
+	if err := addScopedDefines(t, tpls, keys); err != nil {
+		return map[string]string{}, err
+	}
+
 	rendered = make(map[string]string, len(keys))
 	for _, filename := range keys {
 		// Don't render partials. We don't care out the direct output of partials.
@@ -410,6 +416,10 @@ func recAllTpls(c *chart.Chart, templates map[string]renderable, vals chartutil.
 	}
 
 	newParentID := c.ChartFullPath()
+	var defineScope string
+	if hasScopedDefines(c) {
+		defineScope = newParentID
+	}
 	for _, t := range c.Templates {
 		if t == nil {
 			continue
@@ -418,9 +428,10 @@ func recAllTpls(c *chart.Chart, templates map[string]renderable, vals chartutil.
 			continue
 		}
 		templates[path.Join(newParentID, t.Name)] = renderable{
-			tpl:      string(t.Data),
-			vals:     next,
-			basePath: path.Join(newParentID, "templates"),
+			tpl:         string(t.Data),
+			vals:        next,
+			basePath:    path.Join(newParentID, "templates"),
+			defineScope: defineScope,
 		}
 	}
 
@@ -19,6 +19,7 @@ package engine
 import (
 	"fmt"
 	"path"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -647,6 +648,98 @@ func TestRenderDependency(t *testing.T) {
 
 }
 
+func defineCollisionChart(scoped bool) *chart.Chart {
+	var annotations map[string]string
+	if scoped {
+		annotations = map[string]string{ScopedDefinesAnnotation: "true"}
+	}
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "parent"},
+	}
+	for _, name := range []string{"a", "b"} {
+		sub := &chart.Chart{
+			Metadata: &chart.Metadata{Name: name, Annotations: annotations},
+			Templates: []*chart.File{
+				{Name: "templates/include", Data: []byte(`{{ include "common.labels" . }}`)},
+				{Name: "templates/template", Data: []byte(`{{ if true }}{{ template "common.labels" . }}{{ end }}`)},
+			},
+		}
+		sub.AddDependency(&chart.Chart{
+			Metadata: &chart.Metadata{Name: "common", Type: "library"},
+			Templates: []*chart.File{
+				{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "common.labels" }}{{ template "common.name" }}{{ end }}{{ define "common.name" }}` + name + `{{ end }}`)},
+			},
+		})
+		ch.AddDependency(sub)
+	}
+	return ch
+}
+
+func TestFindDefineCollisions(t *testing.T) {
+	collisions, err := FindDefineCollisions(defineCollisionChart(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []DefineCollision{
+		{
+			Name: "common.name",
+			Files: []string{
+				"parent/charts/b/charts/common/templates/_helpers.tpl",
+				"parent/charts/a/charts/common/templates/_helpers.tpl",
+			},
+		},
+	}
+	if !reflect.DeepEqual(collisions, expect) {
+		t.Errorf("Expected %v, got %v", expect, collisions)
+	}
+
+	ch := defineCollisionChart(false)
+	ch.Dependencies()[1].Dependencies()[0].Templates[0].Data = ch.Dependencies()[0].Dependencies()[0].Templates[0].Data
+	collisions, err = FindDefineCollisions(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 0 {
+		t.Errorf("Expected identical definitions to be ignored, got %v", collisions)
+	}
+
+	collisions, err = FindDefineCollisions(defineCollisionChart(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 0 {
+		t.Errorf("Expected definitions resolved by scoping to be ignored, got %v", collisions)
+	}
+}
+
+func TestRenderScopedDefines(t *testing.T) {
+	out, err := Render(defineCollisionChart(false), chartutil.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		for _, file := range []string{"include", "template"} {
+			key := "parent/charts/" + name + "/templates/" + file
+			if out[key] != "a" {
+				t.Errorf("Expected %s to render the shared definition %q, got %q", key, "a", out[key])
+			}
+		}
+	}
+
+	out, err = Render(defineCollisionChart(true), chartutil.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		for _, file := range []string{"include", "template"} {
+			key := "parent/charts/" + name + "/templates/" + file
+			if out[key] != name {
+				t.Errorf("Expected %s to render its own definition %q, got %q", key, name, out[key])
+			}
+		}
+	}
+}
+
 func TestRenderNestedValues(t *testing.T) {
 	innerpath := "templates/inner.tpl"
 	outerpath := "templates/outer.tpl"
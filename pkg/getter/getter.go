@@ -18,7 +18,9 @@ package getter
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/pkg/errors"
@@ -46,6 +48,9 @@ type options struct {
 	registryClient        *registry.Client
 	timeout               time.Duration
 	transport             *http.Transport
+	chartCache            string
+	offline               bool
+	cacheValidators       *CacheValidators
 }
 
 // Option allows specifying various settings configurable by the user for overriding the defaults
@@ -135,6 +140,72 @@ func WithTransport(transport *http.Transport) Option {
 	}
 }
 
+// WithChartCache sets the directory of the cache of chart archives consulted by
+// the getters of registries before pulling charts.
+func WithChartCache(dir string) Option {
+	return func(opts *options) {
+		opts.chartCache = dir
+	}
+}
+
+// WithOffline makes the getters of registries get charts from the chart cache
+// only.
+func WithOffline(offline bool) Option {
+	return func(opts *options) {
+		opts.offline = offline
+	}
+}
+
+// ErrNotModified is returned by getters when the content requested with
+// WithCacheValidators has not changed since it was cached.
+var ErrNotModified = errors.New("not modified")
+
+// CacheValidators are the validators of a cached HTTP response, used to make
+// conditional requests.
+type CacheValidators struct {
+	// ETag is the value of the ETag header of the cached response.
+	ETag string
+	// LastModified is the value of the Last-Modified header of the cached
+	// response.
+	LastModified string
+}
+
+// WithCacheValidators makes the HTTP getter request the content only if it
+// has changed since the response validated by v was cached, returning
+// ErrNotModified otherwise. When the content is fetched, v is set to the
+// validators of the response.
+//
+// Unlike the other options, it only applies to a single Get.
+func WithCacheValidators(v *CacheValidators) Option {
+	return func(opts *options) {
+		opts.cacheValidators = v
+	}
+}
+
+// HTTPError is returned by the HTTP getter when the server responds with an
+// unexpected status.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("failed to fetch %s : %s", e.URL, e.Status)
+}
+
+// IsUnavailable reports whether err indicates that the server could not be
+// reached or failed to serve the request, in which case the content may be
+// fetched from a mirror of the server instead.
+func IsUnavailable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
 // Getter is an interface to support GET to the specified URL.
 type Getter interface {
 	// Get file content by url string
@@ -201,11 +272,16 @@ var ociProvider = Provider{
 	New:     NewOCIGetter,
 }
 
+var ociLayoutProvider = Provider{
+	Schemes: []string{registry.OCILayoutScheme},
+	New:     NewOCILayoutGetter,
+}
+
 // All finds all of the registered getters as a list of Provider instances.
 // Currently, the built-in getters and the discovered plugins with downloader
 // notations are collected.
 func All(settings *cli.EnvSettings) Providers {
-	result := Providers{httpProvider, ociProvider}
+	result := Providers{httpProvider, ociProvider, ociLayoutProvider}
 	pluginDownloaders, _ := collectPlugins(settings)
 	result = append(result, pluginDownloaders...)
 	return result
@@ -39,6 +39,7 @@ type HTTPGetter struct {
 
 // Get performs a Get from repo.Getter and returns the body.
 func (g *HTTPGetter) Get(href string, options ...Option) (*bytes.Buffer, error) {
+	g.opts.cacheValidators = nil
 	for _, opt := range options {
 		opt(&g.opts)
 	}
@@ -58,6 +59,16 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		req.Header.Set("User-Agent", g.opts.userAgent)
 	}
 
+	validators := g.opts.cacheValidators
+	if validators != nil {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
 	// Before setting the basic auth credentials, make sure the URL associated
 	// with the basic auth is the one being fetched.
 	u1, err := url.Parse(g.opts.url)
@@ -88,8 +99,15 @@ func (g *HTTPGetter) get(href string) (*bytes.Buffer, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && validators != nil {
+		return nil, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("failed to fetch %s : %s", href, resp.Status)
+		return nil, &HTTPError{URL: href, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if validators != nil {
+		validators.ETag = resp.Header.Get("ETag")
+		validators.LastModified = resp.Header.Get("Last-Modified")
 	}
 
 	buf := bytes.NewBuffer(nil)
@@ -282,6 +282,86 @@ func TestDownload(t *testing.T) {
 	}
 }
 
+func TestHTTPGetterCacheValidators(t *testing.T) {
+	const expect = "Call me Ishmael"
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"1"` || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		fmt.Fprint(w, expect)
+	}))
+	defer srv.Close()
+
+	g, err := NewHTTPGetter(WithURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &CacheValidators{}
+	got, err := g.Get(srv.URL, WithCacheValidators(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != expect {
+		t.Errorf("Expected %q, got %q", expect, got.String())
+	}
+	if v.ETag != `"1"` || v.LastModified != lastModified {
+		t.Errorf("Expected the validators of the response, got %+v", v)
+	}
+
+	for _, v := range []*CacheValidators{{ETag: `"1"`}, {LastModified: lastModified}} {
+		if _, err := g.Get(srv.URL, WithCacheValidators(v)); !errors.Is(err, ErrNotModified) {
+			t.Errorf("Expected ErrNotModified with %+v, got %v", v, err)
+		}
+	}
+
+	// The validators only apply to a single Get.
+	got, err = g.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != expect {
+		t.Errorf("Expected %q, got %q", expect, got.String())
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	g, err := NewHTTPGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for href, expect := range map[string]bool{
+		srv.URL + "/404": false,
+		srv.URL + "/401": false,
+		srv.URL + "/500": true,
+		srv.URL + "/503": true,
+		closed.URL:       true,
+	} {
+		_, err := g.Get(href)
+		if err == nil {
+			t.Fatalf("Expected an error getting %s", href)
+		}
+		if IsUnavailable(err) != expect {
+			t.Errorf("Expected IsUnavailable to be %t for %s", expect, err)
+		}
+	}
+	if IsUnavailable(errors.New("some error")) {
+		t.Error("Expected IsUnavailable to be false for other errors")
+	}
+}
+
 func TestDownloadTLS(t *testing.T) {
 	cd := "../../testdata"
 	ca, pub, priv := filepath.Join(cd, "rootca.crt"), filepath.Join(cd, "crt.pem"), filepath.Join(cd, "key.pem")
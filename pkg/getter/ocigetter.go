@@ -24,6 +24,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/chartcache"
 	"helm.sh/helm/v3/internal/tlsutil"
 	"helm.sh/helm/v3/internal/urlutil"
 	"helm.sh/helm/v3/pkg/registry"
@@ -67,6 +70,10 @@ func (g *OCIGetter) get(href string) (*bytes.Buffer, error) {
 			registry.PullOptWithProv(true))
 	}
 
+	if g.opts.chartCache != "" {
+		return g.getCached(client, ref, pullOpts, requestingProv)
+	}
+
 	result, err := client.Pull(ref, pullOpts...)
 	if err != nil {
 		return nil, err
@@ -78,6 +85,52 @@ func (g *OCIGetter) get(href string) (*bytes.Buffer, error) {
 	return bytes.NewBuffer(result.Chart.Data), nil
 }
 
+// getCached gets the chart at ref, or its provenance file, from the chart
+// cache when it holds the chart of the manifest of ref, and otherwise pulls
+// it and stores it in the cache. When offline, the chart is looked up by ref.
+func (g *OCIGetter) getCached(client *registry.Client, ref string, pullOpts []registry.PullOption, requestingProv bool) (*bytes.Buffer, error) {
+	cache := chartcache.New(g.opts.chartCache)
+	href := fmt.Sprintf("%s://%s", registry.OCIScheme, ref)
+
+	var digest string
+	var err error
+	if g.opts.offline {
+		digest, err = cache.Resolve(href)
+	} else {
+		digest, err = client.ChartDigest(ref)
+	}
+	if err == nil {
+		var data []byte
+		if requestingProv {
+			data, err = cache.GetProvenance(digest)
+		} else {
+			data, err = cache.Get(digest)
+		}
+		if err == nil {
+			return bytes.NewBuffer(data), nil
+		}
+	}
+	if g.opts.offline {
+		return nil, errors.Wrapf(chartcache.ErrNotFound, "unable to get %s offline", href)
+	}
+
+	result, err := client.Pull(ref, pullOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if requestingProv {
+		if digest != "" {
+			cache.PutProvenance(digest, result.Prov.Data)
+		}
+		return bytes.NewBuffer(result.Prov.Data), nil
+	}
+	// The cache only saves downloads, failing to write to it is not an error.
+	if digest, err := cache.Put(result.Chart.Data); err == nil {
+		cache.Link(href, digest)
+	}
+	return bytes.NewBuffer(result.Chart.Data), nil
+}
+
 // NewOCIGetter constructs a valid http/https client as a Getter
 func NewOCIGetter(ops ...Option) (Getter, error) {
 	var client OCIGetter
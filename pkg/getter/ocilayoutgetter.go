@@ -0,0 +1,90 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// OCILayoutGetter is the backend handler for charts in OCI image layout
+// directories.
+type OCILayoutGetter struct {
+	opts options
+}
+
+// Get performs a Get from repo.Getter and returns the body.
+//
+// The URL is in the form "oci-layout://<path>/<name>:<version>", where path
+// is the directory of the layout. When it has no version, the tag name
+// option is used instead.
+func (g *OCILayoutGetter) Get(href string, options ...Option) (*bytes.Buffer, error) {
+	for _, opt := range options {
+		opt(&g.opts)
+	}
+	return g.get(href)
+}
+
+func (g *OCILayoutGetter) get(href string) (*bytes.Buffer, error) {
+	requestingProv := strings.HasSuffix(href, ".prov")
+	href = strings.TrimSuffix(href, ".prov")
+
+	dir, name, version, err := registry.ParseLayoutReference(href)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = g.opts.version
+	}
+	if version == "" {
+		return nil, errors.Errorf("no version given for chart %s", href)
+	}
+
+	layout, err := registry.LoadLayoutDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ref := registry.LayoutChartRef(name, version)
+	data, prov, err := layout.PullChart(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if requestingProv {
+		if prov == nil {
+			return nil, errors.Errorf("%s has no provenance file", ref)
+		}
+		return bytes.NewBuffer(prov), nil
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// NewOCILayoutGetter constructs a Getter for charts in OCI image layout
+// directories.
+func NewOCILayoutGetter(ops ...Option) (Getter, error) {
+	var client OCILayoutGetter
+
+	for _, opt := range ops {
+		opt(&client.opts)
+	}
+
+	return &client, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestOCILayoutGetter(t *testing.T) {
+	chartData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz.prov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subchartData, err := os.ReadFile("../downloader/testdata/local-subchart-0.1.0.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "layout")
+	layout := registry.NewLayout()
+	if _, err := layout.PushChart(chartData, provData, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := layout.PushChart(subchartData, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := layout.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewOCILayoutGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := "oci-layout://" + dir + "/signtest"
+
+	got, err := g.Get(ref + ":0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chartData, got.Bytes()) {
+		t.Error("expected the chart archive")
+	}
+	got, err = g.Get(ref+".prov", WithTagName("0.1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(provData, got.Bytes()) {
+		t.Error("expected the provenance file")
+	}
+
+	if _, err := g.Get("oci-layout://" + dir + "/local-subchart:0.1.0.prov"); err == nil {
+		t.Error("expected an error getting a missing provenance file")
+	}
+	if _, err := g.Get("oci-layout://" + dir + "/signtest:0.2.0"); err == nil {
+		t.Error("expected an error getting a missing version")
+	}
+
+	g, err = NewOCILayoutGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(ref); err == nil {
+		t.Error("expected an error getting a chart without version")
+	}
+}
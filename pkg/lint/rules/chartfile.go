@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/lint/support"
 )
@@ -64,6 +65,7 @@ func Chartfile(linter *support.Linter) {
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartIconURL(chartFile))
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartType(chartFile))
 	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartDependencies(chartFile))
+	linter.RunLinterRule(support.ErrorSev, chartFileName, validateChartHelmVersion(chartFile))
 }
 
 func validateChartVersionType(data map[string]interface{}) error {
@@ -211,3 +213,13 @@ func loadChartFileForTypeCheck(filename string) (map[string]interface{}, error)
 	err = yaml.Unmarshal(b, &y)
 	return y, err
 }
+
+func validateChartHelmVersion(cf *chart.Metadata) error {
+	if cf.HelmVersion == "" {
+		return nil
+	}
+	if _, err := semver.NewConstraint(cf.HelmVersion); err != nil {
+		return errors.Errorf("helmVersion %q is not a valid SemVer constraint", cf.HelmVersion)
+	}
+	return loader.CheckHelmVersion(&chart.Chart{Metadata: cf})
+}
@@ -192,6 +192,30 @@ func TestValidateChartIconURL(t *testing.T) {
 	}
 }
 
+func TestValidateChartHelmVersion(t *testing.T) {
+	var failTest = map[string]string{
+		">= three": "is not a valid SemVer constraint",
+		">=99.0.0": "requires Helm >=99.0.0, which is incompatible",
+	}
+	var successTest = []string{"", ">=3.0.0", "^3.0.0 || ^4.0.0"}
+	defer func() { badChart.HelmVersion = "" }()
+	for test, msg := range failTest {
+		badChart.HelmVersion = test
+		err := validateChartHelmVersion(badChart)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("validateChartHelmVersion(%s) to return %q, got %v", test, msg, err)
+		}
+	}
+
+	for _, test := range successTest {
+		badChart.HelmVersion = test
+		err := validateChartHelmVersion(badChart)
+		if err != nil {
+			t.Errorf("validateChartHelmVersion(%s) to return no error, got %s", test, err.Error())
+		}
+	}
+}
+
 func TestChartfile(t *testing.T) {
 	t.Run("Chart.yaml basic validity issues", func(t *testing.T) {
 		linter := support.Linter{ChartDir: badChartDir}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/internal/resolver"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/lint/support"
@@ -41,6 +42,17 @@ func Dependencies(linter *support.Linter) {
 	linter.RunLinterRule(support.WarningSev, linter.ChartDir, validateDependencyInChartsDir(c))
 }
 
+// DependencyDigests checks that the archives of the dependencies in the charts
+// directory of a chart are the ones recorded in its lock file.
+func DependencyDigests(linter *support.Linter) {
+	c, err := loader.LoadDir(linter.ChartDir)
+	if !linter.RunLinterRule(support.ErrorSev, "", validateChartFormat(err)) {
+		return
+	}
+
+	linter.RunLinterRule(support.ErrorSev, linter.ChartDir, validateDependencyDigests(linter.ChartDir, c))
+}
+
 func validateChartFormat(chartError error) error {
 	if chartError != nil {
 		return errors.Errorf("unable to load chart\n\t%s", chartError)
@@ -101,3 +113,14 @@ func validateDependenciesUnique(c *chart.Chart) (err error) {
 	}
 	return err
 }
+
+func validateDependencyDigests(chartDir string, c *chart.Chart) error {
+	if len(c.Metadata.Dependencies) == 0 {
+		return nil
+	}
+	if c.Lock == nil {
+		return errors.New("dependencies are not locked. Please update the dependencies")
+	}
+	_, err := resolver.VerifyDigests(chartDir, c.Lock)
+	return err
+}
@@ -155,3 +155,20 @@ func TestDependencies(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateDependencyDigests(t *testing.T) {
+	c := chartWithBadDependencies()
+	if err := validateDependencyDigests(t.TempDir(), &c); err == nil {
+		t.Error("chart should have been flagged for missing lock file")
+	}
+
+	c.Lock = &chart.Lock{Dependencies: []*chart.Dependency{{Name: "sub2", Version: "0.1.0", Repository: "https://example.com/charts"}}}
+	if err := validateDependencyDigests(t.TempDir(), &c); err == nil {
+		t.Error("chart should have been flagged for missing digest")
+	}
+
+	c.Metadata.Dependencies = nil
+	if err := validateDependencyDigests(t.TempDir(), &c); err != nil {
+		t.Errorf("chart without dependencies should not have been flagged: %s", err)
+	}
+}
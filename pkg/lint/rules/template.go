@@ -66,8 +66,9 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		return
 	}
 
-	// Load chart and parse templates
-	chart, err := loader.Load(linter.ChartDir)
+	// Load chart and parse templates. The version of Helm the chart requires
+	// is checked by the Chart.yaml rules.
+	chart, err := loader.LoadDir(linter.ChartDir)
 
 	chartLoaded := linter.RunLinterRule(support.ErrorSev, fpath, err)
 
@@ -111,6 +112,13 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 		return
 	}
 
+	collisions, err := engine.FindDefineCollisions(chart)
+	if linter.RunLinterRule(support.ErrorSev, fpath, err) {
+		for _, c := range collisions {
+			linter.RunLinterRule(support.WarningSev, fpath, validateNoDefineCollision(c))
+		}
+	}
+
 	/* Iterate over all the templates to check:
 	- It is a .yaml file
 	- All the values in the template file is defined
@@ -176,6 +184,13 @@ func TemplatesWithSkipSchemaValidation(linter *support.Linter, values map[string
 	}
 }
 
+// validateNoDefineCollision reports a named template defined with different
+// content in several files, where only the last definition is used.
+func validateNoDefineCollision(c engine.DefineCollision) error {
+	return fmt.Errorf("template %q is defined with different content in %s; only the definition in %s is used. Consider the %s annotation to scope named templates per chart",
+		c.Name, strings.Join(c.Files, ", "), c.Files[len(c.Files)-1], engine.ScopedDefinesAnnotation)
+}
+
 // validateTopIndentLevel checks that the content does not start with an indent level > 0.
 //
 // This error can occur when a template accidentally inserts space. It can cause
@@ -25,6 +25,7 @@ import (
 
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
 	"helm.sh/helm/v3/pkg/lint/support"
 )
 
@@ -420,6 +421,76 @@ func TestEmptyWithCommentsManifests(t *testing.T) {
 		t.Fatalf("Expected 0 lint errors, got %d", l)
 	}
 }
+
+func TestDefineCollisions(t *testing.T) {
+	mychart := chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: "v2",
+			Name:       "collisions",
+			Version:    "0.1.0",
+			Icon:       "satisfy-the-linting-gods.gif",
+		},
+		Templates: []*chart.File{
+			{Name: "templates/_a.tpl", Data: []byte(`{{ define "collisions.name" }}a{{ end }}`)},
+			{Name: "templates/_b.tpl", Data: []byte(`{{ define "collisions.name" }}b{{ end }}`)},
+		},
+	}
+	tmpdir := t.TempDir()
+
+	if err := chartutil.SaveDir(&mychart, tmpdir); err != nil {
+		t.Fatal(err)
+	}
+
+	linter := support.Linter{ChartDir: filepath.Join(tmpdir, mychart.Name())}
+	Templates(&linter, values, namespace, strict)
+	if l := len(linter.Messages); l != 1 {
+		t.Fatalf("Expected 1 lint message, got %d: %v", l, linter.Messages)
+	}
+	msg := linter.Messages[0]
+	if msg.Severity != support.WarningSev {
+		t.Errorf("Expected a warning, got severity %d", msg.Severity)
+	}
+	if !strings.Contains(msg.Err.Error(), `template "collisions.name" is defined with different content`) {
+		t.Errorf("Unexpected message: %s", msg.Err)
+	}
+}
+
+func TestScopedDefineCollisions(t *testing.T) {
+	mychart := chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: "v2",
+			Name:       "scoped",
+			Version:    "0.1.0",
+			Icon:       "satisfy-the-linting-gods.gif",
+		},
+	}
+	for _, name := range []string{"a", "b"} {
+		sub := &chart.Chart{
+			Metadata: &chart.Metadata{
+				APIVersion:  "v2",
+				Name:        name,
+				Version:     "0.1.0",
+				Annotations: map[string]string{engine.ScopedDefinesAnnotation: "true"},
+			},
+			Templates: []*chart.File{
+				{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "common.name" }}` + name + `{{ end }}`)},
+			},
+		}
+		mychart.AddDependency(sub)
+	}
+	tmpdir := t.TempDir()
+
+	if err := chartutil.SaveDir(&mychart, tmpdir); err != nil {
+		t.Fatal(err)
+	}
+
+	linter := support.Linter{ChartDir: filepath.Join(tmpdir, mychart.Name())}
+	Templates(&linter, values, namespace, strict)
+	if l := len(linter.Messages); l != 0 {
+		t.Fatalf("Expected no lint messages, got %d: %v", l, linter.Messages)
+	}
+}
+
 func TestValidateListAnnotations(t *testing.T) {
 	md := &K8sYamlStruct{
 		APIVersion: "v1",
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/lint/support"
 )
@@ -40,6 +41,8 @@ func Values(linter *support.Linter) {
 //
 // If additional values are supplied, they are coalesced into the values in values.yaml.
 func ValuesWithOverrides(linter *support.Linter, values map[string]interface{}) {
+	linter.RunLinterRule(support.ErrorSev, chartutil.MigrationsDir, validateValuesMigrations(linter.ChartDir))
+
 	file := "values.yaml"
 	vf := filepath.Join(linter.ChartDir, file)
 	fileExists := linter.RunLinterRule(support.InfoSev, file, validateValuesFileExistence(vf))
@@ -51,6 +54,20 @@ func ValuesWithOverrides(linter *support.Linter, values map[string]interface{})
 	linter.RunLinterRule(support.ErrorSev, file, validateValuesFile(vf, values))
 }
 
+// validateValuesMigrations checks the values migrations of the chart, if any.
+func validateValuesMigrations(chartDir string) error {
+	if _, err := os.Stat(filepath.Join(chartDir, chartutil.MigrationsDir)); err != nil {
+		return nil
+	}
+	chrt, err := loader.LoadDir(chartDir)
+	if err != nil {
+		// Failing to load the chart is reported by other rules.
+		return nil
+	}
+	_, err = chartutil.LoadValuesMigrations(chrt)
+	return err
+}
+
 func validateValuesFileExistence(valuesPath string) error {
 	_, err := os.Stat(valuesPath)
 	if err != nil {
@@ -82,5 +99,17 @@ func validateValuesFile(valuesPath string, overrides map[string]interface{}) err
 	if err != nil {
 		return err
 	}
-	return chartutil.ValidateAgainstSingleSchema(coalescedValues, schema)
+	// Load the chart so that references to other JSON files it ships resolve.
+	chartDir := filepath.Dir(valuesPath)
+	if _, err := os.Stat(filepath.Join(chartDir, chartutil.ChartfileName)); err != nil {
+		return chartutil.ValidateAgainstSingleSchema(coalescedValues, schema)
+	}
+	chrt, err := loader.Load(chartDir)
+	if err != nil {
+		return errors.Wrap(err, "unable to load the chart to resolve the references of its schema")
+	}
+	if chrt.Schema == nil {
+		return chartutil.ValidateAgainstSingleSchema(coalescedValues, schema)
+	}
+	return chartutil.ValidateAgainstChartSchema(chrt, coalescedValues)
 }
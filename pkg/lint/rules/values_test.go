@@ -113,6 +113,56 @@ func TestValidateValuesFileSchemaOverrides(t *testing.T) {
 	}
 }
 
+func TestValidateValuesFileSchemaLocalRef(t *testing.T) {
+	yaml := "username: 1234"
+	tmpdir := ensure.TempFile(t, "values.yaml", []byte(yaml))
+	chartfile := "apiVersion: v2\nname: test\nversion: 0.1.0\n"
+	if err := os.WriteFile(filepath.Join(tmpdir, "Chart.yaml"), []byte(chartfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"properties": {"username": {"$ref": "schemas/username.json"}}}`
+	if err := os.WriteFile(filepath.Join(tmpdir, "values.schema.json"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpdir, "schemas"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpdir, "schemas", "username.json"), []byte(`{"type": "string"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := validateValuesFile(filepath.Join(tmpdir, "values.yaml"), map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected values file to fail validation")
+	}
+	assert.Contains(t, err.Error(), "Expected: string, given: integer", "integer should be caught by the referenced schema")
+}
+
+func TestValidateValuesFileSchemaLocalRefBrokenChart(t *testing.T) {
+	yaml := "username: admin"
+	tmpdir := ensure.TempFile(t, "values.yaml", []byte(yaml))
+	chartfile := "apiVersion: v2\nname: test\nversion: 0.1.0\n"
+	if err := os.WriteFile(filepath.Join(tmpdir, "Chart.yaml"), []byte(chartfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"properties": {"username": {"$ref": "schemas/username.json"}}}`
+	if err := os.WriteFile(filepath.Join(tmpdir, "values.schema.json"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpdir, "charts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpdir, "charts", "broken-0.1.0.tgz"), []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := validateValuesFile(filepath.Join(tmpdir, "values.yaml"), map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error when the chart cannot be loaded to resolve the references of its schema")
+	}
+	assert.Contains(t, err.Error(), "unable to load the chart")
+}
+
 func TestValidateValuesFile(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -159,6 +209,30 @@ func TestValidateValuesFile(t *testing.T) {
 	}
 }
 
+func TestValidateValuesMigrations(t *testing.T) {
+	chartDir := t.TempDir()
+	chartfile := "apiVersion: v2\nname: migrated\nversion: 2.0.0\n"
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateValuesMigrations(chartDir); err != nil {
+		t.Errorf("Expected a chart without migrations to pass, got %s", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(chartDir, "migrations"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	migration := "version: 2.0.0\noperations:\n  - op: copy\n    path: image.name\n"
+	if err := os.WriteFile(filepath.Join(chartDir, "migrations", "2.0.0.yaml"), []byte(migration), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := validateValuesMigrations(chartDir)
+	if err == nil {
+		t.Fatal("Expected an invalid migration to fail")
+	}
+	assert.Contains(t, err.Error(), `unknown op "copy"`)
+}
+
 func createTestingSchema(t *testing.T, dir string) string {
 	t.Helper()
 	schemafile := filepath.Join(dir, "values.schema.json")
@@ -59,6 +59,8 @@ type SumCollection struct {
 type Verification struct {
 	// SignedBy contains the entity that signed a chart.
 	SignedBy *openpgp.Entity
+	// SignedBySSH contains the allowed signer that signed a chart with SSH.
+	SignedBySSH *AllowedSigner
 	// FileHash is the hash, prepended with the scheme, for the file that was verified.
 	FileHash string
 	// FileName is the name of the file that FileHash verifies.
@@ -271,25 +273,10 @@ func (s *Signatory) Verify(chartpath, sigpath string) (*Verification, error) {
 	ver.SignedBy = by
 
 	// Second, verify the hash of the tarball.
-	sum, err := DigestFile(chartpath)
-	if err != nil {
-		return ver, err
-	}
-	_, sums, err := parseMessageBlock(sig.Plaintext)
-	if err != nil {
+	if err := verifyMessageSum(ver, chartpath, sig.Plaintext); err != nil {
 		return ver, err
 	}
 
-	sum = "sha256:" + sum
-	basename := filepath.Base(chartpath)
-	if sha, ok := sums.Files[basename]; !ok {
-		return ver, errors.Errorf("provenance does not contain a SHA for a file named %q", basename)
-	} else if sha != sum {
-		return ver, errors.Errorf("sha256 sum does not match for %s: %q != %q", basename, sha, sum)
-	}
-	ver.FileHash = sum
-	ver.FileName = basename
-
 	// TODO: when image signing is added, verify that here.
 
 	return ver, nil
@@ -0,0 +1,426 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SSHNamespace is the namespace of the SSH signatures of charts. Allowed
+	// signers restricted to namespaces must list it.
+	SSHNamespace = "helm"
+
+	sshSigMagic       = "SSHSIG"
+	sshSigVersion     = 1
+	sshSigHash        = "sha512"
+	sshSigArmorStart  = "-----BEGIN SSH SIGNATURE-----"
+	sshSigArmorEnd    = "-----END SSH SIGNATURE-----"
+	sshSigLineLength  = 70
+	sshSigTimeLayout  = "20060102150405"
+	sshSigShortLayout = "200601021504"
+	sshSigDateLayout  = "20060102"
+)
+
+// AllowedSigner is an entry of an allowed signers file, as used by
+// 'ssh-keygen -Y verify'.
+type AllowedSigner struct {
+	// Principals are the identities of the signer.
+	Principals []string
+	// Namespaces the signer may sign in. Any namespace is allowed when empty.
+	Namespaces []string
+	// Key is the public key of the signer.
+	Key ssh.PublicKey
+	// ValidAfter and ValidBefore bound the validity of the key when not zero.
+	ValidAfter, ValidBefore time.Time
+}
+
+// allows returns whether s may sign in namespace at time now.
+func (s *AllowedSigner) allows(key ssh.PublicKey, namespace string, now time.Time) bool {
+	if !bytes.Equal(s.Key.Marshal(), key.Marshal()) {
+		return false
+	}
+	if !s.ValidAfter.IsZero() && now.Before(s.ValidAfter) {
+		return false
+	}
+	if !s.ValidBefore.IsZero() && now.After(s.ValidBefore) {
+		return false
+	}
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range s.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// SSHSignatory signs and verifies charts with SSH signatures, in the format of
+// 'ssh-keygen -Y sign'.
+//
+// SSH signed provenance files hold the same message as PGP signed ones, followed
+// by an armored SSH signature of the message in the SSHNamespace namespace.
+type SSHSignatory struct {
+	// Signer signs charts.
+	Signer ssh.Signer
+	// AllowedSigners are trusted to sign charts. This is used for verification.
+	AllowedSigners []*AllowedSigner
+}
+
+// NewSSHFromKeyFile constructs a new SSHSignatory from the private key in the
+// given file, which may be an OpenSSH or a PKCS#8 key, such as a raw ed25519
+// key generated by 'openssl genpkey -algorithm ed25519'.
+//
+// If the key is encrypted, fn is called to fetch its passphrase.
+func NewSSHFromKeyFile(keyfile string, fn PassphraseFetcher) (*SSHSignatory, error) {
+	data, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ssh.ParseRawPrivateKey(data)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok && fn != nil {
+		passphrase, perr := fn(keyfile)
+		if perr != nil {
+			return nil, perr
+		}
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, passphrase)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load SSH private key %s", keyfile)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &SSHSignatory{Signer: signer}, nil
+}
+
+// NewSSHFromAllowedSigners constructs a new SSHSignatory verifying charts with
+// the signers of the given allowed signers file.
+func NewSSHFromAllowedSigners(path string) (*SSHSignatory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := ParseAllowedSigners(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid allowed signers file %s", path)
+	}
+	return &SSHSignatory{AllowedSigners: signers}, nil
+}
+
+// IsSSHPrivateKeyFile returns whether the file at path holds an SSH or PKCS#8
+// private key, which NewSSHFromKeyFile can load.
+func IsSSHPrivateKeyFile(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, err = ssh.ParseRawPrivateKey(data)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return true
+	}
+	return err == nil
+}
+
+// IsSSHSigned returns whether the provenance file data holds an SSH signature.
+func IsSSHSigned(data []byte) bool {
+	_, _, ok := splitSSHSigned(data)
+	return ok
+}
+
+// ParseAllowedSigners parses an allowed signers file. Each line holds the
+// comma-separated principals of a signer, optional options, and its public key
+// in the format of authorized_keys files:
+//
+//	ops@example.com namespaces="helm" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI...
+//
+// The namespaces, valid-after and valid-before options are supported. Entries
+// of certificate authorities are not, and are ignored.
+func ParseAllowedSigners(data []byte) ([]*AllowedSigner, error) {
+	var signers []*AllowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		principals, rest, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, errors.Errorf("line %d: missing public key", n)
+		}
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		signer := &AllowedSigner{Principals: strings.Split(principals, ","), Key: key}
+		ignored := false
+		for _, opt := range options {
+			name, value, _ := strings.Cut(opt, "=")
+			value = strings.Trim(value, `"`)
+			switch strings.ToLower(name) {
+			case "namespaces":
+				signer.Namespaces = strings.Split(value, ",")
+			case "valid-after":
+				if signer.ValidAfter, err = parseSSHTime(value); err != nil {
+					return nil, errors.Wrapf(err, "line %d", n)
+				}
+			case "valid-before":
+				if signer.ValidBefore, err = parseSSHTime(value); err != nil {
+					return nil, errors.Wrapf(err, "line %d", n)
+				}
+			case "cert-authority":
+				ignored = true
+			}
+		}
+		if !ignored {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, scanner.Err()
+}
+
+func parseSSHTime(s string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(s, "Z") {
+		s, loc = strings.TrimSuffix(s, "Z"), time.UTC
+	}
+	for _, layout := range []string{sshSigTimeLayout, sshSigShortLayout, sshSigDateLayout} {
+		if len(s) == len(layout) {
+			return time.ParseInLocation(layout, s, loc)
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time %q", s)
+}
+
+// ClearSign signs a chart with the SSH key of the SSHSignatory.
+//
+// This takes the path to a chart archive file, and it returns the contents of
+// its provenance file.
+func (s *SSHSignatory) ClearSign(chartpath string) (string, error) {
+	if s.Signer == nil {
+		return "", errors.New("private key not found")
+	}
+
+	if fi, err := os.Stat(chartpath); err != nil {
+		return "", err
+	} else if fi.IsDir() {
+		return "", errors.New("cannot sign a directory")
+	}
+
+	b, err := messageBlock(chartpath)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.sign(b.Bytes())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign message block")
+	}
+	b.WriteString(sig)
+	return b.String(), nil
+}
+
+// sign returns the armored SSH signature of message.
+func (s *SSHSignatory) sign(message []byte) (string, error) {
+	signed := sshSignedData(message)
+	var sig *ssh.Signature
+	var err error
+	if as, ok := s.Signer.(ssh.AlgorithmSigner); ok && s.Signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// SHA-1 RSA signatures are rejected by OpenSSH.
+		sig, err = as.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.Signer.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}{sshSigVersion, s.Signer.PublicKey().Marshal(), SSHNamespace, "", sshSigHash, ssh.Marshal(sig)})...)
+
+	var out strings.Builder
+	out.WriteString(sshSigArmorStart + "\n")
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	for len(encoded) > sshSigLineLength {
+		out.WriteString(encoded[:sshSigLineLength] + "\n")
+		encoded = encoded[sshSigLineLength:]
+	}
+	out.WriteString(encoded + "\n" + sshSigArmorEnd + "\n")
+	return out.String(), nil
+}
+
+// sshSignedData returns the data actually signed for message.
+func sshSignedData(message []byte) []byte {
+	h := sha512.Sum512(message)
+	return append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		Hash      string
+		Digest    []byte
+	}{SSHNamespace, "", sshSigHash, h[:]})...)
+}
+
+// splitSSHSigned splits an SSH signed provenance file into its message and its
+// armored signature.
+func splitSSHSigned(data []byte) ([]byte, []byte, bool) {
+	idx := bytes.Index(data, []byte(sshSigArmorStart))
+	if idx < 0 || (idx > 0 && data[idx-1] != '\n') {
+		return nil, nil, false
+	}
+	return data[:idx], data[idx:], true
+}
+
+// Verify checks an SSH signature and verifies that it is legit for a chart.
+func (s *SSHSignatory) Verify(chartpath, sigpath string) (*Verification, error) {
+	ver := &Verification{}
+	for _, fname := range []string{chartpath, sigpath} {
+		if fi, err := os.Stat(fname); err != nil {
+			return ver, err
+		} else if fi.IsDir() {
+			return ver, errors.Errorf("%s cannot be a directory", fname)
+		}
+	}
+
+	data, err := os.ReadFile(sigpath)
+	if err != nil {
+		return ver, err
+	}
+	message, armored, ok := splitSSHSigned(data)
+	if !ok {
+		return ver, errors.New("failed to decode signature: SSH signature block not found")
+	}
+
+	// First verify the signature
+	by, err := s.verifySignature(message, armored, time.Now())
+	if err != nil {
+		return ver, err
+	}
+	ver.SignedBySSH = by
+
+	// Second, verify the hash of the tarball.
+	if err := verifyMessageSum(ver, chartpath, message); err != nil {
+		return ver, err
+	}
+	return ver, nil
+}
+
+// verifySignature verifies that message is validly signed by armored, and
+// returns the signer.
+func (s *SSHSignatory) verifySignature(message, armored []byte, now time.Time) (*AllowedSigner, error) {
+	body := strings.TrimSpace(string(armored))
+	if !strings.HasPrefix(body, sshSigArmorStart) || !strings.HasSuffix(body, sshSigArmorEnd) {
+		return nil, errors.New("failed to decode signature: malformed SSH signature block")
+	}
+	body = strings.TrimSuffix(strings.TrimPrefix(body, sshSigArmorStart), sshSigArmorEnd)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode signature")
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSigMagic)) {
+		return nil, errors.New("failed to decode signature: not an SSH signature")
+	}
+
+	var sigBlob struct {
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}
+	if err := ssh.Unmarshal(blob[len(sshSigMagic):], &sigBlob); err != nil {
+		return nil, errors.Wrap(err, "failed to decode signature")
+	}
+	if sigBlob.Version != sshSigVersion {
+		return nil, errors.Errorf("unsupported SSH signature version %d", sigBlob.Version)
+	}
+	if sigBlob.Namespace != SSHNamespace {
+		return nil, errors.Errorf("SSH signature is for namespace %q, not %q", sigBlob.Namespace, SSHNamespace)
+	}
+	if sigBlob.Hash != sshSigHash {
+		return nil, errors.Errorf("unsupported SSH signature hash %q", sigBlob.Hash)
+	}
+	key, err := ssh.ParsePublicKey(sigBlob.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode signature")
+	}
+	sig := new(ssh.Signature)
+	if err := ssh.Unmarshal(sigBlob.Signature, sig); err != nil {
+		return nil, errors.Wrap(err, "failed to decode signature")
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		return nil, errors.New("SHA-1 RSA SSH signatures are not supported")
+	}
+
+	var signer *AllowedSigner
+	for _, allowed := range s.AllowedSigners {
+		if allowed.allows(key, SSHNamespace, now) {
+			signer = allowed
+			break
+		}
+	}
+	if signer == nil {
+		return nil, errors.Errorf("signing key %s is not an allowed signer", ssh.FingerprintSHA256(key))
+	}
+	if err := key.Verify(sshSignedData(message), sig); err != nil {
+		return nil, errors.Wrap(err, "invalid SSH signature")
+	}
+	return signer, nil
+}
+
+// verifyMessageSum verifies that the chart at chartpath has the sum listed in
+// the provenance message, and records it in ver.
+func verifyMessageSum(ver *Verification, chartpath string, message []byte) error {
+	sum, err := DigestFile(chartpath)
+	if err != nil {
+		return err
+	}
+	_, sums, err := parseMessageBlock(message)
+	if err != nil {
+		return err
+	}
+
+	sum = "sha256:" + sum
+	basename := filepath.Base(chartpath)
+	if sha, ok := sums.Files[basename]; !ok {
+		return errors.Errorf("provenance does not contain a SHA for a file named %q", basename)
+	} else if sha != sum {
+		return errors.Errorf("sha256 sum does not match for %s: %q != %q", basename, sha, sum)
+	}
+	ver.FileHash = sum
+	ver.FileName = basename
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// testSSHKeyfile is an SSH private key without passphrase, generated with
+	// `ssh-keygen -t ed25519 -N "" -C helm-testing@helm.sh`.
+	testSSHKeyfile = "testdata/helm-test-ssh-key"
+
+	// testPasswordSSHKeyfile is an SSH private key with the passphrase "secret".
+	testPasswordSSHKeyfile = "testdata/helm-password-ssh-key"
+
+	// testAllowedSigners allows the key of testSSHKeyfile to sign in the helm
+	// namespace.
+	testAllowedSigners = "testdata/allowed_signers"
+
+	// testSSHSigBlock is msgblock.yaml followed by its signature, generated with
+	// `ssh-keygen -Y sign -f helm-test-ssh-key -n helm msgblock.yaml`.
+	testSSHSigBlock = "testdata/msgblock.yaml.ssh"
+)
+
+func TestParseAllowedSigners(t *testing.T) {
+	pub, err := os.ReadFile(testSSHKeyfile + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimSpace(string(pub))
+
+	data := "# comment\n\n" +
+		"a@example.com,b@example.com " + key + "\n" +
+		`c@example.com namespaces="git,helm",valid-after="20200101",valid-before="20300101Z" ` + key + "\n" +
+		"*.example.com cert-authority " + key + "\n"
+	signers, err := ParseAllowedSigners([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("expected 2 signers, got %d", len(signers))
+	}
+	if got := strings.Join(signers[0].Principals, ","); got != "a@example.com,b@example.com" {
+		t.Errorf("unexpected principals %q", got)
+	}
+	if len(signers[0].Namespaces) != 0 {
+		t.Errorf("unexpected namespaces %v", signers[0].Namespaces)
+	}
+	if got := strings.Join(signers[1].Namespaces, ","); got != "git,helm" {
+		t.Errorf("unexpected namespaces %q", got)
+	}
+	if want := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC); !signers[1].ValidBefore.Equal(want) {
+		t.Errorf("expected valid-before %s, got %s", want, signers[1].ValidBefore)
+	}
+
+	for _, bad := range []string{
+		"a@example.com\n",
+		"a@example.com ssh-ed25519 notbase64\n",
+		`a@example.com valid-after="yesterday" ` + key + "\n",
+	} {
+		if _, err := ParseAllowedSigners([]byte(bad)); err == nil {
+			t.Errorf("expected %q to be invalid", bad)
+		}
+	}
+}
+
+func TestSSHVerify(t *testing.T) {
+	signer, err := NewSSHFromAllowedSigners(testAllowedSigners)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ver, err := signer.Verify(testChartfile, testSSHSigBlock)
+	if err != nil {
+		t.Fatalf("Failed to pass verify. Err: %s", err)
+	}
+	if ver.SignedBySSH == nil || ver.SignedBySSH.Principals[0] != "helm-testing@helm.sh" {
+		t.Errorf("unexpected signer %+v", ver.SignedBySSH)
+	}
+	if ver.FileHash == "" {
+		t.Error("Verification is missing hash.")
+	}
+	if ver.FileName != "hashtest-1.2.3.tgz" {
+		t.Errorf("FileName is unexpectedly %q", ver.FileName)
+	}
+
+	// A PGP signature is not an SSH signature.
+	if _, err := signer.Verify(testChartfile, testSigBlock); err == nil {
+		t.Errorf("Expected %s to fail.", testSigBlock)
+	}
+
+	// Tampering with the message breaks the signature.
+	data, err := os.ReadFile(testSSHSigBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(t.TempDir(), "msgblock.yaml.ssh")
+	if err := os.WriteFile(tampered, []byte(strings.Replace(string(data), "version: 1.2.3", "version: 1.2.4", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.Verify(testChartfile, tampered); err == nil || !strings.Contains(err.Error(), "invalid SSH signature") {
+		t.Errorf("Expected tampered signature to fail, got %v", err)
+	}
+}
+
+func TestSSHClearSign(t *testing.T) {
+	signer, err := NewSSHFromKeyFile(testSSHKeyfile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := signer.ClearSign(testChartfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sig, testMessageBlock) {
+		t.Errorf("expected message block to be signed, got\n%s", sig)
+	}
+	if !IsSSHSigned([]byte(sig)) {
+		t.Error("expected an SSH signature")
+	}
+	if _, err := signer.ClearSign("testdata/hashtest"); err == nil {
+		t.Error("expected directories not to be signed")
+	}
+
+	sigpath := filepath.Join(t.TempDir(), "hashtest-1.2.3.tgz.prov")
+	if err := os.WriteFile(sigpath, []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewSSHFromAllowedSigners(testAllowedSigners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(testChartfile, sigpath); err != nil {
+		t.Fatalf("failed to verify signature: %s", err)
+	}
+
+	// Signers outside of the allowed signers are rejected.
+	verifier.AllowedSigners = nil
+	if _, err := verifier.Verify(testChartfile, sigpath); err == nil || !strings.Contains(err.Error(), "not an allowed signer") {
+		t.Errorf("expected unknown signer to fail, got %v", err)
+	}
+
+	// So are signers not allowed in the helm namespace.
+	verifier, err = NewSSHFromAllowedSigners(testAllowedSigners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.AllowedSigners[0].Namespaces = []string{"git"}
+	if _, err := verifier.Verify(testChartfile, sigpath); err == nil {
+		t.Error("expected signer outside of the helm namespace to fail")
+	}
+
+	// And expired ones.
+	verifier.AllowedSigners[0].Namespaces = nil
+	verifier.AllowedSigners[0].ValidBefore = time.Now().Add(-time.Hour)
+	if _, err := verifier.Verify(testChartfile, sigpath); err == nil {
+		t.Error("expected expired signer to fail")
+	}
+}
+
+func TestNewSSHFromKeyFilePassphrase(t *testing.T) {
+	if !IsSSHPrivateKeyFile(testPasswordSSHKeyfile) {
+		t.Errorf("expected %s to be an SSH private key", testPasswordSSHKeyfile)
+	}
+	if IsSSHPrivateKeyFile(testKeyfile) {
+		t.Errorf("expected %s not to be an SSH private key", testKeyfile)
+	}
+
+	if _, err := NewSSHFromKeyFile(testPasswordSSHKeyfile, nil); err == nil {
+		t.Error("expected missing passphrase to fail")
+	}
+	if _, err := NewSSHFromKeyFile(testPasswordSSHKeyfile, func(string) ([]byte, error) {
+		return []byte("wrong"), nil
+	}); err == nil {
+		t.Error("expected wrong passphrase to fail")
+	}
+	signer, err := NewSSHFromKeyFile(testPasswordSSHKeyfile, func(string) ([]byte, error) {
+		return []byte("secret"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.ClearSign(testChartfile); err != nil {
+		t.Fatal(err)
+	}
+}
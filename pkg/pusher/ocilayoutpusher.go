@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pusher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/time/ctime"
+)
+
+// OCILayoutPusher is the backend handler for OCI image layout directories.
+type OCILayoutPusher struct {
+	opts options
+}
+
+// Push performs a Push from repo.Pusher.
+//
+// The URL is in the form "oci-layout://<path>", where path is the directory
+// of the layout, which is created if it does not exist.
+func (pusher *OCILayoutPusher) Push(chartRef, href string, options ...Option) error {
+	for _, opt := range options {
+		opt(&pusher.opts)
+	}
+	return pusher.push(chartRef, href)
+}
+
+func (pusher *OCILayoutPusher) push(chartRef, href string) error {
+	stat, err := os.Stat(chartRef)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("%s: no such file", chartRef)
+		}
+		return err
+	}
+	if stat.IsDir() {
+		return errors.New("cannot push directory, must provide chart archive (.tgz)")
+	}
+
+	chartBytes, err := os.ReadFile(chartRef)
+	if err != nil {
+		return err
+	}
+	var provBytes []byte
+	provRef := fmt.Sprintf("%s.prov", chartRef)
+	if _, err := os.Stat(provRef); err == nil {
+		if provBytes, err = os.ReadFile(provRef); err != nil {
+			return err
+		}
+	}
+
+	dir := strings.TrimPrefix(href, fmt.Sprintf("%s://", registry.OCILayoutScheme))
+	if dir == "" {
+		return errors.Errorf("improperly formatted OCI layout reference: %s", href)
+	}
+	layout := registry.NewLayout()
+	if _, err := os.Stat(filepath.Join(dir, ocispec.ImageLayoutFile)); err == nil {
+		if layout, err = registry.LoadLayoutDir(dir); err != nil {
+			return err
+		}
+	}
+
+	chartCreationTime := ctime.Created(stat)
+	if _, err := layout.PushChart(chartBytes, provBytes, chartCreationTime.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return layout.WriteDir(dir)
+}
+
+// NewOCILayoutPusher constructs a Pusher for OCI image layout directories.
+func NewOCILayoutPusher(ops ...Option) (Pusher, error) {
+	var client OCILayoutPusher
+
+	for _, opt := range ops {
+		opt(&client.opts)
+	}
+
+	return &client, nil
+}
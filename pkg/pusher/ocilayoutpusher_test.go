@@ -0,0 +1,71 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pusher
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestOCILayoutPusher(t *testing.T) {
+	p, err := NewOCILayoutPusher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "layout")
+	chartRef := "../downloader/testdata/signtest-0.1.0.tgz"
+	if err := p.Push(chartRef, "oci-layout://"+dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Push("../downloader/testdata/local-subchart-0.1.0.tgz", "oci-layout://"+dir); err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := registry.LoadLayoutDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, prov, err := layout.PullChart("signtest:0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := os.ReadFile(chartRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, data) {
+		t.Error("expected the pushed chart to match the chart archive")
+	}
+	if prov == nil {
+		t.Error("expected the provenance file to be pushed along with the chart")
+	}
+	if _, _, err := layout.PullChart("local-subchart:0.1.0"); err != nil {
+		t.Error(err)
+	}
+
+	if err := p.Push("../downloader/testdata/missing-0.1.0.tgz", "oci-layout://"+dir); err == nil {
+		t.Error("expected an error pushing a missing chart")
+	}
+	if err := p.Push("../downloader/testdata", "oci-layout://"+dir); err == nil {
+		t.Error("expected an error pushing a directory")
+	}
+}
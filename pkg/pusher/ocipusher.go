@@ -57,7 +57,7 @@ func (pusher *OCIPusher) push(chartRef, href string) error {
 		return errors.New("cannot push directory, must provide chart archive (.tgz)")
 	}
 
-	meta, err := loader.Load(chartRef)
+	meta, err := loader.LoadFile(chartRef)
 	if err != nil {
 		return err
 	}
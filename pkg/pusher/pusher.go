@@ -114,9 +114,14 @@ var ociProvider = Provider{
 	New:     NewOCIPusher,
 }
 
+var ociLayoutProvider = Provider{
+	Schemes: []string{registry.OCILayoutScheme},
+	New:     NewOCILayoutPusher,
+}
+
 // All finds all of the registered pushers as a list of Provider instances.
 // Currently, just the built-in pushers are collected.
 func All(_ *cli.EnvSettings) Providers {
-	result := Providers{ociProvider}
+	result := Providers{ociProvider, ociLayoutProvider}
 	return result
 }
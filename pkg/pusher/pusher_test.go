@@ -54,8 +54,8 @@ func TestProviders(t *testing.T) {
 func TestAll(t *testing.T) {
 	env := cli.New()
 	all := All(env)
-	if len(all) != 1 {
-		t.Errorf("expected 1 provider (OCI), got %d", len(all))
+	if len(all) != 2 {
+		t.Errorf("expected 2 providers (OCI and OCI layout), got %d", len(all))
 	}
 }
 
@@ -65,4 +65,7 @@ func TestByScheme(t *testing.T) {
 	if _, err := g.ByScheme(registry.OCIScheme); err != nil {
 		t.Error(err)
 	}
+	if _, err := g.ByScheme(registry.OCILayoutScheme); err != nil {
+		t.Error(err)
+	}
 }
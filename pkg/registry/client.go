@@ -482,6 +482,49 @@ func (c *Client) Pull(ref string, options ...PullOption) (*PullResult, error) {
 	return result, nil
 }
 
+// ChartDigest returns the digest of the chart layer of the manifest of ref,
+// without pulling any layer, so that charts can be looked up in a cache of
+// chart archives.
+func (c *Client) ChartDigest(ref string) (string, error) {
+	parsedRef, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	remotesResolver, err := c.resolver(parsedRef)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := ctx(c.out, c.debug)
+	name, desc, err := remotesResolver.Resolve(ctx, parsedRef.String())
+	if err != nil {
+		return "", err
+	}
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return "", errors.Errorf("%s is not a chart manifest: unexpected media type %s", ref, desc.MediaType)
+	}
+	fetcher, err := remotesResolver.Fetcher(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var manifest ocispec.Manifest
+	if err := json.NewDecoder(io.LimitReader(rc, desc.Size)).Decode(&manifest); err != nil {
+		return "", errors.Wrapf(err, "unable to read the manifest of %s", ref)
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == ChartLayerMediaType || layer.MediaType == LegacyChartLayerMediaType {
+			return layer.Digest.String(), nil
+		}
+	}
+	return "", fmt.Errorf("manifest does not contain a layer with mediatype %s", ChartLayerMediaType)
+}
+
 // PullOptWithChart returns a function that sets the withChart setting on pull
 func PullOptWithChart(withChart bool) PullOption {
 	return func(operation *pullOperation) {
@@ -659,8 +702,13 @@ func PushOptCreationTime(creationTime string) PushOption {
 	}
 }
 
-// Tags provides a sorted list all semver compliant tags for a given repository
+// Tags provides a sorted list all semver compliant tags for a given repository,
+// or for a chart in an OCI image layout directory ("oci-layout://<path>/<name>")
 func (c *Client) Tags(ref string) ([]string, error) {
+	if IsOCILayout(ref) {
+		return layoutTags(ref)
+	}
+
 	parsedReference, err := registry.ParseReference(ref)
 	if err != nil {
 		return nil, err
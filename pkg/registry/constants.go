@@ -20,6 +20,10 @@ const (
 	// OCIScheme is the URL scheme for OCI-based requests
 	OCIScheme = "oci"
 
+	// OCILayoutScheme is the URL scheme for charts stored in OCI image layout
+	// directories on disk
+	OCILayoutScheme = "oci-layout"
+
 	// CredentialsFileBasename is the filename for auth credentials file
 	CredentialsFileBasename = "registry/config.json"
 
@@ -0,0 +1,584 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry // import "helm.sh/helm/v3/pkg/registry"
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/fileutil"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// Layout is an OCI image layout, as described by
+// https://github.com/opencontainers/image-spec/blob/main/image-layout.md,
+// held in memory or loaded from a directory.
+//
+// Charts are stored as they are pushed to registries, and are referenced by
+// their name and version, as in "mychart:1.2.3".
+type Layout struct {
+	// Index is the index of the layout, listing its manifests.
+	Index ocispec.Index
+
+	// blobs holds the blobs added to the layout, or read from an archive.
+	blobs map[digest.Digest][]byte
+	// dir is the directory the layout was loaded from, whose blobs are read
+	// when needed.
+	dir string
+}
+
+// NewLayout returns an empty layout.
+func NewLayout() *Layout {
+	return &Layout{
+		Index: ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: []ocispec.Descriptor{},
+		},
+		blobs: map[digest.Digest][]byte{},
+	}
+}
+
+// IsLayoutArchive returns whether the file at path is a tar archive of an OCI
+// image layout.
+func IsLayoutArchive(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hd, err := tr.Next()
+		if err != nil {
+			return false
+		}
+		if cleanLayoutPath(hd.Name) == ocispec.ImageLayoutFile {
+			return true
+		}
+	}
+}
+
+// LoadLayoutArchive reads an OCI image layout from a tar archive.
+func LoadLayoutArchive(r io.Reader) (*Layout, error) {
+	l := NewLayout()
+	var hasLayoutFile, hasIndex bool
+
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read OCI layout archive")
+		}
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := cleanLayoutPath(hd.Name)
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", name)
+		}
+
+		switch {
+		case name == ocispec.ImageLayoutFile:
+			if err := checkLayoutFile(data); err != nil {
+				return nil, err
+			}
+			hasLayoutFile = true
+		case name == ocispec.ImageIndexFile:
+			if err := json.Unmarshal(data, &l.Index); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse %s", name)
+			}
+			hasIndex = true
+		case strings.HasPrefix(name, ocispec.ImageBlobsDir+"/"):
+			parts := strings.Split(name, "/")
+			if len(parts) != 3 {
+				continue
+			}
+			d := digest.NewDigestFromEncoded(digest.Algorithm(parts[1]), parts[2])
+			if err := d.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid blob %s", name)
+			}
+			if d.Algorithm().FromBytes(data) != d {
+				return nil, errors.Errorf("blob %s does not match its digest", name)
+			}
+			l.blobs[d] = data
+		}
+	}
+
+	if !hasLayoutFile {
+		return nil, errors.Errorf("not an OCI layout: missing %s", ocispec.ImageLayoutFile)
+	}
+	if !hasIndex {
+		return nil, errors.Errorf("not an OCI layout: missing %s", ocispec.ImageIndexFile)
+	}
+	return l, nil
+}
+
+// LoadLayoutDir reads the OCI image layout in the directory dir. Its blobs are
+// only read, and checked against their digests, when needed.
+func LoadLayoutDir(dir string) (*Layout, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ocispec.ImageLayoutFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("%s is not an OCI layout: missing %s", dir, ocispec.ImageLayoutFile)
+		}
+		return nil, err
+	}
+	if err := checkLayoutFile(data); err != nil {
+		return nil, err
+	}
+
+	l := NewLayout()
+	l.dir = dir
+	data, err = os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("%s is not an OCI layout: missing %s", dir, ocispec.ImageIndexFile)
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.Index); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", ocispec.ImageIndexFile)
+	}
+	return l, nil
+}
+
+// WriteDir writes the layout to the directory dir, creating it if needed.
+// Blobs already in the directory are kept, and its index is replaced with the
+// index of the layout once all the blobs are written.
+func (l *Layout) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	layoutFile := filepath.Join(dir, ocispec.ImageLayoutFile)
+	if _, err := os.Stat(layoutFile); os.IsNotExist(err) {
+		data, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+		if err != nil {
+			return err
+		}
+		if err := fileutil.AtomicWriteFile(layoutFile, bytes.NewReader(data), 0644); err != nil {
+			return err
+		}
+	}
+
+	digests, err := l.digests()
+	if err != nil {
+		return err
+	}
+	for _, d := range digests {
+		blobFile := filepath.Join(dir, ocispec.ImageBlobsDir, d.Algorithm().String(), d.Encoded())
+		if _, err := os.Stat(blobFile); err == nil {
+			continue
+		}
+		data, err := l.blob(d)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(blobFile), 0755); err != nil {
+			return err
+		}
+		if err := fileutil.AtomicWriteFile(blobFile, bytes.NewReader(data), 0644); err != nil {
+			return err
+		}
+	}
+
+	index, err := json.Marshal(l.Index)
+	if err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(filepath.Join(dir, ocispec.ImageIndexFile), bytes.NewReader(index), 0644)
+}
+
+// WriteArchive writes the layout as a tar archive. Files are written in a
+// stable order and with fixed times, so that writing the same layout twice
+// yields the same archive.
+func (l *Layout) WriteArchive(w io.Writer) error {
+	layoutFile, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	index, err := json.Marshal(l.Index)
+	if err != nil {
+		return err
+	}
+
+	digests, err := l.digests()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	writeDir := func(name string) error {
+		return tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     name + "/",
+			Mode:     0755,
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatPAX,
+		})
+	}
+	writeFile := func(name string, data []byte) error {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatPAX,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	if err := writeFile(ocispec.ImageLayoutFile, layoutFile); err != nil {
+		return err
+	}
+	if err := writeFile(ocispec.ImageIndexFile, index); err != nil {
+		return err
+	}
+	algorithms := map[digest.Algorithm]bool{}
+	if err := writeDir(ocispec.ImageBlobsDir); err != nil {
+		return err
+	}
+	for _, d := range digests {
+		if !algorithms[d.Algorithm()] {
+			if err := writeDir(path.Join(ocispec.ImageBlobsDir, d.Algorithm().String())); err != nil {
+				return err
+			}
+			algorithms[d.Algorithm()] = true
+		}
+		data, err := l.blob(d)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(path.Join(ocispec.ImageBlobsDir, d.Algorithm().String(), d.Encoded()), data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// AddBlob adds data to the blobs of the layout, and returns its descriptor.
+func (l *Layout) AddBlob(mediaType string, data []byte) ocispec.Descriptor {
+	d := digest.FromBytes(data)
+	l.blobs[d] = data
+	return ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    d,
+		Size:      int64(len(data)),
+	}
+}
+
+// Blob returns the blob of the layout with the given descriptor.
+func (l *Layout) Blob(desc ocispec.Descriptor) ([]byte, error) {
+	return l.blob(desc.Digest)
+}
+
+func (l *Layout) blob(d digest.Digest) ([]byte, error) {
+	if data, ok := l.blobs[d]; ok {
+		return data, nil
+	}
+	if l.dir == "" || d.Validate() != nil {
+		return nil, errors.Errorf("blob %s not found", d)
+	}
+	name := path.Join(ocispec.ImageBlobsDir, d.Algorithm().String(), d.Encoded())
+	data, err := os.ReadFile(filepath.Join(l.dir, filepath.FromSlash(name)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("blob %s not found", d)
+		}
+		return nil, err
+	}
+	if d.Algorithm().FromBytes(data) != d {
+		return nil, errors.Errorf("blob %s does not match its digest", name)
+	}
+	return data, nil
+}
+
+// digests returns the sorted digests of all the blobs of the layout, including
+// the blobs in its directory.
+func (l *Layout) digests() ([]digest.Digest, error) {
+	seen := map[digest.Digest]bool{}
+	for d := range l.blobs {
+		seen[d] = true
+	}
+	if l.dir != "" {
+		algorithms, err := os.ReadDir(filepath.Join(l.dir, ocispec.ImageBlobsDir))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, algorithm := range algorithms {
+			if !algorithm.IsDir() {
+				continue
+			}
+			blobs, err := os.ReadDir(filepath.Join(l.dir, ocispec.ImageBlobsDir, algorithm.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, blob := range blobs {
+				d := digest.NewDigestFromEncoded(digest.Algorithm(algorithm.Name()), blob.Name())
+				if blob.Type().IsRegular() && d.Validate() == nil {
+					seen[d] = true
+				}
+			}
+		}
+	}
+
+	digests := make([]digest.Digest, 0, len(seen))
+	for d := range seen {
+		digests = append(digests, d)
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+	return digests, nil
+}
+
+// AddManifest adds a manifest to the layout and to its index, and returns its
+// descriptor. When ref is not empty, it replaces the manifest previously
+// listed in the index under that reference, if any.
+func (l *Layout) AddManifest(manifest ocispec.Manifest, ref string) (ocispec.Descriptor, error) {
+	manifest.SchemaVersion = 2
+	manifest.MediaType = ocispec.MediaTypeImageManifest
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := l.AddBlob(ocispec.MediaTypeImageManifest, data)
+	desc.ArtifactType = manifest.ArtifactType
+	if ref != "" {
+		desc.Annotations = map[string]string{ocispec.AnnotationRefName: ref}
+	}
+
+	manifests := l.Index.Manifests[:0]
+	for _, m := range l.Index.Manifests {
+		if ref == "" || m.Annotations[ocispec.AnnotationRefName] != ref {
+			manifests = append(manifests, m)
+		}
+	}
+	l.Index.Manifests = append(manifests, desc)
+	return desc, nil
+}
+
+// Manifest returns the manifest listed in the index with the given reference.
+func (l *Layout) Manifest(ref string) (ocispec.Descriptor, *ocispec.Manifest, error) {
+	for _, desc := range l.Index.Manifests {
+		if desc.Annotations[ocispec.AnnotationRefName] == ref {
+			manifest, err := l.manifest(desc)
+			return desc, manifest, err
+		}
+	}
+	return ocispec.Descriptor{}, nil, errors.Errorf("%s: not found", ref)
+}
+
+// Referrers returns the manifests of the given artifact type whose subject is
+// the manifest with the given descriptor.
+func (l *Layout) Referrers(subject ocispec.Descriptor, artifactType string) ([]*ocispec.Manifest, error) {
+	var referrers []*ocispec.Manifest
+	for _, desc := range l.Index.Manifests {
+		if desc.ArtifactType != artifactType {
+			continue
+		}
+		manifest, err := l.manifest(desc)
+		if err != nil {
+			return nil, err
+		}
+		if manifest.Subject != nil && manifest.Subject.Digest == subject.Digest {
+			referrers = append(referrers, manifest)
+		}
+	}
+	return referrers, nil
+}
+
+func (l *Layout) manifest(desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	data, err := l.Blob(desc)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ocispec.Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse manifest %s", desc.Digest)
+	}
+	return manifest, nil
+}
+
+// PushChart adds the chart archive data, along with its provenance file if
+// prov is not nil, to the layout. It is referenced by the name and version of
+// the chart, replacing the chart previously added with the same reference.
+func (l *Layout) PushChart(data, prov []byte, creationTime string) (ocispec.Descriptor, error) {
+	meta, err := extractChartMeta(data)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	configData, err := json.Marshal(meta)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	manifest := ocispec.Manifest{
+		Config:      l.AddBlob(ConfigMediaType, configData),
+		Layers:      []ocispec.Descriptor{l.AddBlob(ChartLayerMediaType, data)},
+		Annotations: generateOCIAnnotations(meta, creationTime),
+	}
+	if prov != nil {
+		manifest.Layers = append(manifest.Layers, l.AddBlob(ProvLayerMediaType, prov))
+	}
+	return l.AddManifest(manifest, LayoutChartRef(meta.Name, meta.Version))
+}
+
+// PullChart returns the chart archive referenced by ref in the layout, along
+// with its provenance file, which is nil when the chart has none.
+func (l *Layout) PullChart(ref string) (data, prov []byte, err error) {
+	_, manifest, err := l.Manifest(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest.Config.MediaType != ConfigMediaType {
+		return nil, nil, errors.Errorf("%s is not a chart", ref)
+	}
+	for _, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case ChartLayerMediaType, LegacyChartLayerMediaType:
+			if data, err = l.Blob(layer); err != nil {
+				return nil, nil, err
+			}
+		case ProvLayerMediaType:
+			if prov, err = l.Blob(layer); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if data == nil {
+		return nil, nil, errors.Errorf("%s has no chart content", ref)
+	}
+	return data, prov, nil
+}
+
+// Charts returns the references of the charts of the layout, in the order of
+// the index.
+func (l *Layout) Charts() []string {
+	var refs []string
+	for _, desc := range l.Index.Manifests {
+		if ref, ok := desc.Annotations[ocispec.AnnotationRefName]; ok && desc.ArtifactType == "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// ChartMetadata returns the metadata of the chart referenced by ref in the
+// layout, as stored in the config of its manifest.
+func (l *Layout) ChartMetadata(ref string) (*chart.Metadata, error) {
+	_, manifest, err := l.Manifest(ref)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Config.MediaType != ConfigMediaType {
+		return nil, errors.Errorf("%s is not a chart", ref)
+	}
+	data, err := l.Blob(manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+	meta := &chart.Metadata{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the config of %s", ref)
+	}
+	return meta, nil
+}
+
+// Tags returns the versions of the charts of the layout with the given name,
+// sorted from the newest to the oldest, as Client.Tags does for repositories
+// of registries.
+func (l *Layout) Tags(name string) []string {
+	var versions []*semver.Version
+	for _, ref := range l.Charts() {
+		version := strings.TrimPrefix(ref, name+":")
+		if version == ref {
+			continue
+		}
+		if v, err := semver.StrictNewVersion(version); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	tags := make([]string, len(versions))
+	for i, v := range versions {
+		tags[i] = v.String()
+	}
+	return tags
+}
+
+// layoutTags returns the versions of the chart referenced by ref, in the form
+// "oci-layout://<path>/<name>", sorted from the newest to the oldest.
+func layoutTags(ref string) ([]string, error) {
+	dir, name, _, err := ParseLayoutReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	l, err := LoadLayoutDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return l.Tags(name), nil
+}
+
+// LayoutChartRef returns the reference of the chart with the given name and
+// version in layouts.
+func LayoutChartRef(name, version string) string {
+	return fmt.Sprintf("%s:%s", name, version)
+}
+
+// checkLayoutFile checks the contents of the oci-layout file of a layout.
+func checkLayoutFile(data []byte) error {
+	var layout ocispec.ImageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return errors.Wrapf(err, "unable to parse %s", ocispec.ImageLayoutFile)
+	}
+	if layout.Version != ocispec.ImageLayoutVersion {
+		return errors.Errorf("unsupported OCI layout version %q", layout.Version)
+	}
+	return nil
+}
+
+// cleanLayoutPath returns name, a path in a layout archive, without its
+// leading "./" or "/".
+func cleanLayoutPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,252 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutArchive(t *testing.T) {
+	is := assert.New(t)
+
+	chartData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+	provData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz.prov")
+	require.NoError(t, err)
+	subchartData, err := os.ReadFile("../downloader/testdata/local-subchart-0.1.0.tgz")
+	require.NoError(t, err)
+
+	l := NewLayout()
+	desc, err := l.PushChart(chartData, provData, "2024-01-02T03:04:05Z")
+	require.NoError(t, err)
+	is.Equal("signtest:0.1.0", desc.Annotations[ocispec.AnnotationRefName])
+	_, err = l.PushChart(subchartData, nil, "2024-01-02T03:04:05Z")
+	require.NoError(t, err)
+	// Pushing a chart again replaces it.
+	_, err = l.PushChart(subchartData, nil, "2024-01-02T03:04:05Z")
+	require.NoError(t, err)
+	is.Equal([]string{"signtest:0.1.0", "local-subchart:0.1.0"}, l.Charts())
+
+	var buf bytes.Buffer
+	require.NoError(t, l.WriteArchive(&buf))
+	path := filepath.Join(t.TempDir(), "layout.tar")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	is.True(IsLayoutArchive(path))
+	is.False(IsLayoutArchive("../downloader/testdata/signtest-0.1.0.tgz"))
+	is.False(IsLayoutArchive(filepath.Join(t.TempDir(), "missing.tar")))
+
+	// Writing the same layout twice yields the same archive.
+	var again bytes.Buffer
+	require.NoError(t, l.WriteArchive(&again))
+	is.Equal(buf.Bytes(), again.Bytes())
+
+	loaded, err := LoadLayoutArchive(&buf)
+	require.NoError(t, err)
+	is.Equal(l.Charts(), loaded.Charts())
+
+	data, prov, err := loaded.PullChart("signtest:0.1.0")
+	require.NoError(t, err)
+	is.Equal(chartData, data)
+	is.Equal(provData, prov)
+
+	data, prov, err = loaded.PullChart("local-subchart:0.1.0")
+	require.NoError(t, err)
+	is.Equal(subchartData, data)
+	is.Nil(prov)
+
+	_, _, err = loaded.PullChart("signtest:0.2.0")
+	is.EqualError(err, "signtest:0.2.0: not found")
+}
+
+func TestLayoutReferrers(t *testing.T) {
+	chartData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+
+	l := NewLayout()
+	subject, err := l.PushChart(chartData, nil, "")
+	require.NoError(t, err)
+	_, err = l.AddManifest(ocispec.Manifest{
+		ArtifactType: "application/vnd.example+json",
+		Config:       l.AddBlob(ocispec.MediaTypeEmptyJSON, []byte("{}")),
+		Layers:       []ocispec.Descriptor{l.AddBlob("application/json", []byte(`"data"`))},
+		Subject:      &subject,
+	}, "")
+	require.NoError(t, err)
+
+	referrers, err := l.Referrers(subject, "application/vnd.example+json")
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	data, err := l.Blob(referrers[0].Layers[0])
+	require.NoError(t, err)
+	assert.Equal(t, `"data"`, string(data))
+
+	referrers, err = l.Referrers(subject, "application/vnd.other+json")
+	require.NoError(t, err)
+	assert.Empty(t, referrers)
+
+	// Artifacts are not charts.
+	assert.Equal(t, []string{"signtest:0.1.0"}, l.Charts())
+}
+
+func TestLayoutDir(t *testing.T) {
+	is := assert.New(t)
+
+	chartData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+	provData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz.prov")
+	require.NoError(t, err)
+	subchartData, err := os.ReadFile("../downloader/testdata/local-subchart-0.1.0.tgz")
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "layout")
+	_, err = LoadLayoutDir(dir)
+	is.EqualError(err, dir+" is not an OCI layout: missing oci-layout")
+
+	l := NewLayout()
+	_, err = l.PushChart(chartData, provData, "")
+	require.NoError(t, err)
+	require.NoError(t, l.WriteDir(dir))
+
+	// Charts are added to the layouts loaded from directories.
+	loaded, err := LoadLayoutDir(dir)
+	require.NoError(t, err)
+	_, err = loaded.PushChart(subchartData, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, loaded.WriteDir(dir))
+
+	loaded, err = LoadLayoutDir(dir)
+	require.NoError(t, err)
+	is.Equal([]string{"signtest:0.1.0", "local-subchart:0.1.0"}, loaded.Charts())
+	is.Equal([]string{"0.1.0"}, loaded.Tags("signtest"))
+	is.Empty(loaded.Tags("sign"))
+	data, prov, err := loaded.PullChart("signtest:0.1.0")
+	require.NoError(t, err)
+	is.Equal(chartData, data)
+	is.Equal(provData, prov)
+
+	// The archive of a layout loaded from a directory holds all its blobs.
+	var buf bytes.Buffer
+	require.NoError(t, loaded.WriteArchive(&buf))
+	archived, err := LoadLayoutArchive(&buf)
+	require.NoError(t, err)
+	data, _, err = archived.PullChart("local-subchart:0.1.0")
+	require.NoError(t, err)
+	is.Equal(subchartData, data)
+
+	// Blobs are checked against their digests.
+	_, manifest, err := loaded.Manifest("signtest:0.1.0")
+	require.NoError(t, err)
+	blob := filepath.Join(dir, "blobs", "sha256", manifest.Layers[0].Digest.Encoded())
+	require.NoError(t, os.WriteFile(blob, []byte("corrupted"), 0644))
+	_, _, err = loaded.PullChart("signtest:0.1.0")
+	is.ErrorContains(err, "does not match its digest")
+}
+
+func TestLayoutTags(t *testing.T) {
+	l := NewLayout()
+	for _, ref := range []string{"app:1.0.0", "app:1.10.0", "app:1.2.0-rc.1", "app:latest", "other:2.0.0"} {
+		_, err := l.AddManifest(ocispec.Manifest{}, ref)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"1.10.0", "1.2.0-rc.1", "1.0.0"}, l.Tags("app"))
+}
+
+func TestLayoutListing(t *testing.T) {
+	is := assert.New(t)
+
+	chartData, err := os.ReadFile("../downloader/testdata/signtest-0.1.0.tgz")
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "layout")
+	l := NewLayout()
+	_, err = l.PushChart(chartData, nil, "2024-01-02T03:04:05Z")
+	require.NoError(t, err)
+	require.NoError(t, l.WriteDir(dir))
+
+	meta, err := l.ChartMetadata("signtest:0.1.0")
+	require.NoError(t, err)
+	is.Equal("signtest", meta.Name)
+	is.Equal("0.1.0", meta.Version)
+	_, err = l.ChartMetadata("signtest:0.2.0")
+	is.ErrorContains(err, "not found")
+
+	client, err := NewClient()
+	require.NoError(t, err)
+	tags, err := client.Tags("oci-layout://" + dir + "/signtest")
+	require.NoError(t, err)
+	is.Equal([]string{"0.1.0"}, tags)
+	_, err = client.Tags("oci-layout://" + t.TempDir() + "/signtest")
+	is.ErrorContains(err, "is not an OCI layout")
+}
+
+func TestLoadLayoutArchiveErrors(t *testing.T) {
+	archive := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, data := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte(data))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		return &buf
+	}
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		expect string
+	}{
+		{
+			name:   "missing layout file",
+			files:  map[string]string{"index.json": "{}"},
+			expect: "not an OCI layout: missing oci-layout",
+		},
+		{
+			name:   "missing index",
+			files:  map[string]string{"oci-layout": `{"imageLayoutVersion":"1.0.0"}`},
+			expect: "not an OCI layout: missing index.json",
+		},
+		{
+			name:   "unsupported version",
+			files:  map[string]string{"oci-layout": `{"imageLayoutVersion":"2.0.0"}`},
+			expect: `unsupported OCI layout version "2.0.0"`,
+		},
+		{
+			name: "corrupted blob",
+			files: map[string]string{
+				"./oci-layout": `{"imageLayoutVersion":"1.0.0"}`,
+				"./index.json": "{}",
+				"./blobs/sha256/44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a": "{ }",
+			},
+			expect: "blob blobs/sha256/44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a does not match its digest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadLayoutArchive(archive(tt.files))
+			assert.EqualError(t, err, tt.expect)
+		})
+	}
+}
@@ -49,6 +49,32 @@ func IsOCI(url string) bool {
 	return strings.HasPrefix(url, fmt.Sprintf("%s://", OCIScheme))
 }
 
+// IsOCILayout determines whether or not a URL is to be treated as a reference
+// to a chart in an OCI image layout directory
+func IsOCILayout(url string) bool {
+	return strings.HasPrefix(url, fmt.Sprintf("%s://", OCILayoutScheme))
+}
+
+// ParseLayoutReference splits a reference to a chart in an OCI image layout
+// directory, in the form "oci-layout://<path>/<name>[:<version>]", into the
+// path of the layout, the name of the chart and its version, which is empty
+// when the reference has none.
+func ParseLayoutReference(ref string) (dir, name, version string, err error) {
+	p := strings.TrimPrefix(ref, fmt.Sprintf("%s://", OCILayoutScheme))
+	idx := strings.LastIndexByte(p, '/')
+	if idx < 0 || idx == len(p)-1 {
+		return "", "", "", errors.Errorf("improperly formatted OCI layout chart reference: %s", ref)
+	}
+	dir, name = p[:idx], p[idx+1:]
+	if dir == "" {
+		dir = "/"
+	}
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name, version = name[:i], name[i+1:]
+	}
+	return dir, name, version, nil
+}
+
 // ContainsTag determines whether a tag is found in a provided list of tags
 func ContainsTag(tags []string, tag string) bool {
 	for _, t := range tags {
@@ -273,3 +273,35 @@ func TestGenerateOCICreatedAnnotations(t *testing.T) {
 	}
 
 }
+
+func TestParseLayoutReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		dir     string
+		name    string
+		version string
+		err     bool
+	}{
+		{ref: "oci-layout://./layout/mychart", dir: "./layout", name: "mychart"},
+		{ref: "oci-layout:///tmp/layout/mychart:1.2.3", dir: "/tmp/layout", name: "mychart", version: "1.2.3"},
+		{ref: "oci-layout:///mychart:1.2.3+build", dir: "/", name: "mychart", version: "1.2.3+build"},
+		{ref: "oci-layout://mychart", err: true},
+		{ref: "oci-layout://./layout/", err: true},
+	}
+	for _, tt := range tests {
+		dir, name, version, err := ParseLayoutReference(tt.ref)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.ref, err)
+			continue
+		}
+		if dir != tt.dir || name != tt.name || version != tt.version {
+			t.Errorf("%s: expected %q, %q, %q, got %q, %q, %q", tt.ref, tt.dir, tt.name, tt.version, dir, name, version)
+		}
+	}
+}
@@ -36,6 +36,7 @@ import (
 	_ "github.com/distribution/distribution/v3/registry/auth/htpasswd"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
 	"github.com/foxcpp/go-mockdns"
+	"github.com/opencontainers/go-digest"
 	"github.com/phayes/freeport"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/crypto/bcrypt"
@@ -393,4 +394,9 @@ func testTags(suite *TestSuite) {
 	tags, err := suite.RegistryClient.Tags(ref)
 	suite.Nil(err, "no error retrieving tags")
 	suite.Equal(1, len(tags))
+
+	// The digest of the chart layer is the digest of the chart archive
+	chartDigest, err := suite.RegistryClient.ChartDigest(fmt.Sprintf("%s:%s", ref, tags[0]))
+	suite.Nil(err, "no error resolving the chart digest")
+	suite.Equal(digest.FromBytes(chartData).String(), chartDigest)
 }
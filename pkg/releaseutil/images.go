@@ -0,0 +1,200 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Image is a container image referenced by the resources of a manifest.
+type Image struct {
+	// Image is the reference of the image, as written in the manifest.
+	Image string `json:"image"`
+	// Resources are the resources using the image, as "Kind/name", or
+	// "Kind/namespace/name" for resources with an explicit namespace.
+	Resources []string `json:"resources"`
+}
+
+// podSpecPaths are the paths of the pod specs of workloads, by kind. The pod
+// spec of other kinds is at spec.template.spec, as for Deployments and the
+// many custom resources modeled after them.
+var podSpecPaths = map[string]string{
+	"Pod":         "spec",
+	"PodTemplate": "template.spec",
+	"CronJob":     "spec.jobTemplate.spec.template.spec",
+}
+
+// podSpecImagePaths are the paths of the images of a pod spec.
+var podSpecImagePaths = []string{
+	"containers[].image",
+	"initContainers[].image",
+	"ephemeralContainers[].image",
+}
+
+// customResourceImagePaths are the paths of the images of well-known custom
+// resources not embedding a pod template, by API group and kind.
+var customResourceImagePaths = map[string][]string{
+	// Tekton
+	"tekton.dev/Task":        {"spec.steps[].image", "spec.sidecars[].image"},
+	"tekton.dev/ClusterTask": {"spec.steps[].image", "spec.sidecars[].image"},
+	// Argo Workflows
+	"argoproj.io/Workflow":                argoWorkflowImagePaths("spec"),
+	"argoproj.io/WorkflowTemplate":        argoWorkflowImagePaths("spec"),
+	"argoproj.io/ClusterWorkflowTemplate": argoWorkflowImagePaths("spec"),
+	"argoproj.io/CronWorkflow":            argoWorkflowImagePaths("spec.workflowSpec"),
+	// Prometheus Operator
+	"monitoring.coreos.com/Prometheus":   prometheusOperatorImagePaths,
+	"monitoring.coreos.com/Alertmanager": prometheusOperatorImagePaths,
+	"monitoring.coreos.com/ThanosRuler":  prometheusOperatorImagePaths,
+}
+
+var prometheusOperatorImagePaths = []string{
+	"spec.image",
+	"spec.containers[].image",
+	"spec.initContainers[].image",
+}
+
+func argoWorkflowImagePaths(spec string) []string {
+	return []string{
+		spec + ".templates[].container.image",
+		spec + ".templates[].script.image",
+		spec + ".templates[].containerSet.containers[].image",
+		spec + ".templates[].initContainers[].image",
+		spec + ".templates[].sidecars[].image",
+	}
+}
+
+// ExtractImages returns the container images referenced by the resources of a
+// manifest, which may hold many YAML documents, sorted by reference.
+//
+// Images are found in the pod specs of all workload kinds, and in the fields
+// of well-known custom resources, such as Tekton Tasks and Argo Workflows.
+func ExtractImages(manifest string) ([]Image, error) {
+	resources := map[string]map[string]bool{}
+	for _, doc := range sep.Split(strings.TrimSpace(manifest), -1) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse manifest:\n%s", doc)
+		}
+		for _, o := range flattenList(obj) {
+			resource := resourceName(o)
+			for _, image := range objectImages(o) {
+				if resources[image] == nil {
+					resources[image] = map[string]bool{}
+				}
+				resources[image][resource] = true
+			}
+		}
+	}
+
+	images := make([]Image, 0, len(resources))
+	for image, users := range resources {
+		img := Image{Image: image}
+		for r := range users {
+			img.Resources = append(img.Resources, r)
+		}
+		sort.Strings(img.Resources)
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Image < images[j].Image })
+	return images, nil
+}
+
+// flattenList returns the items of Lists, and other objects as they are.
+func flattenList(obj map[string]interface{}) []map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	kind, _ := obj["kind"].(string)
+	items, ok := obj["items"].([]interface{})
+	if !ok || !strings.HasSuffix(kind, "List") {
+		return []map[string]interface{}{obj}
+	}
+	var objs []map[string]interface{}
+	for _, item := range items {
+		if o, ok := item.(map[string]interface{}); ok {
+			objs = append(objs, flattenList(o)...)
+		}
+	}
+	return objs
+}
+
+func resourceName(obj map[string]interface{}) string {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		return kind + "/" + namespace + "/" + name
+	}
+	return kind + "/" + name
+}
+
+// objectImages returns the images referenced by obj.
+func objectImages(obj map[string]interface{}) []string {
+	kind, _ := obj["kind"].(string)
+	apiVersion, _ := obj["apiVersion"].(string)
+	group, _, _ := strings.Cut(apiVersion, "/")
+
+	var paths []string
+	if p, ok := customResourceImagePaths[group+"/"+kind]; ok {
+		paths = p
+	} else {
+		podSpec, ok := podSpecPaths[kind]
+		if !ok {
+			podSpec = "spec.template.spec"
+		}
+		for _, p := range podSpecImagePaths {
+			paths = append(paths, podSpec+"."+p)
+		}
+	}
+
+	var images []string
+	for _, p := range paths {
+		for _, v := range lookupAll(obj, strings.Split(p, ".")) {
+			if image, ok := v.(string); ok && strings.TrimSpace(image) != "" {
+				images = append(images, strings.TrimSpace(image))
+			}
+		}
+	}
+	return images
+}
+
+// lookupAll returns the values at path in v. Keys suffixed with "[]" match
+// all the items of the list they hold.
+func lookupAll(v interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{v}
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	key := path[0]
+	if !strings.HasSuffix(key, "[]") {
+		return lookupAll(m[key], path[1:])
+	}
+	items, _ := m[strings.TrimSuffix(key, "[]")].([]interface{})
+	var values []interface{}
+	for _, item := range items {
+		values = append(values, lookupAll(item, path[1:])...)
+	}
+	return values
+}
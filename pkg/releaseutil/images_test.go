@@ -0,0 +1,146 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releaseutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const imagesManifest = `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: example.com/migrate:1.0
+      containers:
+        - name: web
+          image: nginx:1.25
+        - name: sidecar
+          image: " envoyproxy/envoy:v1.29 "
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  namespace: tools
+spec:
+  containers:
+    - name: debug
+      image: nginx:1.25
+  ephemeralContainers:
+    - name: shell
+      image: busybox
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: example.com/backup@sha256:0123
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: not-an-image
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: argoproj.io/v1alpha1
+    kind: Rollout
+    metadata:
+      name: canary
+    spec:
+      template:
+        spec:
+          containers:
+            - image: nginx:1.26
+  - apiVersion: tekton.dev/v1
+    kind: Task
+    metadata:
+      name: build
+    spec:
+      steps:
+        - image: golang:1.22
+      sidecars:
+        - image: docker:dind
+  - apiVersion: argoproj.io/v1alpha1
+    kind: CronWorkflow
+    metadata:
+      name: nightly
+    spec:
+      workflowSpec:
+        templates:
+          - container:
+              image: alpine:3.19
+          - script:
+              image: python:3.12
+---
+apiVersion: monitoring.coreos.com/v1
+kind: Prometheus
+metadata:
+  name: main
+spec:
+  image: quay.io/prometheus/prometheus:v2.50.0
+# Trailing comment
+---
+`
+
+func TestExtractImages(t *testing.T) {
+	images, err := ExtractImages(imagesManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Image{
+		{Image: "alpine:3.19", Resources: []string{"CronWorkflow/nightly"}},
+		{Image: "busybox", Resources: []string{"Pod/tools/debug"}},
+		{Image: "docker:dind", Resources: []string{"Task/build"}},
+		{Image: "envoyproxy/envoy:v1.29", Resources: []string{"Deployment/web"}},
+		{Image: "example.com/backup@sha256:0123", Resources: []string{"CronJob/backup"}},
+		{Image: "example.com/migrate:1.0", Resources: []string{"Deployment/web"}},
+		{Image: "golang:1.22", Resources: []string{"Task/build"}},
+		{Image: "nginx:1.25", Resources: []string{"Deployment/web", "Pod/tools/debug"}},
+		{Image: "nginx:1.26", Resources: []string{"Rollout/canary"}},
+		{Image: "python:3.12", Resources: []string{"CronWorkflow/nightly"}},
+		{Image: "quay.io/prometheus/prometheus:v2.50.0", Resources: []string{"Prometheus/main"}},
+	}
+	if !reflect.DeepEqual(images, expect) {
+		t.Errorf("expected images\n%v\ngot\n%v", expect, images)
+	}
+
+	if images, err := ExtractImages(""); err != nil || len(images) != 0 {
+		t.Errorf("expected no images in an empty manifest, got %v, %v", images, err)
+	}
+	if _, err := ExtractImages("kind: [Deployment"); err == nil {
+		t.Error("expected an invalid manifest to fail")
+	}
+}
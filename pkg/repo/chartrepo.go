@@ -48,6 +48,10 @@ type Entry struct {
 	CAFile                string `json:"caFile"`
 	InsecureSkipTLSverify bool   `json:"insecure_skip_tls_verify"`
 	PassCredentialsAll    bool   `json:"pass_credentials_all"`
+	// Mirrors are the URLs of the mirrors of the repository, tried in order
+	// when the repository is unavailable. The credentials of the repository
+	// are only sent to mirrors with PassCredentialsAll.
+	Mirrors []string `json:"mirrors,omitempty"`
 }
 
 // ChartRepository represents a chart repository
@@ -57,6 +61,11 @@ type ChartRepository struct {
 	IndexFile  *IndexFile
 	Client     getter.Getter
 	CachePath  string
+	// Log receives debug messages, such as the URL the index was downloaded
+	// from. It may be nil.
+	Log func(format string, v ...interface{})
+
+	getters getter.Providers
 }
 
 // NewChartRepository constructs ChartRepository
@@ -70,12 +79,18 @@ func NewChartRepository(cfg *Entry, getters getter.Providers) (*ChartRepository,
 	if err != nil {
 		return nil, errors.Errorf("could not find protocol handler for: %s", u.Scheme)
 	}
+	for _, mirror := range cfg.Mirrors {
+		if _, err := url.Parse(mirror); err != nil {
+			return nil, errors.Errorf("invalid mirror URL format: %s", mirror)
+		}
+	}
 
 	return &ChartRepository{
 		Config:    cfg,
 		IndexFile: NewIndexFile(),
 		Client:    client,
 		CachePath: helmpath.CachePath("repository"),
+		getters:   getters,
 	}, nil
 }
 
@@ -114,26 +129,38 @@ func (r *ChartRepository) Load() error {
 }
 
 // DownloadIndexFile fetches the index from a repository.
+//
+// The index is only fetched if it has changed since it was last downloaded,
+// when the repository supports conditional requests. When the repository cannot
+// be reached or fails to serve the index, it is fetched from the mirrors of the
+// repository, in order.
 func (r *ChartRepository) DownloadIndexFile() (string, error) {
-	indexURL, err := ResolveReferenceURL(r.Config.URL, "index.yaml")
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := r.Client.Get(indexURL,
-		getter.WithURL(r.Config.URL),
-		getter.WithInsecureSkipVerifyTLS(r.Config.InsecureSkipTLSverify),
-		getter.WithTLSClientConfig(r.Config.CertFile, r.Config.KeyFile, r.Config.CAFile),
-		getter.WithBasicAuth(r.Config.Username, r.Config.Password),
-		getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
-	)
-	if err != nil {
-		return "", err
-	}
+	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
+	chartsFile := filepath.Join(r.CachePath, helmpath.CacheChartsFile(r.Config.Name))
 
-	index, err := io.ReadAll(resp)
-	if err != nil {
-		return "", err
+	var index []byte
+	var indexURL string
+	var validators *getter.CacheValidators
+	for i, endpoint := range r.Config.URLs() {
+		var err error
+		indexURL, err = ResolveReferenceURL(endpoint, "index.yaml")
+		if err != nil {
+			return "", err
+		}
+		validators = r.cacheValidators(fname, chartsFile, indexURL)
+		index, err = r.fetchIndex(endpoint, indexURL, validators)
+		if errors.Is(err, getter.ErrNotModified) {
+			r.logf("Index of %s has not changed at %s", r.Config.URL, endpoint)
+			return fname, nil
+		}
+		if err == nil {
+			r.logf("Downloaded the index of %s from %s", r.Config.URL, endpoint)
+			break
+		}
+		if i == len(r.Config.Mirrors) || !getter.IsUnavailable(err) {
+			return "", err
+		}
+		r.logf("Unable to download the index of %s from %s, trying the next mirror: %s", r.Config.URL, endpoint, err)
 	}
 
 	indexFile, err := loadIndex(index, r.Config.URL)
@@ -146,14 +173,86 @@ func (r *ChartRepository) DownloadIndexFile() (string, error) {
 	for name := range indexFile.Entries {
 		fmt.Fprintln(&charts, name)
 	}
-	chartsFile := filepath.Join(r.CachePath, helmpath.CacheChartsFile(r.Config.Name))
 	os.MkdirAll(filepath.Dir(chartsFile), 0755)
 	os.WriteFile(chartsFile, []byte(charts.String()), 0644)
 
 	// Create the index file in the cache directory
-	fname := filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))
 	os.MkdirAll(filepath.Dir(fname), 0755)
-	return fname, os.WriteFile(fname, index, 0644)
+	if err := os.WriteFile(fname, index, 0644); err != nil {
+		return fname, err
+	}
+
+	// Cache the parsed index, along with the validators of the response
+	header := indexCacheHeader{
+		URL:          indexURL,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+	}
+	if err := writeIndexCache(fname, index, indexFile, header); err != nil {
+		log.Printf("WARNING: failed to cache the index of %s: %s", r.Config.URL, err)
+	}
+	return fname, nil
+}
+
+// fetchIndex fetches the index at indexURL, from the repository or one of its
+// mirrors at endpoint.
+func (r *ChartRepository) fetchIndex(endpoint, indexURL string, validators *getter.CacheValidators) ([]byte, error) {
+	client := r.Client
+	if endpoint != r.Config.URL {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, errors.Errorf("invalid mirror URL format: %s", endpoint)
+		}
+		if p, _ := url.Parse(r.Config.URL); p == nil || p.Scheme != u.Scheme {
+			if client, err = r.getters.ByScheme(u.Scheme); err != nil {
+				return nil, errors.Errorf("could not find protocol handler for: %s", u.Scheme)
+			}
+		}
+	}
+
+	username, password := r.Config.Username, r.Config.Password
+	if endpoint != r.Config.URL && !r.Config.PassCredentialsAll {
+		// Mirrors are other services: the credentials of the repository are
+		// only sent to them when they are passed to all domains.
+		username, password = "", ""
+	}
+	resp, err := client.Get(indexURL,
+		getter.WithURL(endpoint),
+		getter.WithInsecureSkipVerifyTLS(r.Config.InsecureSkipTLSverify),
+		getter.WithTLSClientConfig(r.Config.CertFile, r.Config.KeyFile, r.Config.CAFile),
+		getter.WithBasicAuth(username, password),
+		getter.WithPassCredentialsAll(r.Config.PassCredentialsAll),
+		getter.WithCacheValidators(validators),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp)
+}
+
+func (r *ChartRepository) logf(format string, v ...interface{}) {
+	if r.Log != nil {
+		r.Log(format, v...)
+	}
+}
+
+// cacheValidators returns the validators of the response the index file was
+// downloaded from, if it is still cached unchanged along with its chart list.
+func (r *ChartRepository) cacheValidators(fname, chartsFile, indexURL string) *getter.CacheValidators {
+	validators := &getter.CacheValidators{}
+	header, err := readIndexCacheHeader(fname)
+	if err != nil || header.URL != indexURL {
+		return validators
+	}
+	if _, err := os.Stat(chartsFile); err != nil {
+		return validators
+	}
+	if index, err := os.ReadFile(fname); err != nil || indexDigest(index) != header.Digest {
+		return validators
+	}
+	validators.ETag = header.ETag
+	validators.LastModified = header.LastModified
+	return validators
 }
 
 // Index generates an index for the chart repository and writes an index.yaml file.
@@ -175,7 +274,7 @@ func (r *ChartRepository) saveIndexFile() error {
 
 func (r *ChartRepository) generateIndex() error {
 	for _, path := range r.ChartPaths {
-		ch, err := loader.Load(path)
+		ch, err := loader.LoadFile(path)
 		if err != nil {
 			return err
 		}
@@ -251,6 +350,7 @@ func FindChartInAuthAndTLSAndPassRepoURL(repoURL, username, password, chartName,
 	defer func() {
 		os.RemoveAll(filepath.Join(r.CachePath, helmpath.CacheChartsFile(r.Config.Name)))
 		os.RemoveAll(filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name)))
+		os.RemoveAll(IndexCacheFile(filepath.Join(r.CachePath, helmpath.CacheIndexFile(r.Config.Name))))
 	}()
 
 	// Read the index file for the repository to get chart information and return chart URL
@@ -308,6 +408,35 @@ func ResolveReferenceURL(baseURL, refURL string) (string, error) {
 	return resolvedURL.String(), nil
 }
 
+// URLs returns the URL of the repository followed by the URLs of its mirrors.
+func (e *Entry) URLs() []string {
+	return append([]string{e.URL}, e.Mirrors...)
+}
+
+// MirrorURL returns the URL of the file at u, in the repository, in the given
+// mirror of the repository. It returns false if u is not in the repository.
+func (e *Entry) MirrorURL(mirror, u string) (string, bool) {
+	ref, err := url.Parse(u)
+	if err != nil {
+		return "", false
+	}
+	base, err := url.Parse(e.URL)
+	if err != nil {
+		return "", false
+	}
+	ref.RawQuery = ""
+	base.RawQuery = ""
+	prefix := strings.TrimSuffix(base.String(), "/") + "/"
+	if !strings.HasPrefix(ref.String(), prefix) {
+		return "", false
+	}
+	mirrorURL, err := ResolveReferenceURL(mirror, strings.TrimPrefix(ref.String(), prefix))
+	if err != nil {
+		return "", false
+	}
+	return mirrorURL, true
+}
+
 func (e *Entry) String() string {
 	buf, err := json.Marshal(e)
 	if err != nil {
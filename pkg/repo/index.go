@@ -103,11 +103,17 @@ func NewIndexFile() *IndexFile {
 }
 
 // LoadIndexFile takes a file at the given path and returns an IndexFile object
+//
+// The index is loaded from its cache if it was downloaded by DownloadIndexFile
+// and has not changed since, which is much faster than parsing it.
 func LoadIndexFile(path string) (*IndexFile, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if i, err := loadIndexCache(path, b); err == nil {
+		return i, nil
+	}
 	i, err := loadIndex(b, path)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading %s", path)
@@ -322,7 +328,7 @@ func IndexDirectory(dir, baseURL string) (*IndexFile, error) {
 			parentURL = path.Join(baseURL, parentDir)
 		}
 
-		c, err := loader.Load(arch)
+		c, err := loader.LoadFile(arch)
 		if err != nil {
 			// Assume this is not a chart.
 			continue
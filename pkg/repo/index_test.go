@@ -349,6 +349,224 @@ func TestDownloadIndexFile(t *testing.T) {
 		}
 		verifyLocalChartsFile(t, b, i)
 	})
+
+	t.Run("should download the index file only if it has changed", func(t *testing.T) {
+		fileBytes, err := os.ReadFile("testdata/local-index.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag := `"1"`
+		downloads := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			w.Header().Set("ETag", etag)
+			w.Write(fileBytes)
+		})
+		srv, err := startLocalServerForTests(handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer srv.Close()
+
+		r, err := NewChartRepository(&Entry{
+			Name: testRepo,
+			URL:  srv.URL,
+		}, getter.All(&cli.EnvSettings{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.CachePath = t.TempDir()
+
+		download := func(expectDownloads int) {
+			t.Helper()
+			idx, err := r.DownloadIndexFile()
+			if err != nil {
+				t.Fatalf("Failed to download index file to %s: %#v", idx, err)
+			}
+			if downloads != expectDownloads {
+				t.Errorf("Expected %d downloads of the index file, got %d", expectDownloads, downloads)
+			}
+			i, err := LoadIndexFile(idx)
+			if err != nil {
+				t.Fatalf("Index %q failed to parse: %s", testfile, err)
+			}
+			verifyLocalIndex(t, i)
+		}
+
+		download(1)
+		download(1)
+
+		// The index file is downloaded again when it has changed in the
+		// repository, or in the cache.
+		etag = `"2"`
+		download(2)
+		idx := filepath.Join(r.CachePath, helmpath.CacheIndexFile(testRepo))
+		if err := os.WriteFile(idx, append(fileBytes, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		download(3)
+		if err := os.Remove(filepath.Join(r.CachePath, helmpath.CacheChartsFile(testRepo))); err != nil {
+			t.Fatal(err)
+		}
+		download(4)
+	})
+}
+
+func TestDownloadIndexFileMirrors(t *testing.T) {
+	mirror, err := startLocalServerForTests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mirror.Close()
+	status := http.StatusBadGateway
+	primary, err := startLocalServerForTests(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	unreachable, err := startLocalServerForTests(http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable.Close()
+
+	r, err := NewChartRepository(&Entry{
+		Name:    testRepo,
+		URL:     primary.URL,
+		Mirrors: []string{unreachable.URL, mirror.URL},
+	}, getter.All(&cli.EnvSettings{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CachePath = t.TempDir()
+	var logs []string
+	r.Log = func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}
+
+	idx, err := r.DownloadIndexFile()
+	if err != nil {
+		t.Fatalf("Failed to download index file to %s: %#v", idx, err)
+	}
+	i, err := LoadIndexFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLocalIndex(t, i)
+	if len(logs) != 3 || logs[2] != fmt.Sprintf("Downloaded the index of %s from %s", primary.URL, mirror.URL) {
+		t.Errorf("Unexpected debug messages %q", logs)
+	}
+
+	// The mirrors are only used when the repository is unavailable.
+	status = http.StatusNotFound
+	if _, err := r.DownloadIndexFile(); err == nil {
+		t.Error("Expected an error when the index is not found in the repository")
+	}
+}
+
+func TestDownloadIndexFileMirrorCredentials(t *testing.T) {
+	fileBytes, err := os.ReadFile("testdata/local-index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authorized bool
+	mirror, err := startLocalServerForTests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, authorized = r.BasicAuth()
+		w.Write(fileBytes)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mirror.Close()
+	primary, err := startLocalServerForTests(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	for _, passCredentialsAll := range []bool{false, true} {
+		r, err := NewChartRepository(&Entry{
+			Name:               testRepo,
+			URL:                primary.URL,
+			Username:           "username",
+			Password:           "password",
+			PassCredentialsAll: passCredentialsAll,
+			Mirrors:            []string{mirror.URL},
+		}, getter.All(&cli.EnvSettings{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.CachePath = t.TempDir()
+		authorized = false
+		if _, err := r.DownloadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+		if authorized != passCredentialsAll {
+			t.Errorf("Expected credentials sent to the mirror to be %t with PassCredentialsAll %t", passCredentialsAll, passCredentialsAll)
+		}
+	}
+}
+
+func TestEntryMirrorURL(t *testing.T) {
+	e := &Entry{URL: "https://example.com/charts/?token=1"}
+	for _, tt := range []struct {
+		mirror, u, expect string
+		ok                bool
+	}{
+		{"https://mirror.example.com", "https://example.com/charts/foo-1.0.0.tgz?token=1", "https://mirror.example.com/foo-1.0.0.tgz", true},
+		{"https://mirror.example.com/stable/?key=2", "https://example.com/charts/nested/foo-1.0.0.tgz.prov", "https://mirror.example.com/stable/nested/foo-1.0.0.tgz.prov?key=2", true},
+		{"https://mirror.example.com", "https://example.com/other/foo-1.0.0.tgz", "", false},
+		{"https://mirror.example.com", "https://cdn.example.com/charts/foo-1.0.0.tgz", "", false},
+	} {
+		got, ok := e.MirrorURL(tt.mirror, tt.u)
+		if got != tt.expect || ok != tt.ok {
+			t.Errorf("MirrorURL(%q, %q) = %q, %t, expected %q, %t", tt.mirror, tt.u, got, ok, tt.expect, tt.ok)
+		}
+	}
+}
+
+func TestLoadIndexFileCache(t *testing.T) {
+	dir := t.TempDir()
+	idx := filepath.Join(dir, helmpath.CacheIndexFile(testRepo))
+	data, err := os.ReadFile(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(idx, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The parsed index is loaded from the cache made from the index file.
+	cached := NewIndexFile()
+	cached.Add(&chart.Metadata{APIVersion: "v2", Name: "cached", Version: "1.0.0"}, "cached-1.0.0.tgz", "http://example.com", "sha256:1234567890")
+	if err := writeIndexCache(idx, data, cached, indexCacheHeader{}); err != nil {
+		t.Fatal(err)
+	}
+	i, err := LoadIndexFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !i.Has("cached", "1.0.0") {
+		t.Error("Expected the index to be loaded from its cache")
+	}
+
+	// The cache is ignored once the index file has changed.
+	if err := os.WriteFile(idx, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	i, err = LoadIndexFile(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLocalIndex(t, i)
 }
 
 func verifyLocalIndex(t *testing.T, i *IndexFile) {
@@ -0,0 +1,112 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/internal/fileutil"
+)
+
+// indexCacheHeader is the first line of the cache of an index file.
+type indexCacheHeader struct {
+	// Digest is the sha256 digest of the index file the cache was made from.
+	Digest string `json:"digest"`
+	// URL, ETag and LastModified are the URL the index file was downloaded
+	// from and the validators of the response.
+	URL          string `json:"url,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// IndexCacheFile returns the path to the cache of the index file at path,
+// holding the parsed index along with the validators of the response it was
+// downloaded from.
+func IndexCacheFile(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".cache"
+}
+
+// writeIndexCache writes the cache of the index file data, parsed as i.
+func writeIndexCache(path string, data []byte, i *IndexFile, header indexCacheHeader) error {
+	header.Digest = indexDigest(data)
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if err := enc.Encode(i); err != nil {
+		return err
+	}
+	return fileutil.AtomicWriteFile(IndexCacheFile(path), &b, 0644)
+}
+
+// readIndexCacheHeader reads the header of the cache of the index file at
+// path.
+func readIndexCacheHeader(path string) (*indexCacheHeader, error) {
+	f, err := os.Open(IndexCacheFile(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeIndexCacheHeader(bufio.NewReader(f))
+}
+
+// loadIndexCache loads the parsed index from the cache of the index file data
+// at path. It fails if the cache was not made from data.
+func loadIndexCache(path string, data []byte) (*IndexFile, error) {
+	f, err := os.Open(IndexCacheFile(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	header, err := decodeIndexCacheHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if header.Digest != indexDigest(data) {
+		return nil, errors.Errorf("stale index cache for %s", path)
+	}
+	i := &IndexFile{}
+	if err := json.NewDecoder(r).Decode(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func decodeIndexCacheHeader(r *bufio.Reader) (*indexCacheHeader, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	header := &indexCacheHeader{}
+	return header, json.Unmarshal(line, header)
+}
+
+func indexDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}